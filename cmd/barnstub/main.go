@@ -0,0 +1,63 @@
+// Команда barnstub запускает заглушку barn manager для локальной разработки:
+//
+//	go run ./cmd/barnstub --addr 127.0.0.1:8082 --inventory configs/barn_inventory.json --latency 200ms --error-rate 0.2
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"menu_manager/internal/barnstub"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8082", "адрес, на котором слушает заглушка")
+	inventory := flag.String("inventory", "configs/barn_inventory.json", "JSON-файл с запасами продуктов")
+	latency := flag.Duration("latency", 0, "задержка перед каждым ответом")
+	errorRate := flag.Float64("error-rate", 0, "доля запросов от 0 до 1, на которые отвечать ошибкой")
+	errorStatus := flag.Int("error-status", http.StatusServiceUnavailable, "код ответа с ошибкой")
+	malformed := flag.Bool("malformed", false, "отвечать телом, которое не разбирается как JSON")
+	flag.Parse()
+
+	if *errorRate < 0 || *errorRate > 1 {
+		log.Fatalf("--error-rate должен быть от 0 до 1, получено %v", *errorRate)
+	}
+
+	products, err := barnstub.LoadInventory(*inventory)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stub := barnstub.New(products)
+	stub.SetBehavior(barnstub.Behavior{
+		Latency:     *latency,
+		ErrorRate:   *errorRate,
+		ErrorStatus: *errorStatus,
+		Malformed:   *malformed,
+	})
+
+	server := &http.Server{Addr: *addr, Handler: stub, ReadHeaderTimeout: 5 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	go func() {
+		log.Printf("заглушка barn manager слушает %s, продуктов в запасах: %d", *addr, len(products))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("не удалось запустить заглушку: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Fatalf("не удалось остановить заглушку: %v", err)
+	}
+}
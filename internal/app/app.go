@@ -2,18 +2,40 @@ package app
 
 import (
 	"context"
+	"crypto/subtle"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"menu_manager/internal/cache"
+	"menu_manager/internal/health"
+	"menu_manager/internal/logging"
 	"menu_manager/internal/menu"
+	memstorage "menu_manager/internal/menu/memory"
 	storage "menu_manager/internal/menu/mysql"
+	pgstorage "menu_manager/internal/menu/postgres"
+	sqlitestorage "menu_manager/internal/menu/sqlite"
+	"menu_manager/internal/migrate"
+	"menu_manager/internal/profile"
+	profilememstorage "menu_manager/internal/profile/memory"
+	profilestorage "menu_manager/internal/profile/mysql"
+	profilepgstorage "menu_manager/internal/profile/postgres"
+	profilesqlitestorage "menu_manager/internal/profile/sqlite"
+	"menu_manager/internal/scheduler"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // App это структура приложения
@@ -22,54 +44,135 @@ type App struct {
 	router  *chi.Mux
 	http    *http.Server
 	barnURL string
+
+	logger *slog.Logger
+
+	// metrics реестр метрик, которые отдаются по /metrics
+	metrics *prometheus.Registry
+	// health проверки готовности для /readyz, зависимости добавляются в Setup
+	health *health.Checker
+
+	// scheduler заранее переносит устаревшие меню, nil если отключен в конфиге
+	scheduler *scheduler.Scheduler
 }
 
 // New создает новое приложение
 func New(ctx context.Context, config *Config) (*App, error) {
+	// Журнал из секции log конфига. Он же становится журналом по умолчанию,
+	// чтобы пакеты без своего журнала писали в том же формате
+	logger, err := logging.New(os.Stderr, config.Log.Level, config.Log.Format, config.Log.PseudonymKey)
+	if err != nil {
+		return nil, fmt.Errorf("некорректные настройки журнала: %w", err)
+	}
+	slog.SetDefault(logger)
+	if config.Log.PseudonymKey == "" {
+		logger.Warn("не задан log.pseudonymkey, псевдонимы пользователей в журнале изменятся после перезапуска")
+	}
+
 	r := chi.NewRouter()
 
+	// Идентификатор запроса и метрики HTTP-запросов, middleware подключаются до регистрации маршрутов
+	r.Use(logging.Middleware(logger))
+	metrics := newMetricsRegistry()
+	r.Use(newHTTPMetrics(metrics).middleware)
+
+	// Проверки живости и готовности для оркестратора
+	checker := health.NewChecker(health.DefaultTimeout)
+	r.Get("/healthz", checker.Liveness)
+	r.Get("/readyz", checker.Readiness)
+
 	return &App{
 		config: config,
 		router: r,
 		http: &http.Server{
 			Addr:    fmt.Sprintf("%s:%s", config.Host, config.Port),
 			Handler: r,
-			// Разумные значения по умолчанию для таймаутов
-			ReadTimeout:       15 * time.Second,
-			ReadHeaderTimeout: 5 * time.Second,
-			WriteTimeout:      15 * time.Second,
-			IdleTimeout:       30 * time.Second,
+			// Таймауты из секции server конфига
+			ReadTimeout:       config.Server.ReadTimeout,
+			ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+			WriteTimeout:      config.Server.WriteTimeout,
+			IdleTimeout:       config.Server.IdleTimeout,
 		},
 		barnURL: config.BarnURL,
+		logger:  logger,
+		metrics: metrics,
+		health:  checker,
 	}, nil
 }
 
 // Setup инициализирует приложение
 func (a *App) Setup(ctx context.Context, dsn string, barnURL string) error {
-	// Инициализация подключения к базе данных
-	db, err := sqlx.ConnectContext(ctx, "mysql", dsn)
+	// Инициализация хранилищ меню и профилей выбранного в конфиге типа
+	store, profileStore, err := a.setupStorage(ctx, dsn)
 	if err != nil {
-		return fmt.Errorf("не удалось подключиться к базе данных: %w", err)
+		return err
 	}
 
-	// Тестирование подключения
-	if err := db.PingContext(ctx); err != nil {
-		return fmt.Errorf("не удалось выполнить ping базы данных: %w", err)
-	}
+	// Метрики приложения в формате Prometheus
+	a.router.Handle("/metrics", promhttp.HandlerFor(a.metrics, promhttp.HandlerOpts{Registry: a.metrics}))
 
 	// log.Println(barnURL)
 
 	// Инициализация клиента для barn manaager
-	client := menu.NewClient(barnURL)
+	barn := a.config.Barn
+	client := menu.NewClient(barnURL,
+		menu.WithTimeout(barn.Timeout),
+		menu.WithRetries(barn.Retries, barn.RetryBaseDelay, barn.RetryMaxDelay),
+		menu.WithCircuitBreaker(barn.BreakerThreshold, barn.BreakerCooldown),
+		menu.WithClientLogger(a.logger))
+
+	// barn manager не критичен для готовности: без него GetMeal отвечает без списка покупок
+	a.health.Add("barn", false, func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"breaker_state": client.Stats().BreakerState}, client.Ping(ctx)
+	})
+
+	// Счетчики запросов к barn manager и состояние circuit breaker
+	if a.config.Debug.Enabled {
+		a.router.Get("/debug/barn", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(client.Stats())
+		})
+	}
+
+	// Инициализация сервиса профилей пользователей
+	profileService := profile.NewService(profileStore)
 
-	// Инициализация хранилища menu
-	store := storage.NewStorage(db)
+	// Инициализация и регистрация обработчиков профилей
+	profileHandler := profile.NewHandler(a.router, profileService)
+	profileHandler.Register()
 
 	// Инициализация сервиса menu
-	service := menu.NewService(store, client)
+	opts := []menu.Option{menu.WithProfiles(profileService), menu.WithRescheduleMetrics(a.metrics), menu.WithLogger(a.logger)}
+	if a.config.Menu.GracePeriod > 0 {
+		opts = append(opts, menu.WithGracePeriod(a.config.Menu.GracePeriod))
+	}
+	if a.config.Scheduler.Enabled {
+		opts = append(opts, menu.WithRescheduleLookahead(a.config.Scheduler.Lookahead))
+	}
+	if a.config.Menu.ShoppingListFallback > 0 {
+		opts = append(opts, menu.WithShoppingListFallback(a.config.Menu.ShoppingListFallback))
+	}
+	// Метрики оборачивают хранилище и клиент под кэшем, поэтому учитываются только обращения к БД и barn manager
+	cachedStore, cachedClient := a.setupCache(menu.NewMeteredStore(store, a.metrics), menu.NewMeteredClient(client, a.metrics))
+	service := menu.NewService(cachedStore, cachedClient, opts...)
+
+	// Инициализация фонового переноса устаревших меню
+	if a.config.Scheduler.Enabled {
+		schedule, err := scheduler.ParseSchedule(a.config.Scheduler.Schedule)
+		if err != nil {
+			return fmt.Errorf("некорректное расписание планировщика: %w", err)
+		}
+		job := func(ctx context.Context) error {
+			rescheduled, err := service.RescheduleStale(ctx)
+			a.logger.InfoContext(ctx, "перенесены устаревшие меню", "rescheduled", rescheduled)
+			return err
+		}
+		a.scheduler = scheduler.New("reschedule-stale-menus", utcSchedule{schedule}, job,
+			scheduler.WithLocker(store, a.config.Scheduler.LockTTL))
+	}
 
 	// Инициализация и регистрация обработчиков menu
-	handler := menu.NewHandler(a.router, service)
+	handler := menu.NewHandler(a.router, service, menu.WithHandlerLogger(a.logger))
 	handler.Register()
 
 	return nil
@@ -78,14 +181,21 @@ func (a *App) Setup(ctx context.Context, dsn string, barnURL string) error {
 // Start запускает приложение
 func (a *App) Start() error {
 	// Создание контекста, который будет отменен при получении сигнала прерывания
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	// или SIGTERM, которым оркестратор останавливает под
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// Запуск фонового планировщика. Его жизненным циклом управляет Stop, а не сигнал
+	if a.scheduler != nil {
+		a.scheduler.Start(context.Background())
+	}
+
 	// Запуск сервера в горутине
 	go func() {
-		log.Printf("запуск веб-сервера на %s", a.http.Addr)
+		a.logger.Info("запуск веб-сервера", "addr", a.http.Addr)
 		if err := a.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("не удалось запустить сервер: %v", err)
+			a.logger.Error("не удалось запустить сервер", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -94,10 +204,14 @@ func (a *App) Start() error {
 
 	// Восстановление стандартного поведения при получении сигнала прерывания и уведомление пользователя о завершении работы
 	stop()
-	log.Println("плавное завершение работы, нажмите Ctrl+C еще раз для принудительного завершения")
+	a.logger.Info("плавное завершение работы, нажмите Ctrl+C еще раз для принудительного завершения")
+
+	// Сервис перестает быть готовым, но еще обслуживает запросы, пока оркестратор уводит с него трафик
+	a.health.Drain()
+	time.Sleep(a.config.Server.DrainDelay)
 
 	// Создание дедлайна для ожидания завершения
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Завершение работы сервера
@@ -105,6 +219,200 @@ func (a *App) Start() error {
 		return fmt.Errorf("не удалось завершить работу сервера: %w", err)
 	}
 
-	log.Println("сервер успешно завершил работу")
+	// Остановка планировщика: текущий перенос меню отменяется, ждем его завершения
+	if a.scheduler != nil {
+		if err := a.scheduler.Stop(shutdownCtx); err != nil {
+			return err
+		}
+	}
+
+	a.logger.Info("сервер успешно завершил работу")
 	return nil
 }
+
+// setupCache оборачивает хранилище меню и клиент barn manager кэшем, если он включен в конфиге,
+// и регистрирует обработчики кэша
+func (a *App) setupCache(store menu.Store, client menu.Client) (menu.Store, menu.Client) {
+	settings := a.config.Cache
+	if !settings.Enabled {
+		return store, client
+	}
+
+	c := cache.NewMemory(settings.MaxEntries)
+	cachedStore := menu.NewCachedStore(store, c, settings.MenuTTL, settings.MealTTL)
+	cachedClient := menu.NewCachedClient(client, c, settings.ShoppingListTTL)
+
+	// Счетчики попаданий и промахов кэша: в /metrics всегда, в /debug/cache только в режиме отладки
+	a.metrics.MustRegister(newCacheCollector(cachedStore.CacheStats, cachedClient.CacheStats))
+	if a.config.Debug.Enabled {
+		a.router.Get("/debug/cache", func(w http.ResponseWriter, r *http.Request) {
+			stats := cachedStore.CacheStats()
+			maps.Copy(stats, cachedClient.CacheStats())
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(stats)
+		})
+	}
+
+	// barn manager сообщает об изменении запасов, сохраненные списки покупок больше не актуальны.
+	// Сброс кэша заставляет каждый запрос обращаться к barn manager, поэтому вызывать его может только
+	// знающий общий секрет; без секрета списки покупок обновляются по истечении TTL
+	if secret := a.config.Barn.WebhookSecret; secret != "" {
+		a.router.Post("/api/v1/barn/inventory-changed", func(w http.ResponseWriter, r *http.Request) {
+			if !validBearer(r, secret) {
+				http.Error(w, "неверный секрет", http.StatusUnauthorized)
+				return
+			}
+			if err := cachedClient.InvalidateInventory(r.Context()); err != nil {
+				a.logger.ErrorContext(r.Context(), "не удалось сбросить кэш списков покупок", "error", err)
+				http.Error(w, "не удалось сбросить кэш", http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+
+	return cachedStore, cachedClient
+}
+
+// validBearer проверяет, что запрос передает secret в заголовке Authorization: Bearer.
+// Сравнение за постоянное время не выдает секрет по времени ответа
+func validBearer(r *http.Request, secret string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
+
+// addMigrationsCheck добавляет проверку готовности версии схемы. Схема новее встроенных миграций допустима:
+// ее уже обновил следующий релиз, который выкатывается рядом
+func (a *App) addMigrationsCheck(db *sqlx.DB, dialect string) error {
+	migrator, err := migrate.New(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	latest := migrator.Latest()
+	a.health.Add("migrations", true, func(ctx context.Context) (map[string]any, error) {
+		version, dirty, err := migrator.Version(ctx)
+		details := map[string]any{"version": version, "latest": latest}
+		switch {
+		case err != nil:
+			return details, err
+		case dirty:
+			return details, fmt.Errorf("%w на версии %d", migrate.ErrDirty, version)
+		case version < latest:
+			return details, fmt.Errorf("не применены миграции: версия схемы %d, последняя миграция %d", version, latest)
+		}
+		return details, nil
+	})
+	return nil
+}
+
+// menuStore хранилище меню, которое также служит блокировкой фонового планировщика
+type menuStore interface {
+	menu.Store
+	scheduler.Locker
+}
+
+// setupStorage создает хранилища меню и профилей по значению storage.driver из конфига
+func (a *App) setupStorage(ctx context.Context, dsn string) (menuStore, profile.Store, error) {
+	if a.config.Storage.Driver == "memory" {
+		store := memstorage.NewStorage()
+		profileStore := profilememstorage.NewStorage()
+		if fixture := a.config.Storage.Fixture; fixture != "" {
+			if err := store.LoadFixtureFile(fixture); err != nil {
+				return nil, nil, err
+			}
+			if err := profileStore.LoadFixtureFile(fixture); err != nil {
+				return nil, nil, err
+			}
+		}
+		a.logger.Warn("используется хранилище в памяти, данные не сохранятся после перезапуска")
+		return store, profileStore, nil
+	}
+
+	db, dialect, err := a.openDB(ctx, dsn)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Статистика пула соединений
+	a.metrics.MustRegister(collectors.NewDBStatsCollector(db.DB, dialect))
+
+	// Готовность: база отвечает и схема не отстает от встроенных миграций
+	a.health.Add("db", true, func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"driver": dialect}, db.PingContext(ctx)
+	})
+	if err := a.addMigrationsCheck(db, dialect); err != nil {
+		return nil, nil, err
+	}
+
+	// Применение встроенных миграций, если это включено в конфиге
+	if a.config.Storage.MigrateOnStart {
+		migrator, err := migrate.New(db, dialect)
+		if err != nil {
+			return nil, nil, err
+		}
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("не удалось применить миграции: %w", err)
+		}
+		a.logger.Info("применены миграции", "applied", applied)
+	}
+
+	switch dialect {
+	case "postgres":
+		return pgstorage.NewStorage(db, pgstorage.WithLogger(a.logger)), profilepgstorage.NewStorage(db), nil
+	case "sqlite":
+		return sqlitestorage.NewStorage(db, sqlitestorage.WithLogger(a.logger)), profilesqlitestorage.NewStorage(db), nil
+	default:
+		return storage.NewStorage(db, storage.WithLogger(a.logger)), profilestorage.NewStorage(db), nil
+	}
+}
+
+// openDB подключается к SQL-базе, выбранной в storage.driver, и возвращает ее диалект для миграций
+func (a *App) openDB(ctx context.Context, dsn string) (*sqlx.DB, string, error) {
+	switch a.config.Storage.Driver {
+	case "", "mysql":
+		db, err := a.connect(ctx, "mysql", dsn)
+		return db, "mysql", err
+
+	case "postgres":
+		db, err := a.connect(ctx, "pgx", dsn)
+		return db, "postgres", err
+
+	case "sqlite":
+		db, err := sqlitestorage.Open(ctx, a.config.Storage.Path)
+		return db, "sqlite", err
+
+	case "memory":
+		return nil, "", fmt.Errorf("хранилище memory не использует базу данных")
+
+	default:
+		return nil, "", fmt.Errorf("неизвестное хранилище %q, ожидается mysql, postgres, sqlite или memory", a.config.Storage.Driver)
+	}
+}
+
+// connect подключается к базе данных, настраивает пул соединений и проверяет соединение
+func (a *App) connect(ctx context.Context, driverName, dsn string) (*sqlx.DB, error) {
+	// Инициализация подключения к базе данных
+	db, err := sqlx.ConnectContext(ctx, driverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось подключиться к базе данных: %w", err)
+	}
+	db.SetMaxOpenConns(a.config.DB.MaxOpenConns)
+	db.SetMaxIdleConns(a.config.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(a.config.DB.ConnMaxLifetime)
+
+	// Тестирование подключения
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("не удалось выполнить ping базы данных: %w", err)
+	}
+	return db, nil
+}
+
+// utcSchedule вычисляет расписание планировщика в UTC независимо от часового пояса сервера
+type utcSchedule struct {
+	scheduler.Schedule
+}
+
+func (s utcSchedule) Next(t time.Time) time.Time {
+	return s.Schedule.Next(t.UTC())
+}
@@ -0,0 +1,397 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"menu_manager/internal/barnstub"
+	"menu_manager/internal/cache"
+	"menu_manager/internal/health"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newMemoryApp собирает приложение на хранилище в памяти с данными из configs/fixture.json
+// и заглушкой barn manager с запасами из configs/barn_inventory.json
+func newMemoryApp(t *testing.T, configure ...func(*Config)) (*App, *barnstub.Server) {
+	t.Helper()
+
+	products, err := barnstub.LoadInventory("../../configs/barn_inventory.json")
+	assert.NoError(t, err)
+	barn := barnstub.Start(products)
+	t.Cleanup(barn.Close)
+
+	config := &Config{}
+	config.Storage.Driver = "memory"
+	config.Storage.Fixture = "../../configs/fixture.json"
+	for _, c := range configure {
+		c(config)
+	}
+
+	a, err := New(context.Background(), config)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Setup(context.Background(), "", barn.URL))
+	return a, barn
+}
+
+func TestSetup_MemoryStorage(t *testing.T) {
+	a, _ := newMemoryApp(t)
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/menus/entries?user_id=kolya", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var entries []map[string]any
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&entries))
+	assert.Len(t, entries, 2)
+
+	rec = httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/profiles/dan", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"diet":"vegetarian"`)
+}
+
+func TestSetup_MemoryStorage_GetMeal(t *testing.T) {
+	a, _ := newMemoryApp(t, func(config *Config) { config.Debug.Enabled = true })
+
+	// меню из фикстуры устарело, поэтому запрос еще и переносит его на будущее
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=kolya", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Meal struct {
+			DishNames []string `json:"dishname"`
+		} `json:"meal"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.NotEmpty(t, body.Meal.DishNames)
+
+	rec = httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/barn", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"requests":1,"attempts":1,"retries":0,"failures":0,"rejected":0,"breaker_state":"closed"}`, rec.Body.String())
+}
+
+func TestSetup_DebugDisabledByDefault(t *testing.T) {
+	a, _ := newMemoryApp(t, func(config *Config) { config.Cache.Enabled = true })
+
+	// отладочные обработчики и сброс кэша без секрета не регистрируются
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/debug/barn", nil),
+		httptest.NewRequest(http.MethodGet, "/debug/cache", nil),
+		httptest.NewRequest(http.MethodPost, "/api/v1/barn/inventory-changed", nil),
+	} {
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code, req.URL.Path)
+	}
+}
+
+func TestSetup_MemoryStorage_GetMealWithoutBarn(t *testing.T) {
+	a, barn := newMemoryApp(t)
+	barn.SetBehavior(barnstub.Behavior{ErrorRate: 1})
+
+	// barn manager недоступен, но прием пищи все равно возвращается
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=kolya", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Meal struct {
+			ID string `json:"id"`
+		} `json:"meal"`
+		ShoppingList *json.RawMessage `json:"shopping_list"`
+		Partial      bool             `json:"partial"`
+		Warnings     []string         `json:"warnings"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.NotEmpty(t, body.Meal.ID)
+	assert.Nil(t, body.ShoppingList)
+	assert.True(t, body.Partial)
+	assert.NotEmpty(t, body.Warnings)
+}
+
+func TestSetup_MemoryStorage_Cache(t *testing.T) {
+	a, barn := newMemoryApp(t, func(config *Config) {
+		config.Cache.Enabled = true
+		config.Cache.MenuTTL = time.Minute
+		config.Cache.MealTTL = time.Minute
+		config.Cache.ShoppingListTTL = time.Minute
+		config.Barn.WebhookSecret = "s3cret"
+		config.Debug.Enabled = true
+	})
+
+	getMeal := func() {
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=kolya", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	// повторный запрос обходится без barn manager
+	getMeal()
+	getMeal()
+	assert.Len(t, barn.Requests(), 1)
+
+	inventoryChanged := func(authorization string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/barn/inventory-changed", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		a.router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// без общего секрета кэш не сбрасывается
+	assert.Equal(t, http.StatusUnauthorized, inventoryChanged(""))
+	assert.Equal(t, http.StatusUnauthorized, inventoryChanged("Bearer guess"))
+	getMeal()
+	assert.Len(t, barn.Requests(), 1)
+
+	// изменение запасов сбрасывает списки покупок
+	assert.Equal(t, http.StatusNoContent, inventoryChanged("Bearer s3cret"))
+	getMeal()
+	assert.Len(t, barn.Requests(), 2)
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/cache", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var stats map[string]cache.Stats
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 2, Invalidations: 1}, stats["shopping_lists"])
+	assert.NotZero(t, stats["meals"].Hits)
+	assert.NotZero(t, stats["menus"].Hits)
+}
+
+func TestSetup_MemoryStorage_CacheMetrics(t *testing.T) {
+	a, _ := newMemoryApp(t, func(config *Config) {
+		config.Cache.Enabled = true
+		config.Cache.MenuTTL = time.Minute
+		config.Cache.MealTTL = time.Minute
+		config.Cache.ShoppingListTTL = time.Minute
+	})
+
+	for i := 0; i < 2; i++ {
+		a.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=kolya", nil))
+	}
+
+	// счетчики кэша доступны в /metrics и без режима отладки
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `menu_manager_cache_hits_total{cache="shopping_lists"} 1`)
+	assert.Contains(t, body, `menu_manager_cache_misses_total{cache="shopping_lists"} 1`)
+	assert.Contains(t, body, `menu_manager_cache_hits_total{cache="menus"}`)
+	assert.Contains(t, body, `menu_manager_cache_invalidations_total{cache="meals"} 0`)
+}
+
+func TestSetup_MemoryStorage_Metrics(t *testing.T) {
+	a, _ := newMemoryApp(t)
+
+	for _, target := range []string{"/api/v1/menus/getMeal?user_id=kolya", "/api/v1/dishes/unknown", "/no/such/route"} {
+		a.router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	// запросы учитываются по шаблону маршрута, а не по пути
+	assert.Contains(t, body, `menu_manager_http_requests_total{method="GET",route="/api/v1/menus/getMeal",status="200"} 1`)
+	assert.Contains(t, body, `menu_manager_http_requests_total{method="GET",route="/api/v1/dishes/{dishID}",status="404"} 1`)
+	assert.Contains(t, body, `menu_manager_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	assert.Contains(t, body, `menu_manager_store_operation_duration_seconds_count{op="LoadMenu"}`)
+	assert.Contains(t, body, `menu_manager_store_errors_total{op="LoadDish",reason="no_data"} 1`)
+	assert.Contains(t, body, `menu_manager_barn_request_duration_seconds_count{status="200"} 1`)
+	assert.Contains(t, body, `menu_manager_reschedules_total{result="ok"`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+// readyz возвращает код и разобранный ответ /readyz
+func readyz(t *testing.T, a *App) (int, health.Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report health.Report
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestSetup_MemoryStorage_Health(t *testing.T) {
+	a, barn := newMemoryApp(t)
+
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	code, report := readyz(t, a)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", report.Checks["barn"].Status)
+	assert.Equal(t, "closed", report.Checks["barn"].Details["breaker_state"])
+
+	// без barn manager меню отдается без списка покупок, поэтому сервис остается готовым
+	barn.Close()
+	code, report = readyz(t, a)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "error", report.Checks["barn"].Status)
+
+	// при завершении из сервиса уводят трафик, а liveness не меняется
+	a.health.Drain()
+	code, report = readyz(t, a)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+
+	rec = httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestStart_SIGTERMDrainsBeforeShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	assert.NoError(t, err)
+	listener.Close()
+
+	a, _ := newMemoryApp(t, func(config *Config) {
+		config.Host = "127.0.0.1"
+		config.Port = port
+		config.Server.DrainDelay = 500 * time.Millisecond
+		config.Server.ShutdownTimeout = time.Second
+	})
+	url := "http://127.0.0.1:" + port
+
+	done := make(chan error, 1)
+	go func() { done <- a.Start() }()
+
+	// сервер слушает, значит обработчик сигналов уже установлен
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/readyz")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, syscall.Kill(syscall.Getpid(), syscall.SIGTERM))
+
+	// пока идет задержка, сервер еще принимает запросы, но уже не готов
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/readyz")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		var report health.Report
+		return resp.StatusCode == http.StatusServiceUnavailable &&
+			json.NewDecoder(resp.Body).Decode(&report) == nil &&
+			report.Status == health.StatusShuttingDown
+	}, 400*time.Millisecond, 10*time.Millisecond)
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Start не завершился после SIGTERM")
+	}
+	_, err = http.Get(url + "/readyz")
+	assert.Error(t, err)
+}
+
+func TestSetup_SQLiteWithoutMigrations_NotReady(t *testing.T) {
+	config := &Config{}
+	config.Storage.Driver = "sqlite"
+	config.Storage.Path = filepath.Join(t.TempDir(), "menu.db")
+
+	a, err := New(context.Background(), config)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Setup(context.Background(), "", "http://localhost"))
+
+	code, report := readyz(t, a)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, "ok", report.Checks["db"].Status)
+	assert.Equal(t, "error", report.Checks["migrations"].Status)
+
+	// после миграций сервис становится готовым без перезапуска
+	assert.NoError(t, a.Migrate(context.Background(), "", []string{"up"}, &bytes.Buffer{}))
+	code, report = readyz(t, a)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, float64(6), report.Checks["migrations"].Details["version"])
+}
+
+func TestSetup_UnknownStorage(t *testing.T) {
+	config := &Config{}
+	config.Storage.Driver = "redis"
+
+	a, err := New(context.Background(), config)
+	assert.NoError(t, err)
+	assert.ErrorContains(t, a.Setup(context.Background(), "", "http://localhost"), "redis")
+}
+
+func TestSetup_SQLiteMigrateOnStart(t *testing.T) {
+	config := &Config{}
+	config.Storage.Driver = "sqlite"
+	config.Storage.Path = filepath.Join(t.TempDir(), "menu.db")
+	config.Storage.MigrateOnStart = true
+
+	a, err := New(context.Background(), config)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Setup(context.Background(), "", "http://localhost"))
+
+	// схема создана миграциями, поэтому запрос к пустой базе доходит до хранилища
+	rec := httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/profiles/dan", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	// статистика пула соединений публикуется вместе с остальными метриками
+	rec = httptest.NewRecorder()
+	a.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `go_sql_open_connections{db_name="sqlite"}`)
+
+	code, report := readyz(t, a)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusReady, report.Status)
+	assert.Equal(t, "ok", report.Checks["db"].Status)
+	assert.Equal(t, "ok", report.Checks["migrations"].Status)
+}
+
+func TestMigrate(t *testing.T) {
+	config := &Config{}
+	config.Storage.Driver = "sqlite"
+	config.Storage.Path = filepath.Join(t.TempDir(), "menu.db")
+
+	a, err := New(context.Background(), config)
+	assert.NoError(t, err)
+
+	var out bytes.Buffer
+	assert.NoError(t, a.Migrate(context.Background(), "", []string{"up"}, &out))
+	assert.Equal(t, "применено миграций: 6\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, a.Migrate(context.Background(), "", []string{"down", "2"}, &out))
+	assert.Equal(t, "откачено миграций: 2\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, a.Migrate(context.Background(), "", []string{"status"}, &out))
+	assert.Contains(t, out.String(), "версия схемы: 4\n")
+	assert.Contains(t, out.String(), "[x] 000004_add_reschedule_strategy\n[ ] 000005_create_scheduler_locks_table\n")
+
+	assert.ErrorContains(t, a.Migrate(context.Background(), "", []string{"sideways"}, &out), "sideways")
+	assert.ErrorContains(t, a.Migrate(context.Background(), "", []string{"down", "0"}, &out), "использование")
+}
@@ -1,35 +1,336 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"menu_manager/internal/cache"
+	"menu_manager/internal/logging"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/scheduler"
+	"net/url"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
+// EnvPrefix префикс переменных окружения, переопределяющих конфиг.
+// Имя переменной составляется из пути к полю в yaml: MENU_MANAGER_DB_DSN, MENU_MANAGER_SERVER_READTIMEOUT
+const EnvPrefix = "MENU_MANAGER"
+
 // Config представляет конфигурацию приложения
 type Config struct {
 	Host    string
 	Port    string
 	BarnURL string
-	DB      struct {
+	Server  struct {
+		// Таймауты HTTP-сервера, 0 — без ограничения
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+		// ShutdownTimeout сколько ждать завершения запросов и фоновых задач при остановке
+		ShutdownTimeout time.Duration
+		// DrainDelay сколько после сигнала остановки /readyz отвечает 503 до закрытия сервера,
+		// чтобы оркестратор успел убрать экземпляр из балансировки
+		DrainDelay time.Duration
+	}
+	Barn struct {
+		// Timeout ограничивает время одного запроса к barn manager, 0 — без ограничения
+		Timeout time.Duration
+		// Retries сколько раз повторять запрос после сетевой ошибки, таймаута, 429 или 5xx
+		Retries int
+		// Задержка между повторами растет от RetryBaseDelay до RetryMaxDelay со случайным разбросом
+		RetryBaseDelay time.Duration
+		RetryMaxDelay  time.Duration
+		// После BreakerThreshold ошибок подряд запросы к barn manager не отправляются в течение BreakerCooldown,
+		// 0 отключает circuit breaker
+		BreakerThreshold int
+		BreakerCooldown  time.Duration
+		// WebhookSecret общий секрет, который barn manager передает в заголовке Authorization: Bearer
+		// при вызове /api/v1/barn/inventory-changed. Без секрета обработчик не регистрируется
+		WebhookSecret string
+	}
+	DB struct {
 		DSN string
+		// Размеры пула соединений с MySQL и PostgreSQL, 0 — без ограничения
+		MaxOpenConns int
+		MaxIdleConns int
+		// ConnMaxLifetime через сколько соединение закрывается и открывается заново, 0 — без ограничения
+		ConnMaxLifetime time.Duration
+	}
+	Storage struct {
+		// Driver хранилище данных: mysql (по умолчанию), postgres, sqlite или memory для локальной разработки без БД.
+		// mysql и postgres подключаются по db.dsn
+		Driver string
+		// Path путь к файлу базы для хранилища sqlite
+		Path string
+		// Fixture путь к JSON-файлу с начальными данными для хранилища memory
+		Fixture string
+		// MigrateOnStart применяет встроенные миграции при запуске сервиса
+		MigrateOnStart bool
+	}
+	Menu struct {
+		// GracePeriod сколько времени после начала прием пищи еще считается текущим
+		GracePeriod time.Duration
+		// ShoppingListFallback сколько хранить последний список покупок пользователя, чтобы вернуть его,
+		// когда barn manager недоступен. 0 отключает запасной вариант
+		ShoppingListFallback time.Duration
+	}
+	Cache struct {
+		// Enabled включает кэш меню, приемов пищи и списков покупок в памяти процесса
+		Enabled bool
+		// MaxEntries сколько значений хранит кэш
+		MaxEntries int
+		// Сколько хранятся меню пользователя, прием пищи и список покупок.
+		// Изменения через сервис сбрасывают кэш сразу, изменения другими экземплярами видны по истечении срока
+		MenuTTL         time.Duration
+		MealTTL         time.Duration
+		ShoppingListTTL time.Duration
+	}
+	Log struct {
+		// Level минимальный уровень записей журнала: debug, info, warn или error
+		Level string
+		// Format формат записей: text для чтения человеком или json для сборщиков журналов
+		Format string
+		// PseudonymKey секретный ключ HMAC, с которым id пользователей заменяются в журнале псевдонимами.
+		// Без ключа он создается случайным при запуске, и псевдонимы пользователя меняются после перезапуска
+		PseudonymKey string
 	}
+	Debug struct {
+		// Enabled открывает отладочные обработчики /debug/*. Они не требуют авторизации,
+		// поэтому по умолчанию выключены и включаются только во внутренней сети
+		Enabled bool
+	}
+	Scheduler struct {
+		// Enabled включает фоновый перенос устаревших меню
+		Enabled bool
+		// Schedule расписание в формате cron из пяти полей или "@every 15m", вычисляется в UTC
+		Schedule string
+		// LockTTL время жизни блокировки в БД, защищающей от одновременного запуска на нескольких экземплярах
+		LockTTL time.Duration
+		// Lookahead за сколько до окончания предстоящих приемов пищи меню переносится вперед
+		Lookahead time.Duration
+	}
+}
+
+// DefaultConfig возвращает конфигурацию со значениями по умолчанию для необязательных полей
+func DefaultConfig() *Config {
+	config := &Config{Host: "127.0.0.1"}
+	config.Server.ReadTimeout = 15 * time.Second
+	config.Server.ReadHeaderTimeout = 5 * time.Second
+	config.Server.WriteTimeout = 15 * time.Second
+	config.Server.IdleTimeout = 30 * time.Second
+	config.Server.ShutdownTimeout = 15 * time.Second
+	config.Server.DrainDelay = 5 * time.Second
+	config.Barn.Timeout = menu.DefaultBarnTimeout
+	config.Barn.Retries = menu.DefaultBarnRetries
+	config.Barn.RetryBaseDelay = menu.DefaultBarnRetryBaseDelay
+	config.Barn.RetryMaxDelay = menu.DefaultBarnRetryMaxDelay
+	config.Barn.BreakerThreshold = menu.DefaultBarnBreakerThreshold
+	config.Barn.BreakerCooldown = menu.DefaultBarnBreakerCooldown
+	config.DB.MaxOpenConns = 10
+	config.DB.MaxIdleConns = 10
+	config.DB.ConnMaxLifetime = 30 * time.Minute
+	config.Storage.Driver = "mysql"
+	config.Cache.MaxEntries = cache.DefaultMaxEntries
+	config.Cache.MenuTTL = time.Minute
+	config.Cache.MealTTL = 10 * time.Minute
+	config.Cache.ShoppingListTTL = time.Minute
+	config.Log.Level = "info"
+	config.Log.Format = "text"
+	config.Scheduler.Schedule = "*/15 * * * *"
+	config.Scheduler.LockTTL = scheduler.DefaultLockTTL
+	config.Scheduler.Lookahead = 24 * time.Hour
+	return config
 }
 
-// NewConfig создает конфигурацию приложения из yaml файла
+// NewConfig создает конфигурацию приложения: значения по умолчанию дополняются yaml файлом,
+// затем переменными окружения с префиксом EnvPrefix. Некорректная конфигурация возвращается ошибкой
 func NewConfig(configPath string) (*Config, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("error reading config file: %w", err)
 	}
 
-	config := &Config{}
+	config := DefaultConfig()
 
 	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, fmt.Errorf("error parsing config file: %w", err)
 	}
 
-	// log.Println(config)
+	if err := applyEnv(reflect.ValueOf(config).Elem(), EnvPrefix); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
 	return config, nil
 }
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(field, format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+	}
+
+	if c.Port == "" {
+		fail("port", "не задан")
+	} else if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("port", "ожидается число от 1 до 65535, получено %q", c.Port)
+	}
+
+	if c.BarnURL == "" {
+		fail("barnurl", "не задан")
+	} else if u, err := url.Parse(c.BarnURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fail("barnurl", "ожидается адрес вида http://host:port, получено %q", c.BarnURL)
+	}
+
+	switch c.Storage.Driver {
+	case "", "mysql", "postgres":
+		if c.DB.DSN == "" {
+			fail("db.dsn", "не задан, он обязателен для хранилища %s", c.Storage.Driver)
+		}
+	case "sqlite":
+		if c.Storage.Path == "" {
+			fail("storage.path", "не задан, он обязателен для хранилища sqlite")
+		}
+	case "memory":
+	default:
+		fail("storage.driver", "ожидается mysql, postgres, sqlite или memory, получено %q", c.Storage.Driver)
+	}
+
+	durations := []struct {
+		field string
+		value time.Duration
+	}{
+		{"server.readtimeout", c.Server.ReadTimeout},
+		{"server.readheadertimeout", c.Server.ReadHeaderTimeout},
+		{"server.writetimeout", c.Server.WriteTimeout},
+		{"server.idletimeout", c.Server.IdleTimeout},
+		{"server.draindelay", c.Server.DrainDelay},
+		{"barn.timeout", c.Barn.Timeout},
+		{"barn.retrybasedelay", c.Barn.RetryBaseDelay},
+		{"barn.retrymaxdelay", c.Barn.RetryMaxDelay},
+		{"barn.breakercooldown", c.Barn.BreakerCooldown},
+		{"db.connmaxlifetime", c.DB.ConnMaxLifetime},
+		{"menu.graceperiod", c.Menu.GracePeriod},
+		{"menu.shoppinglistfallback", c.Menu.ShoppingListFallback},
+		{"cache.menuttl", c.Cache.MenuTTL},
+		{"cache.mealttl", c.Cache.MealTTL},
+		{"cache.shoppinglistttl", c.Cache.ShoppingListTTL},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			fail(d.field, "не может быть отрицательным, получено %s", d.value)
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		fail("server.shutdowntimeout", "должен быть положительным, получено %s", c.Server.ShutdownTimeout)
+	}
+
+	if c.Barn.Retries < 0 {
+		fail("barn.retries", "не может быть отрицательным, получено %d", c.Barn.Retries)
+	}
+	if c.Barn.BreakerThreshold < 0 {
+		fail("barn.breakerthreshold", "не может быть отрицательным, получено %d", c.Barn.BreakerThreshold)
+	}
+
+	if c.DB.MaxOpenConns < 0 {
+		fail("db.maxopenconns", "не может быть отрицательным, получено %d", c.DB.MaxOpenConns)
+	}
+	if c.DB.MaxIdleConns < 0 {
+		fail("db.maxidleconns", "не может быть отрицательным, получено %d", c.DB.MaxIdleConns)
+	} else if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		fail("db.maxidleconns", "не может быть больше db.maxopenconns (%d), получено %d", c.DB.MaxOpenConns, c.DB.MaxIdleConns)
+	}
+
+	if c.Cache.MaxEntries < 0 {
+		fail("cache.maxentries", "не может быть отрицательным, получено %d", c.Cache.MaxEntries)
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		fail("log.level", "%v", err)
+	}
+	if c.Log.Format != "" && c.Log.Format != "text" && c.Log.Format != "json" {
+		fail("log.format", "ожидается text или json, получено %q", c.Log.Format)
+	}
+
+	if c.Scheduler.Enabled {
+		if _, err := scheduler.ParseSchedule(c.Scheduler.Schedule); err != nil {
+			fail("scheduler.schedule", "%v", err)
+		}
+		if c.Scheduler.LockTTL <= 0 {
+			fail("scheduler.lockttl", "должен быть положительным, получено %s", c.Scheduler.LockTTL)
+		}
+		if c.Scheduler.Lookahead < 0 {
+			fail("scheduler.lookahead", "не может быть отрицательным, получено %s", c.Scheduler.Lookahead)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("некорректная конфигурация: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// applyEnv переопределяет поля структуры v значениями переменных окружения prefix_ИМЯПОЛЯ,
+// вложенные структуры обходятся рекурсивно с префиксом prefix_СЕКЦИЯ
+func applyEnv(v reflect.Value, prefix string) error {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		name := prefix + "_" + strings.ToUpper(v.Type().Field(i).Name)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			return fmt.Errorf("переменная окружения %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, raw string) error {
+	if field.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается длительность вида 15s или 10m, получено %q", raw)
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается true или false, получено %q", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("ожидается целое число, получено %q", raw)
+		}
+		field.SetInt(int64(n))
+	default:
+		return fmt.Errorf("тип %s не поддерживается", field.Type())
+	}
+	return nil
+}
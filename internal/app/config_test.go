@@ -0,0 +1,143 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const minimalConfig = `
+port: "8080"
+barnurl: "http://localhost:8082"
+db:
+  dsn: "user:password@tcp(localhost:3306)/menu"
+`
+
+func TestNewConfig_Repository(t *testing.T) {
+	config, err := NewConfig("../../configs/config.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "mysql", config.Storage.Driver)
+	assert.Equal(t, 15*time.Second, config.Server.ShutdownTimeout)
+}
+
+func TestNewConfig_Defaults(t *testing.T) {
+	config, err := NewConfig(writeConfig(t, minimalConfig))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "127.0.0.1", config.Host)
+	assert.Equal(t, "mysql", config.Storage.Driver)
+	assert.Equal(t, 15*time.Second, config.Server.ReadTimeout)
+	assert.Equal(t, 5*time.Second, config.Server.ReadHeaderTimeout)
+	assert.Equal(t, 5*time.Second, config.Server.DrainDelay)
+	assert.Equal(t, 10*time.Second, config.Barn.Timeout)
+	assert.Equal(t, 2, config.Barn.Retries)
+	assert.Equal(t, 5, config.Barn.BreakerThreshold)
+	assert.Equal(t, 10, config.DB.MaxOpenConns)
+	assert.Equal(t, 10*time.Minute, config.Scheduler.LockTTL)
+	assert.Equal(t, 24*time.Hour, config.Scheduler.Lookahead)
+	assert.False(t, config.Cache.Enabled)
+	assert.Equal(t, 10*time.Minute, config.Cache.MealTTL)
+	assert.Equal(t, "info", config.Log.Level)
+	assert.Equal(t, "text", config.Log.Format)
+}
+
+func TestNewConfig_EnvOverrides(t *testing.T) {
+	t.Setenv("MENU_MANAGER_PORT", "9090")
+	t.Setenv("MENU_MANAGER_DB_DSN", "secret@tcp(db:3306)/menu")
+	t.Setenv("MENU_MANAGER_DB_MAXOPENCONNS", "40")
+	t.Setenv("MENU_MANAGER_SERVER_WRITETIMEOUT", "1m")
+	t.Setenv("MENU_MANAGER_STORAGE_MIGRATEONSTART", "true")
+
+	config, err := NewConfig(writeConfig(t, minimalConfig))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "9090", config.Port)
+	assert.Equal(t, "secret@tcp(db:3306)/menu", config.DB.DSN)
+	assert.Equal(t, 40, config.DB.MaxOpenConns)
+	assert.Equal(t, time.Minute, config.Server.WriteTimeout)
+	assert.True(t, config.Storage.MigrateOnStart)
+}
+
+func TestNewConfig_InvalidEnv(t *testing.T) {
+	t.Setenv("MENU_MANAGER_BARN_TIMEOUT", "soon")
+
+	_, err := NewConfig(writeConfig(t, minimalConfig))
+	assert.ErrorContains(t, err, "MENU_MANAGER_BARN_TIMEOUT")
+}
+
+func TestNewConfig_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   []string
+	}{
+		{
+			name:   "пустой конфиг",
+			config: "",
+			want:   []string{"port: не задан", "barnurl: не задан", "db.dsn: не задан"},
+		},
+		{
+			name:   "некорректные порт и адрес barn manager",
+			config: "port: \"80a\"\nbarnurl: \"localhost:8082\"\ndb:\n  dsn: x\n",
+			want:   []string{"port: ожидается число", "barnurl: ожидается адрес"},
+		},
+		{
+			name:   "sqlite без пути",
+			config: "port: \"8080\"\nbarnurl: \"http://barn\"\nstorage:\n  driver: sqlite\n  path: \"\"\n",
+			want:   []string{"storage.path: не задан"},
+		},
+		{
+			name:   "неизвестное хранилище",
+			config: "port: \"8080\"\nbarnurl: \"http://barn\"\nstorage:\n  driver: redis\n",
+			want:   []string{"storage.driver"},
+		},
+		{
+			name:   "отрицательные таймауты и пул",
+			config: "port: \"8080\"\nbarnurl: \"http://barn\"\nserver:\n  readtimeout: -1s\n  shutdowntimeout: 0s\n  draindelay: -1s\ndb:\n  dsn: x\n  maxidleconns: 20\n",
+			want:   []string{"server.readtimeout", "server.shutdowntimeout", "server.draindelay", "db.maxidleconns"},
+		},
+		{
+			name:   "некорректный кэш",
+			config: minimalConfig + "cache:\n  enabled: true\n  maxentries: -1\n  mealttl: -1m\n",
+			want:   []string{"cache.maxentries", "cache.mealttl"},
+		},
+		{
+			name:   "некорректный журнал",
+			config: minimalConfig + "log:\n  level: verbose\n  format: xml\n",
+			want:   []string{"log.level", "log.format"},
+		},
+		{
+			name:   "некорректное расписание",
+			config: minimalConfig + "scheduler:\n  enabled: true\n  schedule: \"every day\"\n  lookahead: -1h\n",
+			want:   []string{"scheduler.schedule", "scheduler.lookahead"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewConfig(writeConfig(t, tt.config))
+			assert.ErrorContains(t, err, "некорректная конфигурация")
+			for _, want := range tt.want {
+				assert.ErrorContains(t, err, want)
+			}
+		})
+	}
+}
+
+func TestNewConfig_MemoryNeedsNoDSN(t *testing.T) {
+	config, err := NewConfig(writeConfig(t, "port: \"8080\"\nbarnurl: \"https://barn.example.com\"\nstorage:\n  driver: memory\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "memory", config.Storage.Driver)
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"menu_manager/internal/cache"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// httpMetrics метрики входящих HTTP-запросов
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// newMetricsRegistry создает реестр метрик приложения с метриками рантайма Go и процесса
+func newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return registry
+}
+
+func newHTTPMetrics(reg prometheus.Registerer) *httpMetrics {
+	factory := promauto.With(reg)
+	return &httpMetrics{
+		requests: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "menu_manager",
+			Name:      "http_requests_total",
+			Help:      "Обработанные HTTP-запросы по маршруту и коду ответа.",
+		}, []string{"method", "route", "status"}),
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "menu_manager",
+			Name:      "http_request_duration_seconds",
+			Help:      "Длительность обработки HTTP-запросов по маршруту.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+	}
+}
+
+// middleware учитывает запрос по шаблону маршрута chi, а не по пути: иначе каждый id давал бы новую серию
+func (m *httpMetrics) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		route := chi.RouteContext(r.Context()).RoutePattern()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		m.requests.WithLabelValues(r.Method, route, strconv.Itoa(status)).Inc()
+		m.duration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// cacheCollector отдает счетчики кэша из Stats загрузчиков с меткой cache — видом кэшируемых значений
+type cacheCollector struct {
+	sources []func() map[string]cache.Stats
+
+	hits, misses, shared, errors, invalidations *prometheus.Desc
+}
+
+func newCacheCollector(sources ...func() map[string]cache.Stats) *cacheCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("menu_manager", "cache", name), help, []string{"cache"}, nil)
+	}
+	return &cacheCollector{
+		sources:       sources,
+		hits:          desc("hits_total", "Попадания в кэш."),
+		misses:        desc("misses_total", "Промахи кэша, в том числе из-за ошибок кэша."),
+		shared:        desc("shared_loads_total", "Промахи, получившие значение, загруженное для другого конкурентного запроса."),
+		errors:        desc("errors_total", "Ошибки обращения к кэшу."),
+		invalidations: desc("invalidations_total", "Сбросы ключей кэша."),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.shared
+	ch <- c.errors
+	ch <- c.invalidations
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	counter := func(desc *prometheus.Desc, value uint64, name string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), name)
+	}
+	for _, source := range c.sources {
+		for name, stats := range source() {
+			counter(c.hits, stats.Hits, name)
+			counter(c.misses, stats.Misses, name)
+			counter(c.shared, stats.Shared, name)
+			counter(c.errors, stats.Errors, name)
+			counter(c.invalidations, stats.Invalidations, name)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"menu_manager/internal/migrate"
+	"strconv"
+)
+
+// MigrateUsage описание подкоманды migrate
+const MigrateUsage = "использование: migrate up | down [N] | status"
+
+// Migrate выполняет подкоманду migrate над базой из конфига и печатает результат в out:
+//   - up применяет все новые миграции;
+//   - down [N] откатывает N последних миграций (по умолчанию одну);
+//   - status выводит текущую версию схемы и список миграций.
+func (a *App) Migrate(ctx context.Context, dsn string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(MigrateUsage)
+	}
+
+	steps := 1
+	switch {
+	case args[0] != "up" && args[0] != "down" && args[0] != "status":
+		return fmt.Errorf("неизвестная команда migrate %q: %s", args[0], MigrateUsage)
+	case args[0] == "down" && len(args) == 2:
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return fmt.Errorf("некорректное количество миграций %q: %s", args[1], MigrateUsage)
+		}
+		steps = n
+	case len(args) != 1:
+		return errors.New(MigrateUsage)
+	}
+
+	db, dialect, err := a.openDB(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db, dialect)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "применено миграций: %d\n", applied)
+
+	case "down":
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "откачено миграций: %d\n", reverted)
+
+	case "status":
+		version, dirty, statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "версия схемы: %d", version)
+		if dirty {
+			fmt.Fprint(out, " (dirty)")
+		}
+		fmt.Fprintln(out)
+		for _, status := range statuses {
+			mark := " "
+			if status.Applied {
+				mark = "x"
+			}
+			fmt.Fprintf(out, "[%s] %06d_%s\n", mark, status.Version, status.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package barnstub реализует заглушку сервиса barn manager для локальной разработки и тестов.
+// Заглушка отвечает на POST /api/v1/products/check-availability продуктами из файла запасов
+// и умеет имитировать задержки, ошибки и некорректные ответы
+package barnstub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	common "menu_manager/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"time"
+)
+
+// CheckAvailabilityPath путь запроса наличия продуктов
+const CheckAvailabilityPath = common.CheckAvailabilityPath
+
+// Behavior задает, как заглушка отвечает на запросы
+type Behavior struct {
+	// Latency задержка перед каждым ответом
+	Latency time.Duration
+	// ErrorRate доля запросов от 0 до 1, на которые заглушка отвечает ошибкой ErrorStatus
+	ErrorRate float64
+	// FailNext сколько ближайших запросов завершить ошибкой ErrorStatus независимо от ErrorRate
+	FailNext int
+	// ErrorStatus код ответа с ошибкой, по умолчанию 503
+	ErrorStatus int
+	// Malformed отвечать телом, которое не разбирается как JSON
+	Malformed bool
+}
+
+// Request запрос, полученный заглушкой
+type Request struct {
+	ContentType string
+	Body        []byte
+}
+
+// Stub обработчик HTTP-запросов barn manager
+type Stub struct {
+	mu       sync.Mutex
+	products map[string]common.Product
+	behavior Behavior
+	requests []Request
+}
+
+// New создает заглушку с запасами products
+func New(products []common.Product) *Stub {
+	s := &Stub{}
+	s.SetProducts(products)
+	return s
+}
+
+// LoadInventory читает запасы из JSON-файла вида {"products": [...]}
+func LoadInventory(path string) ([]common.Product, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать файл запасов: %w", err)
+	}
+
+	var inventory struct {
+		Products []common.Product `json:"products"`
+	}
+	if err := json.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("не удалось разобрать файл запасов %s: %w", path, err)
+	}
+	return inventory.Products, nil
+}
+
+// SetProducts заменяет запасы заглушки
+func (s *Stub) SetProducts(products []common.Product) {
+	byID := make(map[string]common.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products = byID
+}
+
+// SetBehavior меняет режим ответа заглушки
+func (s *Stub) SetBehavior(behavior Behavior) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.behavior = behavior
+}
+
+// Requests возвращает все полученные запросы в порядке поступления
+func (s *Stub) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != CheckAvailabilityPath {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+	behavior, fail := s.record(Request{ContentType: r.Header.Get("Content-Type"), Body: body})
+
+	if behavior.Latency > 0 {
+		select {
+		case <-time.After(behavior.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail {
+		status := behavior.ErrorStatus
+		if status == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, "barnstub: simulated error", status)
+		return
+	}
+
+	// заглушка проверяет запрос так же строго, как контракт barn manager
+	var request common.CheckAvailabilityRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := request.Validate(); err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if behavior.Malformed {
+		w.Write([]byte(`{"products": [{"id": `))
+		return
+	}
+
+	// продукты отдаются в порядке запроса, неизвестные barn manager продукты пропускаются
+	response := common.CheckAvailabilityResponse{Products: make([]common.Product, 0)}
+	s.mu.Lock()
+	for _, ingredient := range request.Ingredients {
+		if product, ok := s.products[ingredient.ProductID]; ok {
+			response.Products = append(response.Products, product)
+		}
+	}
+	s.mu.Unlock()
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// record сохраняет запрос и решает, нужно ли ответить на него ошибкой
+func (s *Stub) record(req Request) (Behavior, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, req)
+	behavior := s.behavior
+
+	if s.behavior.FailNext > 0 {
+		s.behavior.FailNext--
+		return behavior, true
+	}
+	return behavior, behavior.ErrorRate > 0 && rand.Float64() < behavior.ErrorRate
+}
+
+// Server заглушка, запущенная на локальном порту через httptest
+type Server struct {
+	*Stub
+	URL string
+
+	server *httptest.Server
+}
+
+// Start запускает заглушку с запасами products, ее нужно остановить через Close
+func Start(products []common.Product) *Server {
+	stub := New(products)
+	server := httptest.NewServer(stub)
+	return &Server{Stub: stub, URL: server.URL, server: server}
+}
+
+// Close останавливает сервер, дожидаясь завершения запросов
+func (s *Server) Close() {
+	s.server.Close()
+}
@@ -0,0 +1,96 @@
+package barnstub_test
+
+import (
+	"encoding/json"
+	"menu_manager/internal/barnstub"
+	common "menu_manager/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func checkAvailability(stub http.Handler, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, barnstub.CheckAvailabilityPath, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	stub.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStub_ReturnsRequestedProducts(t *testing.T) {
+	stub := barnstub.New([]common.Product{
+		{ID: "milk", Name: "Milk", Amount: 500},
+		{ID: "eggs", Name: "Eggs", Amount: 10},
+		{ID: "bread", Name: "Bread"},
+	})
+
+	rec := checkAvailability(stub, `{"version": 1, "ingredients": [
+		{"product_id": "eggs", "amount": 2, "unit": "шт"},
+		{"product_id": "unknown", "amount": 1, "unit": "г"},
+		{"product_id": "milk", "amount": 200, "unit": "мл"}
+	]}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response common.CheckAvailabilityResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, []common.Product{{ID: "eggs", Name: "Eggs", Amount: 10}, {ID: "milk", Name: "Milk", Amount: 500}}, response.Products)
+
+	requests := stub.Requests()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "application/json", requests[0].ContentType)
+}
+
+const validBody = `{"version": 1, "ingredients": [{"product_id": "eggs", "amount": 1, "unit": "шт"}]}`
+
+func TestStub_SimulatesFailures(t *testing.T) {
+	stub := barnstub.New(nil)
+
+	stub.SetBehavior(barnstub.Behavior{FailNext: 2, ErrorStatus: http.StatusTooManyRequests})
+	assert.Equal(t, http.StatusTooManyRequests, checkAvailability(stub, validBody).Code)
+	assert.Equal(t, http.StatusTooManyRequests, checkAvailability(stub, validBody).Code)
+	assert.Equal(t, http.StatusOK, checkAvailability(stub, validBody).Code)
+
+	stub.SetBehavior(barnstub.Behavior{ErrorRate: 1})
+	assert.Equal(t, http.StatusServiceUnavailable, checkAvailability(stub, validBody).Code)
+
+	stub.SetBehavior(barnstub.Behavior{Malformed: true})
+	rec := checkAvailability(stub, validBody)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, json.Valid(rec.Body.Bytes()))
+
+	assert.Len(t, stub.Requests(), 5)
+}
+
+func TestStub_RejectsUnknownRequests(t *testing.T) {
+	stub := barnstub.New(nil)
+
+	rec := httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, barnstub.CheckAvailabilityPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	stub.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/products", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	for _, body := range []string{
+		`[{"ingredients": [{"product_id": "eggs"}]}]`,
+		`{"version": 1, "ingredients": []}`,
+		`{"version": 2, "ingredients": [{"product_id": "eggs", "amount": 1, "unit": "шт"}]}`,
+		`{"version": 1, "ingredients": [{"product_id": "eggs", "amount": 1, "unit": "шт"}, {"product_id": "eggs", "amount": 2, "unit": "шт"}]}`,
+		`{"version": 1, "ingredients": [{"product_id": "eggs", "unit": "шт"}]}`,
+	} {
+		assert.Equal(t, http.StatusBadRequest, checkAvailability(stub, body).Code, body)
+	}
+}
+
+func TestLoadInventory(t *testing.T) {
+	products, err := barnstub.LoadInventory("../../configs/barn_inventory.json")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, products)
+
+	_, err = barnstub.LoadInventory("missing.json")
+	assert.Error(t, err)
+}
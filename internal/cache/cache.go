@@ -0,0 +1,136 @@
+// Package cache кэширует ответы хранилища и внешних сервисов.
+// Значения хранятся сериализованными в JSON, поэтому кэш в памяти процесса можно заменить общим,
+// например Redis, реализовав интерфейс Cache
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache хранилище значений с ограниченным временем жизни
+type Cache interface {
+	// Get возвращает значение по ключу. ok == false, если значения нет или его срок истек
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set сохраняет значение на время ttl, нулевой ttl означает, что срок не ограничен
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete удаляет ключи, отсутствующие ключи пропускаются
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// DefaultMaxEntries сколько значений по умолчанию хранит кэш в памяти
+const DefaultMaxEntries = 10000
+
+// Memory реализует Cache в памяти процесса, безопасен для конкурентного использования.
+// Когда кэш заполнен, сначала удаляются значения с истекшим сроком, затем произвольные
+type Memory struct {
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]entry
+}
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time // нулевое время — без ограничения срока
+}
+
+// Option настраивает необязательные параметры кэша в памяти
+type Option func(*Memory)
+
+// WithClock подменяет источник текущего времени, используется в тестах
+func WithClock(now func() time.Time) Option {
+	return func(m *Memory) {
+		m.now = now
+	}
+}
+
+// NewMemory создает кэш в памяти не более чем на maxEntries значений.
+// Неположительный maxEntries означает DefaultMaxEntries
+func NewMemory(maxEntries int, opts ...Option) *Memory {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	m := &Memory{
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]entry),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Get возвращает значение по ключу, значение с истекшим сроком удаляется
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if m.expired(e, m.now()) {
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	return e.value, true, nil
+}
+
+// Set сохраняет значение на время ttl
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if _, ok := m.entries[key]; !ok && len(m.entries) >= m.maxEntries {
+		m.evict(now)
+	}
+
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expiresAt = now.Add(ttl)
+	}
+	m.entries[key] = e
+	return nil
+}
+
+// Delete удаляет ключи
+func (m *Memory) Delete(ctx context.Context, keys ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+	return nil
+}
+
+// Len возвращает число хранимых значений, включая еще не удаленные значения с истекшим сроком
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.entries)
+}
+
+// evict освобождает место под новое значение, вызывается под m.mu
+func (m *Memory) evict(now time.Time) {
+	for key, e := range m.entries {
+		if m.expired(e, now) {
+			delete(m.entries, key)
+		}
+	}
+	for key := range m.entries {
+		if len(m.entries) < m.maxEntries {
+			return
+		}
+		delete(m.entries, key)
+	}
+}
+
+func (m *Memory) expired(e entry, now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
@@ -0,0 +1,74 @@
+package cache_test
+
+import (
+	"context"
+	"menu_manager/internal/cache"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory_SetGetDelete(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemory(0)
+
+	_, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.NoError(t, c.Set(ctx, "a", []byte("1"), 0))
+	value, ok, err := c.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+
+	assert.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, _ = c.Get(ctx, "a")
+	assert.False(t, ok)
+}
+
+func TestMemory_Expiration(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := cache.NewMemory(0, cache.WithClock(func() time.Time { return now }))
+
+	assert.NoError(t, c.Set(ctx, "short", []byte("1"), time.Minute))
+	assert.NoError(t, c.Set(ctx, "forever", []byte("2"), 0))
+
+	now = now.Add(59 * time.Second)
+	_, ok, _ := c.Get(ctx, "short")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok, _ = c.Get(ctx, "short")
+	assert.False(t, ok)
+	_, ok, _ = c.Get(ctx, "forever")
+	assert.True(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestMemory_Eviction(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := cache.NewMemory(2, cache.WithClock(func() time.Time { return now }))
+
+	assert.NoError(t, c.Set(ctx, "expiring", []byte("1"), time.Minute))
+	assert.NoError(t, c.Set(ctx, "kept", []byte("2"), 0))
+	now = now.Add(time.Hour)
+
+	// место освобождается за счет значения с истекшим сроком
+	assert.NoError(t, c.Set(ctx, "new", []byte("3"), 0))
+	_, ok, _ := c.Get(ctx, "kept")
+	assert.True(t, ok)
+	assert.Equal(t, 2, c.Len())
+
+	// перезапись существующего ключа ничего не вытесняет
+	assert.NoError(t, c.Set(ctx, "new", []byte("4"), 0))
+	assert.Equal(t, 2, c.Len())
+
+	assert.NoError(t, c.Set(ctx, "another", []byte("5"), 0))
+	assert.Equal(t, 2, c.Len())
+	_, ok, _ = c.Get(ctx, "another")
+	assert.True(t, ok)
+}
@@ -0,0 +1,174 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Stats счетчики обращений к кэшу одного вида значений с момента запуска
+type Stats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	// Shared сколько промахов получили значение, загруженное для другого конкурентного запроса
+	Shared uint64 `json:"shared"`
+	// Errors сколько раз кэш ответил ошибкой, такие обращения считаются промахами
+	Errors        uint64 `json:"errors"`
+	Invalidations uint64 `json:"invalidations"`
+}
+
+// Loader читает значения одного вида через кэш. Ключи в кэше получают префикс prefix.
+// При промахе значение загружается один раз для всех конкурентных запросов с тем же ключом,
+// поэтому истечение срока популярного ключа не приводит к лавине запросов в хранилище
+type Loader struct {
+	cache  Cache
+	prefix string
+	ttl    time.Duration
+
+	group singleflight.Group
+	// version меняется при каждой инвалидации, загруженное до нее значение в кэш не попадает
+	version atomic.Uint64
+
+	hits, misses, shared, errors, invalidations atomic.Uint64
+}
+
+// NewLoader создает загрузчик значений, которые хранятся в кэше c в течение ttl
+func NewLoader(c Cache, prefix string, ttl time.Duration) *Loader {
+	return &Loader{cache: c, prefix: prefix, ttl: ttl}
+}
+
+// Load возвращает значение key из кэша, а при промахе вызывает load и сохраняет результат.
+// Ошибки load не кэшируются. Каждый вызов получает собственную копию значения
+func Load[T any](ctx context.Context, l *Loader, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	var value T
+	key = l.prefix + ":" + key
+	if l.get(ctx, key, &value) {
+		return value, nil
+	}
+
+	// загрузка продолжается, даже если вызвавший ее запрос отменен: ее результат ждут другие запросы
+	loadCtx := context.WithoutCancel(ctx)
+	leader := false
+	result := l.group.DoChan(key, func() (any, error) {
+		leader = true
+		version := l.version.Load()
+		loaded, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		l.set(loadCtx, key, data, version)
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return value, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return value, res.Err
+		}
+		if !leader {
+			l.shared.Add(1)
+		}
+		err := json.Unmarshal(res.Val.([]byte), &value)
+		return value, err
+	}
+}
+
+// LoadMany возвращает значения keys, ключ результата — ключ без префикса. Отсутствующие в кэше значения
+// загружаются одним вызовом load и сохраняются. В отличие от Load, конкурентные загрузки не объединяются
+func LoadMany[T any](ctx context.Context, l *Loader, keys []string, load func(ctx context.Context, missing []string) (map[string]T, error)) (map[string]T, error) {
+	values := make(map[string]T, len(keys))
+	missing := make([]string, 0)
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var value T
+		if l.get(ctx, l.prefix+":"+key, &value) {
+			values[key] = value
+			continue
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return values, nil
+	}
+
+	version := l.version.Load()
+	loaded, err := load(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range loaded {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		l.set(ctx, l.prefix+":"+key, data, version)
+		values[key] = value
+	}
+	return values, nil
+}
+
+// get читает значение из кэша в value и учитывает попадание или промах
+func (l *Loader) get(ctx context.Context, key string, value any) bool {
+	data, ok, err := l.cache.Get(ctx, key)
+	if err != nil {
+		l.errors.Add(1)
+		// ключ не логируется, в ключах меню это id пользователя
+		slog.WarnContext(ctx, "не удалось прочитать значение из кэша", "cache", l.prefix, "error", err)
+	}
+	if ok && json.Unmarshal(data, value) == nil {
+		l.hits.Add(1)
+		return true
+	}
+	l.misses.Add(1)
+	return false
+}
+
+// set сохраняет значение, загруженное при версии version, если с тех пор не было инвалидации
+func (l *Loader) set(ctx context.Context, key string, data []byte, version uint64) {
+	if l.version.Load() != version {
+		return
+	}
+	if err := l.cache.Set(ctx, key, data, l.ttl); err != nil {
+		l.errors.Add(1)
+		slog.WarnContext(ctx, "не удалось сохранить значение в кэш", "cache", l.prefix, "error", err)
+	}
+}
+
+// Invalidate удаляет значения keys из кэша. Загрузки, начатые до вызова, не сохраняют результат в кэш
+func (l *Loader) Invalidate(ctx context.Context, keys ...string) error {
+	l.version.Add(1)
+	l.invalidations.Add(1)
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = l.prefix + ":" + key
+		l.group.Forget(prefixed[i])
+	}
+	return l.cache.Delete(ctx, prefixed...)
+}
+
+// Stats возвращает текущие значения счетчиков
+func (l *Loader) Stats() Stats {
+	return Stats{
+		Hits:          l.hits.Load(),
+		Misses:        l.misses.Load(),
+		Shared:        l.shared.Load(),
+		Errors:        l.errors.Load(),
+		Invalidations: l.invalidations.Load(),
+	}
+}
@@ -0,0 +1,189 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type meal struct {
+	ID     string   `json:"id"`
+	Dishes []string `json:"dishes"`
+}
+
+func TestLoad_HitAndMiss(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	var calls int
+	load := func(ctx context.Context) (*meal, error) {
+		calls++
+		return &meal{ID: "m1", Dishes: []string{"omelette"}}, nil
+	}
+
+	first, err := cache.Load(ctx, l, "m1", load)
+	assert.NoError(t, err)
+	second, err := cache.Load(ctx, l, "m1", load)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, first, second)
+	// каждый вызов получает собственную копию
+	first.Dishes[0] = "changed"
+	assert.Equal(t, "omelette", second.Dishes[0])
+
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1}, l.Stats())
+}
+
+func TestLoad_ErrorsAreNotCached(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+	errLoad := errors.New("db is down")
+
+	_, err := cache.Load(ctx, l, "m1", func(ctx context.Context) (*meal, error) { return nil, errLoad })
+	assert.ErrorIs(t, err, errLoad)
+
+	got, err := cache.Load(ctx, l, "m1", func(ctx context.Context) (*meal, error) { return &meal{ID: "m1"}, nil })
+	assert.NoError(t, err)
+	assert.Equal(t, "m1", got.ID)
+	assert.Equal(t, uint64(2), l.Stats().Misses)
+}
+
+func TestLoad_Stampede(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (*meal, error) {
+		calls.Add(1)
+		<-release
+		return &meal{ID: "m1"}, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := cache.Load(ctx, l, "m1", load)
+			assert.NoError(t, err)
+			assert.Equal(t, "m1", got.ID)
+		}()
+	}
+
+	// ждем, пока все запросы промахнутся и встанут в очередь за одной загрузкой
+	assert.Eventually(t, func() bool { return l.Stats().Misses == callers }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, uint64(callers-1), l.Stats().Shared)
+}
+
+func TestLoad_CallerCanceled(t *testing.T) {
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	release := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cache.Load(ctx, l, "m1", func(ctx context.Context) (*meal, error) {
+		<-release
+		// загрузка не зависит от отмены запроса, который ее начал
+		return &meal{ID: "m1"}, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	close(release)
+	got, err := cache.Load(context.Background(), l, "m1", func(ctx context.Context) (*meal, error) {
+		return &meal{ID: "m1"}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "m1", got.ID)
+}
+
+func TestLoader_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	version := "old"
+	load := func(ctx context.Context) (string, error) { return version, nil }
+
+	got, _ := cache.Load(ctx, l, "m1", load)
+	assert.Equal(t, "old", got)
+
+	version = "new"
+	assert.NoError(t, l.Invalidate(ctx, "m1"))
+	got, _ = cache.Load(ctx, l, "m1", load)
+	assert.Equal(t, "new", got)
+	assert.Equal(t, uint64(1), l.Stats().Invalidations)
+}
+
+func TestLoader_InvalidateDuringLoad(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan string)
+	go func() {
+		got, _ := cache.Load(ctx, l, "m1", func(ctx context.Context) (string, error) {
+			close(started)
+			<-release
+			return "stale", nil
+		})
+		done <- got
+	}()
+
+	<-started
+	assert.NoError(t, l.Invalidate(ctx, "m1"))
+	close(release)
+	assert.Equal(t, "stale", <-done)
+
+	// значение, загруженное до инвалидации, в кэш не попало
+	got, _ := cache.Load(ctx, l, "m1", func(ctx context.Context) (string, error) { return "fresh", nil })
+	assert.Equal(t, "fresh", got)
+}
+
+func TestLoadMany(t *testing.T) {
+	ctx := context.Background()
+	l := cache.NewLoader(cache.NewMemory(0), "meal", time.Minute)
+
+	var requested [][]string
+	load := func(ctx context.Context, missing []string) (map[string]*meal, error) {
+		requested = append(requested, missing)
+		meals := make(map[string]*meal, len(missing))
+		for _, id := range missing {
+			meals[id] = &meal{ID: id}
+		}
+		return meals, nil
+	}
+
+	_, err := cache.Load(ctx, l, "m1", func(ctx context.Context) (*meal, error) { return &meal{ID: "m1"}, nil })
+	assert.NoError(t, err)
+
+	// из хранилища загружаются только отсутствующие в кэше значения, повторы ключей не загружаются дважды
+	got, err := cache.LoadMany(ctx, l, []string{"m1", "m2", "m3", "m2"}, load)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Equal(t, "m3", got["m3"].ID)
+	assert.Equal(t, [][]string{{"m2", "m3"}}, requested)
+
+	got, err = cache.LoadMany(ctx, l, []string{"m1", "m2", "m3"}, load)
+	assert.NoError(t, err)
+	assert.Len(t, got, 3)
+	assert.Len(t, requested, 1)
+
+	_, err = cache.LoadMany(ctx, l, []string{"m4"}, func(ctx context.Context, missing []string) (map[string]*meal, error) {
+		return nil, errors.New("db is down")
+	})
+	assert.Error(t, err)
+}
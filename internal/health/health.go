@@ -0,0 +1,140 @@
+// Package health реализует проверки живости и готовности сервиса для оркестратора.
+// /healthz отвечает 200, пока процесс жив. /readyz проверяет зависимости и отвечает 503,
+// если не прошла критичная проверка или сервис завершает работу и из него нужно увести трафик
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout сколько ждать ответа одной проверки
+const DefaultTimeout = 2 * time.Second
+
+// Статусы ответа /readyz
+const (
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc проверяет одну зависимость. Details попадают в ответ /readyz даже при ошибке
+type CheckFunc func(ctx context.Context) (details map[string]any, err error)
+
+// Result результат одной проверки
+type Result struct {
+	// Status ok или error
+	Status string `json:"status"`
+	// Critical ошибка критичной проверки делает сервис неготовым, некритичной — только попадает в ответ
+	Critical bool           `json:"critical"`
+	Duration string         `json:"duration"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// Report ответ /readyz
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker выполняет проверки готовности
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+	// draining сервис завершает работу, трафик на него больше не нужен
+	draining atomic.Bool
+}
+
+// NewChecker создает набор проверок, каждая из которых ограничена timeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add добавляет проверку зависимости name. Проверки добавляются до начала обработки запросов
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Drain переводит сервис в состояние завершения: /readyz отвечает 503 без выполнения проверок
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+// Check выполняет все проверки одновременно и собирает отчет
+func (c *Checker) Check(ctx context.Context) Report {
+	if c.draining.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: make(map[string]Result, len(c.checks))}
+	for i, chk := range c.checks {
+		report.Checks[chk.name] = results[i]
+		if chk.critical && results[i].Status != "ok" {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) Result {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := chk.fn(ctx)
+	result := Result{Status: "ok", Critical: chk.critical, Duration: time.Since(start).String(), Details: details}
+	if err != nil {
+		result.Status = "error"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Liveness обработчик /healthz: процесс жив и обслуживает запросы, зависимости не проверяются,
+// чтобы недоступность базы не приводила к перезапуску сервиса
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness обработчик /readyz: отчет по каждой зависимости, 503 если сервис не готов принимать трафик
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+	status := http.StatusOK
+	if report.Status != StatusReady {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, report)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	// ответ проверки не должен кэшироваться прокси
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,97 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"menu_manager/internal/health"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func ok(ctx context.Context) (map[string]any, error) { return nil, nil }
+
+func readiness(t *testing.T, c *health.Checker) (int, health.Report) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	c.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report health.Report
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&report))
+	return rec.Code, report
+}
+
+func TestReadiness_Ready(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	c.Add("db", true, func(ctx context.Context) (map[string]any, error) {
+		return map[string]any{"driver": "sqlite"}, nil
+	})
+	// ошибка некритичной зависимости видна в отчете, но не снимает готовность
+	c.Add("barn", false, func(ctx context.Context) (map[string]any, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	code, report := readiness(t, c)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, health.StatusReady, report.Status)
+	assert.Equal(t, "ok", report.Checks["db"].Status)
+	assert.Equal(t, "sqlite", report.Checks["db"].Details["driver"])
+	assert.Equal(t, "error", report.Checks["barn"].Status)
+	assert.Equal(t, "connection refused", report.Checks["barn"].Error)
+	assert.False(t, report.Checks["barn"].Critical)
+}
+
+func TestReadiness_CriticalFailure(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	c.Add("db", true, func(ctx context.Context) (map[string]any, error) {
+		return nil, errors.New("database is locked")
+	})
+	c.Add("barn", false, ok)
+
+	code, report := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusNotReady, report.Status)
+	assert.Equal(t, "database is locked", report.Checks["db"].Error)
+	assert.Equal(t, "ok", report.Checks["barn"].Status)
+}
+
+func TestReadiness_Timeout(t *testing.T) {
+	c := health.NewChecker(20 * time.Millisecond)
+	c.Add("db", true, func(ctx context.Context) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	start := time.Now()
+	code, report := readiness(t, c)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, report.Checks["db"].Error, "deadline exceeded")
+}
+
+func TestDrain(t *testing.T) {
+	c := health.NewChecker(time.Second)
+	called := false
+	c.Add("db", true, func(ctx context.Context) (map[string]any, error) {
+		called = true
+		return nil, nil
+	})
+	c.Drain()
+
+	code, report := readiness(t, c)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, health.StatusShuttingDown, report.Status)
+	assert.False(t, called)
+
+	// процесс жив, пока завершает запросы, поэтому liveness не меняется
+	rec := httptest.NewRecorder()
+	c.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
@@ -0,0 +1,189 @@
+// Package logging настраивает структурированный журнал сервиса на log/slog: уровень и формат задаются в конфиге,
+// идентификатор запроса из контекста попадает в каждую запись, а персональные данные — id пользователей
+// и содержимое рецептов — в журнал не выводятся
+package logging
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"menu_manager/internal/oops"
+	"strings"
+)
+
+// Ключи атрибутов, которые журнал обрабатывает особо
+const (
+	// RequestIDKey идентификатор запроса, добавляется к записи из контекста
+	RequestIDKey = "request_id"
+	// UserIDKey id пользователя, в журнал выводится его псевдоним
+	UserIDKey = "user_id"
+	// RecipeKey рецепт или рецепты, содержимое в журнал не выводится
+	RecipeKey = "recipe"
+	// ErrorKey ошибка, id из полей oops.DBError и oops.UserError в ее тексте заменяются псевдонимами
+	ErrorKey = "error"
+)
+
+// redacted значение, которое выводится вместо скрытых данных
+const redacted = "[скрыто]"
+
+// ParseLevel разбирает уровень журнала: debug, info, warn или error. Пустая строка означает info
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("ожидается debug, info, warn или error, получено %q", level)
+}
+
+// New создает журнал, который пишет в w записи уровня level и выше в формате format: text (по умолчанию) или json.
+// Псевдонимы id пользователей вычисляются с ключом pseudonymKey, пустой ключ заменяется случайным
+func New(w io.Writer, level, format, pseudonymKey string) (*slog.Logger, error) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	if pseudonymKey == "" {
+		pseudonymKey = randomHex(32)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl, ReplaceAttr: redactor{key: pseudonymKey}.replaceAttr}
+	var handler slog.Handler
+	switch format {
+	case "", "text":
+		handler = slog.NewTextHandler(w, opts)
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("ожидается формат text или json, получено %q", format)
+	}
+	return slog.New(contextHandler{Handler: handler}), nil
+}
+
+type requestIDContextKey struct{}
+
+// WithRequestID возвращает контекст с идентификатором запроса, он попадет во все записи журнала с этим контекстом
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// NewRequestID создает случайный идентификатор запроса
+func NewRequestID() string {
+	return randomHex(8)
+}
+
+// randomHex возвращает n случайных байт в шестнадцатеричной записи
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Pseudonym возвращает псевдоним id — HMAC-SHA256 от id с ключом key: одинаковые id дают одинаковый псевдоним,
+// поэтому записи одного пользователя можно связать между собой, а без ключа id по псевдониму не подобрать
+func Pseudonym(key, id string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:5])
+}
+
+// contextHandler добавляет к записям идентификатор запроса из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String(RequestIDKey, requestID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// redactor скрывает персональные данные в атрибутах записи, id заменяются псевдонимами с ключом key
+type redactor struct {
+	key string
+}
+
+func (r redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case UserIDKey:
+		return slog.String(a.Key, Pseudonym(r.key, a.Value.String()))
+	case RecipeKey, "recipes":
+		return slog.String(a.Key, redacted)
+	case ErrorKey:
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, r.redactError(err))
+		}
+	}
+	return a
+}
+
+// redactError возвращает текст ошибки, в котором id из полей oops.DBError и oops.UserError цепочки
+// заменены псевдонимами. Текст остальных ошибок не разбирается: в нем id пользователей быть не должно
+func (r redactor) redactError(err error) string {
+	switch e := err.(type) {
+	case *oops.DBError:
+		id := e.ID
+		if id != "" {
+			id = Pseudonym(r.key, id)
+		}
+		return e.Text(id, r.redactCause(e.Err))
+	case *oops.UserError:
+		return e.Text(Pseudonym(r.key, e.UserID), r.redactCause(e.Err))
+	}
+
+	// обертки вроде fmt.Errorf и errors.Join не хранят вложенные ошибки отдельно от своего текста,
+	// поэтому в нем заменяется текст вложенной ошибки целиком
+	msg := err.Error()
+	for _, inner := range unwrap(err) {
+		text := inner.Error()
+		if redactedText := r.redactError(inner); redactedText != text {
+			msg = strings.Replace(msg, text, redactedText, 1)
+		}
+	}
+	return msg
+}
+
+func (r redactor) redactCause(err error) string {
+	if err == nil {
+		return fmt.Sprint(err)
+	}
+	return r.redactError(err)
+}
+
+// unwrap возвращает ошибки, непосредственно вложенные в err
+func unwrap(err error) []error {
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return []error{inner}
+		}
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+	return nil
+}
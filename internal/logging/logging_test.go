@@ -0,0 +1,124 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"menu_manager/internal/logging"
+	"menu_manager/internal/oops"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recipe struct {
+	Ingredients []string `json:"ingredients"`
+}
+
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var line map[string]any
+		assert.NoError(t, dec.Decode(&line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestNew_JSONWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json", "secret")
+	assert.NoError(t, err)
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "запрос обработан", "status", 200)
+	logger.With("component", "test").InfoContext(ctx, "с атрибутами")
+	logger.Info("без запроса")
+	logger.DebugContext(ctx, "ниже уровня")
+
+	lines := decodeLines(t, &buf)
+	assert.Len(t, lines, 3)
+	assert.Equal(t, "req-1", lines[0][logging.RequestIDKey])
+	assert.Equal(t, float64(200), lines[0]["status"])
+	assert.Equal(t, "req-1", lines[1][logging.RequestIDKey])
+	assert.Equal(t, "test", lines[1]["component"])
+	assert.NotContains(t, lines[2], logging.RequestIDKey)
+}
+
+func TestNew_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "text", "secret")
+	assert.NoError(t, err)
+
+	dbErr := oops.NewDBError(errors.New("deadlock"), "LoadMenu", "kolya")
+	logger.Warn("перенос не удался",
+		logging.UserIDKey, "kolya",
+		logging.RecipeKey, recipe{Ingredients: []string{"секретный соус"}},
+		"recipes", []recipe{{Ingredients: []string{"яйца"}}},
+		logging.ErrorKey, fmt.Errorf("перенос: %w", errors.Join(oops.NewUserError("kolya", dbErr), errors.New("timeout"))))
+
+	line := buf.String()
+	assert.NotContains(t, line, "kolya")
+	assert.NotContains(t, line, "секретный соус")
+	assert.NotContains(t, line, "яйца")
+	// псевдоним одинаков для одного id, поэтому записи пользователя можно связать
+	assert.Contains(t, line, "user_id="+logging.Pseudonym("secret", "kolya"))
+	assert.Contains(t, line, "пользователь "+logging.Pseudonym("secret", "kolya"))
+	assert.Contains(t, line, "deadlock")
+	assert.Contains(t, line, "timeout")
+}
+
+func TestNew_RedactsOnlyIDFields(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json", "secret")
+	assert.NoError(t, err)
+
+	// короткий id совпадает с частью слов текста, заменяется только поле ошибки
+	dbErr := oops.NewDBError(errors.New("нет данных"), "LoadMenu", "дан")
+	logger.Warn("обертка", logging.ErrorKey, fmt.Errorf("загрузка меню: %w", dbErr))
+	logger.Warn("без полей", logging.UserIDKey, "дан", logging.ErrorKey, errors.New("нет данных"))
+
+	lines := decodeLines(t, &buf)
+	assert.Len(t, lines, 2)
+	pseudonym := logging.Pseudonym("secret", "дан")
+	assert.Equal(t, "загрузка меню: операция БД 'LoadMenu' для ID '"+pseudonym+"': нет данных", lines[0][logging.ErrorKey])
+	assert.Equal(t, "нет данных", lines[1][logging.ErrorKey])
+	assert.Equal(t, pseudonym, lines[1][logging.UserIDKey])
+}
+
+func TestPseudonym(t *testing.T) {
+	assert.Equal(t, logging.Pseudonym("secret", "kolya"), logging.Pseudonym("secret", "kolya"))
+	assert.NotEqual(t, logging.Pseudonym("secret", "kolya"), logging.Pseudonym("secret", "dan"))
+	// без ключа псевдоним не подобрать перебором id
+	assert.NotEqual(t, logging.Pseudonym("secret", "kolya"), logging.Pseudonym("other", "kolya"))
+	assert.Len(t, logging.Pseudonym("secret", "kolya"), 10)
+}
+
+func TestNew_InvalidSettings(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "verbose", "text", "")
+	assert.ErrorContains(t, err, "verbose")
+
+	_, err = logging.New(&bytes.Buffer{}, "info", "xml", "")
+	assert.ErrorContains(t, err, "xml")
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"":      slog.LevelInfo,
+		"debug": slog.LevelDebug,
+		"INFO":  slog.LevelInfo,
+		"warn":  slog.LevelWarn,
+		"error": slog.LevelError,
+	}
+	for level, want := range tests {
+		got, err := logging.ParseLevel(level)
+		assert.NoError(t, err, level)
+		assert.Equal(t, want, got, level)
+	}
+}
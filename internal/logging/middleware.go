@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса. Идентификатор, переданный клиентом или балансировщиком,
+// сохраняется, иначе создается новый. Он возвращается в ответе, чтобы по нему можно было найти записи журнала
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора из заголовка, чтобы клиент не раздувал журнал
+const maxRequestIDLength = 64
+
+// Middleware кладет в контекст идентификатор запроса и пишет в журнал по строке на каждый обработанный запрос.
+// В журнал попадает шаблон маршрута, а не путь и параметры: в них бывают id пользователей
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = NewRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+			ctx := WithRequestID(r.Context(), requestID)
+
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(ctx, level, "запрос обработан",
+				slog.String("method", r.Method),
+				slog.String("route", route),
+				slog.Int("status", status),
+				slog.Duration("duration", time.Since(start)))
+		})
+	}
+}
+
+// validRequestID проверяет, что идентификатор из заголовка не пустой, не слишком длинный
+// и состоит из видимых ASCII-символов
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
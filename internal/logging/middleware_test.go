@@ -0,0 +1,60 @@
+package logging_test
+
+import (
+	"bytes"
+	"menu_manager/internal/logging"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "info", "json", "secret")
+	assert.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Use(logging.Middleware(logger))
+	r.Get("/api/v1/profiles/{userID}", func(w http.ResponseWriter, r *http.Request) {
+		// идентификатор запроса доступен обработчику через контекст
+		logger.InfoContext(r.Context(), "обработчик", "handler_request_id", logging.RequestID(r.Context()))
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/profiles/kolya?user_id=kolya", nil)
+	req.Header.Set(logging.RequestIDHeader, "balancer-42")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	assert.Equal(t, "balancer-42", rec.Header().Get(logging.RequestIDHeader))
+	assert.NotContains(t, buf.String(), "kolya")
+
+	lines := decodeLines(t, &buf)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "balancer-42", lines[0]["handler_request_id"])
+	assert.Equal(t, "balancer-42", lines[0][logging.RequestIDKey])
+	assert.Equal(t, "/api/v1/profiles/{userID}", lines[1]["route"])
+	assert.Equal(t, float64(http.StatusNotFound), lines[1]["status"])
+	assert.Equal(t, "balancer-42", lines[1][logging.RequestIDKey])
+}
+
+func TestMiddleware_GeneratesRequestID(t *testing.T) {
+	logger, err := logging.New(&bytes.Buffer{}, "info", "text", "secret")
+	assert.NoError(t, err)
+	handler := logging.Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, header := range []string{"", "with space", strings.Repeat("x", 65)} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(logging.RequestIDHeader, header)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		requestID := rec.Header().Get(logging.RequestIDHeader)
+		assert.Len(t, requestID, 16, header)
+		assert.NotEqual(t, header, requestID)
+	}
+}
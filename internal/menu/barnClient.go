@@ -4,66 +4,335 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"math/rand/v2"
+	"menu_manager/internal/logging"
 	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
 	"net/http"
-	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default settings of the barn_manager client
+const (
+	DefaultBarnTimeout          = 10 * time.Second
+	DefaultBarnRetries          = 2
+	DefaultBarnRetryBaseDelay   = 100 * time.Millisecond
+	DefaultBarnRetryMaxDelay    = 2 * time.Second
+	DefaultBarnBreakerThreshold = 5
+	DefaultBarnBreakerCooldown  = 30 * time.Second
 )
 
 // Client represents an HTTP client for the barn_manager service
 type bClient struct {
 	baseURL string
 	client  *http.Client
+
+	// timeout limits a single attempt, retries get their own timeout
+	timeout        time.Duration
+	retries        int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	// jitter returns a random delay in [0, max), replaced in tests
+	jitter func(max time.Duration) time.Duration
+
+	breaker *circuitBreaker
+	stats   clientCounters
+	logger  *slog.Logger
+}
+
+// ClientStats contains counters of the barn_manager client since it was created
+type ClientStats struct {
+	// Requests is the number of GetProducts calls
+	Requests uint64 `json:"requests"`
+	// Attempts is the number of HTTP requests sent to barn_manager, including retries
+	Attempts uint64 `json:"attempts"`
+	Retries  uint64 `json:"retries"`
+	// Failures is the number of calls that returned an error
+	Failures uint64 `json:"failures"`
+	// Rejected is the number of calls rejected by the open circuit breaker without a request
+	Rejected     uint64 `json:"rejected"`
+	BreakerState string `json:"breaker_state"`
+}
+
+// StatusError is returned when barn_manager responds with a status other than 200 OK
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d, body: %s", e.StatusCode, e.Body)
+}
+
+type clientCounters struct {
+	requests, attempts, retries, failures, rejected atomic.Uint64
+}
+
+// ClientOption configures optional parameters of the barn_manager client
+type ClientOption func(*bClient)
+
+// WithTimeout limits the duration of a single request to barn_manager, 0 means no limit
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *bClient) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetries sets how many times a failed request is retried.
+// Only transport errors, timeouts, 429 and 5xx responses are retried, the check-availability request is idempotent.
+// Delays grow exponentially from baseDelay up to maxDelay with full jitter
+func WithRetries(retries int, baseDelay, maxDelay time.Duration) ClientOption {
+	return func(c *bClient) {
+		c.retries = retries
+		c.retryBaseDelay = baseDelay
+		c.retryMaxDelay = maxDelay
+	}
+}
+
+// WithCircuitBreaker opens the circuit after threshold consecutive failed requests,
+// calls then fail fast with oops.ErrCircuitOpen for cooldown. A zero threshold disables the breaker
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ClientOption {
+	return func(c *bClient) {
+		c.breaker = newCircuitBreaker(threshold, cooldown, time.Now)
+	}
+}
+
+// WithClientLogger sets the client logger, slog.Default is used by default.
+// Request bodies are never logged, they contain recipe ingredients
+func WithClientLogger(logger *slog.Logger) ClientOption {
+	return func(c *bClient) {
+		c.logger = logger
+	}
 }
 
 // NewClient creates a new client for the barn_manager service
-func NewClient(baseURL string) *bClient {
-	return &bClient{
-		baseURL: baseURL,
-		client:  &http.Client{},
+func NewClient(baseURL string, opts ...ClientOption) *bClient {
+	c := &bClient{
+		baseURL:        baseURL,
+		client:         &http.Client{},
+		timeout:        DefaultBarnTimeout,
+		retries:        DefaultBarnRetries,
+		retryBaseDelay: DefaultBarnRetryBaseDelay,
+		retryMaxDelay:  DefaultBarnRetryMaxDelay,
+		jitter:         fullJitter,
+		breaker:        newCircuitBreaker(DefaultBarnBreakerThreshold, DefaultBarnBreakerCooldown, time.Now),
+		logger:         slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
 }
 
 var JsonMarshal = json.Marshal
 
 // GetProducts retrieves products from the barn_manager service
-func (c *bClient) GetProducts(ctx context.Context, recipes []string) (string, error) {
+func (c *bClient) GetProducts(ctx context.Context, recipes []Recipe) (*ShoppingList, error) {
+	c.stats.requests.Add(1)
+
+	request, err := newCheckAvailabilityRequest(recipes)
+	if err != nil {
+		c.stats.failures.Add(1)
+		return nil, fmt.Errorf("invalid check-availability request: %w", err)
+	}
+	if len(request.Ingredients) == 0 {
+		// nothing to check, every recipe is just steps
+		return NewShoppingList(recipes, nil)
+	}
+	if err := request.Validate(); err != nil {
+		c.stats.failures.Add(1)
+		return nil, fmt.Errorf("invalid check-availability request: %w", err)
+	}
+
+	data, err := JsonMarshal(request)
+	if err != nil {
+		c.stats.failures.Add(1)
+		return nil, fmt.Errorf("failed to marshal product: %w", err)
+	}
+	c.logger.DebugContext(ctx, "checking availability in barn_manager", "ingredients", len(request.Ingredients))
+
+	for attempt := 0; ; attempt++ {
+		if !c.breaker.allow() {
+			c.stats.rejected.Add(1)
+			c.stats.failures.Add(1)
+			return nil, fmt.Errorf("%w: %w", oops.ErrBarnUnavailable, oops.ErrCircuitOpen)
+		}
+
+		c.stats.attempts.Add(1)
+		products, retryable, err := c.checkAvailability(ctx, data)
+		switch {
+		case ctx.Err() != nil:
+			// the caller gave up, this says nothing about barn_manager health
+			c.breaker.cancel()
+		case isClientError(err):
+			// barn_manager is up and rejected this request, which neither proves nor disproves its health
+			c.breaker.cancel()
+		default:
+			c.breaker.record(err == nil)
+		}
+		if err == nil {
+			return NewShoppingList(recipes, products)
+		}
+
+		if !retryable || attempt >= c.retries || ctx.Err() != nil {
+			c.stats.failures.Add(1)
+			return nil, err
+		}
+
+		c.logger.WarnContext(ctx, "barn_manager request failed, retrying", "attempt", attempt+1, logging.ErrorKey, err)
 
-	recipeString := "[" + strings.Join(recipes, ", ") + "]"
-	log.Println(recipeString)
-	data := []byte(recipeString)
+		c.stats.retries.Add(1)
+		if err := c.sleep(ctx, attempt); err != nil {
+			c.stats.failures.Add(1)
+			return nil, fmt.Errorf("%w: retry canceled: %w", oops.ErrBarnUnavailable, err)
+		}
+	}
+}
+
+// Ping checks that barn_manager is reachable. The service has no health endpoint, so the check-availability path
+// is requested with GET: any response below 500, including 405, means barn_manager is up and serving.
+// Ping bypasses retries, the circuit breaker and the client counters
+func (c *bClient) Ping(ctx context.Context) error {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+common.CheckAvailabilityPath, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", oops.ErrBarnUnavailable, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
 
-	// data, err := JsonMarshal(recipeString)
-	// if err != nil {
-	// 	return "", fmt.Errorf("failed to marshal product: %w", err)
-	// }
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: %w", oops.ErrBarnUnavailable, &StatusError{StatusCode: resp.StatusCode})
+	}
+	return nil
+}
+
+// Stats returns a snapshot of the client counters
+func (c *bClient) Stats() ClientStats {
+	return ClientStats{
+		Requests:     c.stats.requests.Load(),
+		Attempts:     c.stats.attempts.Load(),
+		Retries:      c.stats.retries.Load(),
+		Failures:     c.stats.failures.Load(),
+		Rejected:     c.stats.rejected.Load(),
+		BreakerState: c.breaker.currentState(),
+	}
+}
+
+// checkAvailability sends a single request and reports whether its failure may be retried
+func (c *bClient) checkAvailability(ctx context.Context, data []byte) ([]common.Product, bool, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+common.CheckAvailabilityPath, bytes.NewReader(data))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.client.Post(c.baseURL+"/api/v1/products/check-availability", "application/json", bytes.NewBuffer(data))
+	resp, err := c.client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to get products: %w", err)
+		return nil, true, fmt.Errorf("%w: failed to get products: %w", oops.ErrBarnUnavailable, err)
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		return nil, retryable, fmt.Errorf("%w: %w", oops.ErrBarnUnavailable, &StatusError{StatusCode: resp.StatusCode, Body: string(body)})
 	}
 
-	var productResp struct {
-		Products []common.Product `json:"products"`
+	var productResp common.CheckAvailabilityResponse
+	if err := json.NewDecoder(resp.Body).Decode(&productResp); err != nil {
+		// a body cut off by the timeout is worth another try, a malformed one is not
+		return nil, ctx.Err() != nil, fmt.Errorf("%w: failed to decode response: %w", oops.ErrBarnUnavailable, err)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&productResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	return productResp.Products, false, nil
+}
+
+// isClientError reports whether err is a 4xx response other than 429 Too Many Requests
+func isClientError(err error) bool {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return false
 	}
+	return statusErr.StatusCode >= http.StatusBadRequest && statusErr.StatusCode < http.StatusInternalServerError &&
+		statusErr.StatusCode != http.StatusTooManyRequests
+}
 
-	// Преобразуем результат в строку
-	resultBytes, err := json.Marshal(productResp)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal product response: %w", err)
+// newCheckAvailabilityRequest sums up the ingredients of all recipes per product, keeping the order of first appearance.
+// Amounts of one product in different units can not be summed, so such recipes are rejected
+func newCheckAvailabilityRequest(recipes []Recipe) (common.CheckAvailabilityRequest, error) {
+	request := common.CheckAvailabilityRequest{
+		Version:     common.CheckAvailabilityVersion,
+		Ingredients: make([]common.RequiredIngredient, 0),
+	}
+
+	index := make(map[string]int)
+	for _, recipe := range recipes {
+		for _, ingredient := range recipe.Ingredients {
+			i, ok := index[ingredient.ProductID]
+			if !ok {
+				index[ingredient.ProductID] = len(request.Ingredients)
+				request.Ingredients = append(request.Ingredients, common.RequiredIngredient{
+					ProductID: ingredient.ProductID,
+					Amount:    ingredient.Amount,
+					Unit:      ingredient.Unit,
+				})
+				continue
+			}
+
+			required := &request.Ingredients[i]
+			if required.Unit != ingredient.Unit {
+				return request, oops.NewValidationError("unit", fmt.Errorf("%w: product %s is measured in both %q and %q",
+					oops.ErrInvalidValue, ingredient.ProductID, required.Unit, ingredient.Unit))
+			}
+			required.Amount += ingredient.Amount
+		}
+	}
+	return request, nil
+}
+
+// sleep waits before the retry following attempt or until ctx is done
+func (c *bClient) sleep(ctx context.Context, attempt int) error {
+	delay := c.retryMaxDelay
+	if attempt < 32 && c.retryBaseDelay<<attempt < c.retryMaxDelay {
+		delay = c.retryBaseDelay << attempt
+	}
+
+	timer := time.NewTimer(c.jitter(delay))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func fullJitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
 	}
-	return string(resultBytes), nil
+	return rand.N(max)
 }
@@ -4,45 +4,86 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"menu_manager/internal/barnstub"
 	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetProducts_Success(t *testing.T) {
-	// Создаем мок-сервер
-	mockResponse := `"Eggs, Bread, Milk"`
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Проверяем, что запрос отправлен корректно
-		assert.Equal(t, http.MethodPost, r.Method)
-		assert.Equal(t, "/api/v1/products", r.URL.Path)
-		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
-
-		// Проверяем тело запроса
-		var recipes []string
-		err := json.NewDecoder(r.Body).Decode(&recipes)
-		assert.NoError(t, err)
-		assert.ElementsMatch(t, []string{"recipe1", "recipe2"}, recipes)
-
-		// Отправляем успешный ответ
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(mockResponse))
-	}))
+	// Запускаем заглушку barn manager
+	server := barnstub.Start([]common.Product{
+		{ID: "eggs", Name: "Eggs", WeightPerPkg: 10, Amount: 5, PresentInFridge: true},
+		{ID: "milk", Name: "Milk", WeightPerPkg: 1000, Amount: 0, PricePerPkg: 90},
+	})
 	defer server.Close()
 
+	recipes := []menu.Recipe{
+		{Ingredients: []menu.Ingredient{{ProductID: "eggs", Amount: 2, Unit: "шт"}}, Steps: []string{"Разбить яйца"}},
+		{Ingredients: []menu.Ingredient{{ProductID: "milk", Amount: 200, Unit: "мл"}}},
+	}
+
 	// Создаем клиента
 	client := menu.NewClient(server.URL)
 
 	// Вызываем метод GetProducts
-	recipes := []string{"recipe1", "recipe2"}
 	products, err := client.GetProducts(context.Background(), recipes)
 
 	// Проверяем результат
 	assert.NoError(t, err)
-	assert.Equal(t, "Eggs, Bread, Milk", products)
+	assert.Len(t, products.Items, 1)
+	assert.Equal(t, "milk", products.Items[0].Product.ID)
+	assert.Equal(t, uint(200), products.Items[0].ToBuy)
+	assert.Equal(t, uint(1), products.Items[0].Packages)
+
+	// Проверяем, что запрос отправлен корректно
+	requests := server.Requests()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "application/json", requests[0].ContentType)
+
+	var got common.CheckAvailabilityRequest
+	assert.NoError(t, json.Unmarshal(requests[0].Body, &got))
+	assert.Equal(t, common.CheckAvailabilityRequest{
+		Version: common.CheckAvailabilityVersion,
+		Ingredients: []common.RequiredIngredient{
+			{ProductID: "eggs", Amount: 2, Unit: "шт"},
+			{ProductID: "milk", Amount: 200, Unit: "мл"},
+		},
+	}, got)
+}
+
+// eggsRecipes рецепты с одним ингредиентом, для которых клиент отправляет запрос в barn manager
+var eggsRecipes = []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "eggs", Amount: 2, Unit: "шт"}}, Steps: []string{"Разбить яйца"}}}
+
+func TestPing(t *testing.T) {
+	server := barnstub.Start(nil)
+	client := menu.NewClient(server.URL, menu.WithTimeout(time.Second))
+	assert.NoError(t, client.Ping(context.Background()))
+
+	// проверка доступности не учитывается в статистике запросов
+	assert.Zero(t, client.Stats().Requests)
+
+	server.Close()
+	assert.ErrorIs(t, client.Ping(context.Background()), oops.ErrBarnUnavailable)
+
+	// 5xx означает, что barn manager не может обслуживать запросы
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	err := menu.NewClient(failing.URL).Ping(context.Background())
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+	var statusErr *menu.StatusError
+	if assert.ErrorAs(t, err, &statusErr) {
+		assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	}
 }
 
 func TestGetProducts_MarshalError(t *testing.T) {
@@ -55,47 +96,241 @@ func TestGetProducts_MarshalError(t *testing.T) {
 
 	client := menu.NewClient("http://example.com")
 
-	_, err := client.GetProducts(context.Background(), []string{"valid_string"})
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to marshal product")
 }
 
 func TestGetProducts_BadStatusCode(t *testing.T) {
-	// Создаем мок-сервер
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte("Invalid request"))
-	}))
+	// Заглушка отвечает 400 на все запросы
+	server := barnstub.Start(nil)
 	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{ErrorRate: 1, ErrorStatus: http.StatusBadRequest})
 
 	// Создаем клиента
 	client := menu.NewClient(server.URL)
 
 	// Вызываем метод GetProducts
-	recipes := []string{"recipe1", "recipe2"}
-	_, err := client.GetProducts(context.Background(), recipes)
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
 
 	// Проверяем, что ошибка корректно обработана
-	assert.Error(t, err)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
 	assert.Contains(t, err.Error(), "unexpected status code: 400")
 }
 
 func TestGetProducts_DecodeError(t *testing.T) {
-	// Создаем мок-сервер
-	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("{invalid-json"))
-	}))
+	// Заглушка отвечает некорректным JSON
+	server := barnstub.Start(nil)
 	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{Malformed: true})
 
 	// Создаем клиента
 	client := menu.NewClient(server.URL)
 
 	// Вызываем метод GetProducts
-	recipes := []string{"recipe1", "recipe2"}
-	_, err := client.GetProducts(context.Background(), recipes)
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
 
 	// Проверяем, что ошибка корректно обработана
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to decode response")
+	assert.Len(t, server.Requests(), 1)
+}
+
+func TestGetProducts_Timeout(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{Latency: time.Minute})
+
+	client := menu.NewClient(server.URL, menu.WithTimeout(50*time.Millisecond), menu.WithRetries(0, 0, 0))
+
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+}
+
+func TestGetProducts_RetriesTransientErrors(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{FailNext: 2})
+
+	client := menu.NewClient(server.URL, menu.WithRetries(2, time.Millisecond, 5*time.Millisecond))
+
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
+	assert.NoError(t, err)
+	assert.Len(t, server.Requests(), 3)
+	assert.Equal(t, menu.ClientStats{Requests: 1, Attempts: 3, Retries: 2, BreakerState: menu.BreakerClosed}, client.Stats())
+}
+
+func TestGetProducts_DoesNotRetryClientErrors(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{ErrorRate: 1, ErrorStatus: http.StatusBadRequest})
+
+	client := menu.NewClient(server.URL, menu.WithRetries(3, time.Millisecond, time.Millisecond))
+
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+	assert.Len(t, server.Requests(), 1)
+	assert.Equal(t, uint64(1), client.Stats().Failures)
+}
+
+func TestGetProducts_CircuitBreaker(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{ErrorRate: 1, ErrorStatus: http.StatusInternalServerError})
+
+	client := menu.NewClient(server.URL,
+		menu.WithRetries(0, 0, 0),
+		menu.WithCircuitBreaker(2, 50*time.Millisecond))
+	recipes := eggsRecipes
+
+	for range 2 {
+		_, err := client.GetProducts(context.Background(), recipes)
+		assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+	}
+	assert.Equal(t, menu.BreakerOpen, client.Stats().BreakerState)
+
+	// открытый breaker отвечает сразу, не обращаясь к barn manager
+	_, err := client.GetProducts(context.Background(), recipes)
+	assert.ErrorIs(t, err, oops.ErrCircuitOpen)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+	assert.Len(t, server.Requests(), 2)
+	assert.Equal(t, uint64(1), client.Stats().Rejected)
+
+	// после cooldown пробный запрос проходит и закрывает breaker
+	server.SetBehavior(barnstub.Behavior{})
+	time.Sleep(60 * time.Millisecond)
+	_, err = client.GetProducts(context.Background(), recipes)
+	assert.NoError(t, err)
+	assert.Equal(t, menu.BreakerClosed, client.Stats().BreakerState)
+}
+
+func TestGetProducts_CircuitBreakerIgnoresClientErrors(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+
+	client := menu.NewClient(server.URL,
+		menu.WithRetries(0, 0, 0),
+		menu.WithCircuitBreaker(2, time.Minute))
+	call := func(status int) {
+		server.SetBehavior(barnstub.Behavior{ErrorRate: 1, ErrorStatus: status})
+		_, err := client.GetProducts(context.Background(), eggsRecipes)
+		assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+	}
+
+	// ответы 4xx не открывают breaker
+	for range 3 {
+		call(http.StatusBadRequest)
+	}
+	assert.Equal(t, menu.BreakerClosed, client.Stats().BreakerState)
+
+	// но и не сбрасывают счетчик ошибок подряд
+	call(http.StatusInternalServerError)
+	call(http.StatusBadRequest)
+	call(http.StatusInternalServerError)
+	assert.Equal(t, menu.BreakerOpen, client.Stats().BreakerState)
+}
+
+func TestGetProducts_CircuitBreakerCountsMalformedResponses(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{Malformed: true})
+
+	client := menu.NewClient(server.URL,
+		menu.WithRetries(0, 0, 0),
+		menu.WithCircuitBreaker(2, time.Minute))
+
+	for range 2 {
+		_, err := client.GetProducts(context.Background(), eggsRecipes)
+		assert.ErrorContains(t, err, "failed to decode response")
+	}
+	assert.Equal(t, menu.BreakerOpen, client.Stats().BreakerState)
+
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrCircuitOpen)
+	assert.Len(t, server.Requests(), 2)
+}
+
+func TestGetProducts_ContextCanceled(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+	server.SetBehavior(barnstub.Behavior{ErrorRate: 1, ErrorStatus: http.StatusBadGateway})
+
+	client := menu.NewClient(server.URL,
+		menu.WithRetries(5, time.Hour, time.Hour),
+		menu.WithCircuitBreaker(1, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := client.GetProducts(ctx, eggsRecipes)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Len(t, server.Requests(), 1)
+}
+
+func TestGetProducts_AggregatesIngredients(t *testing.T) {
+	server := barnstub.Start([]common.Product{{ID: "milk", Name: "Milk", Amount: 100}})
+	defer server.Close()
+
+	client := menu.NewClient(server.URL)
+	recipes := []menu.Recipe{
+		{Ingredients: []menu.Ingredient{{ProductID: "milk", Amount: 200, Unit: "мл"}, {ProductID: "oats", Amount: 50, Unit: "г"}}},
+		{Ingredients: []menu.Ingredient{{ProductID: "milk", Amount: 100, Unit: "мл"}}},
+	}
+
+	products, err := client.GetProducts(context.Background(), recipes)
+	assert.NoError(t, err)
+	assert.Len(t, products.Items, 2)
+	assert.Equal(t, uint(200), products.Items[0].ToBuy)
+
+	var got common.CheckAvailabilityRequest
+	assert.NoError(t, json.Unmarshal(server.Requests()[0].Body, &got))
+	assert.Equal(t, []common.RequiredIngredient{
+		{ProductID: "milk", Amount: 300, Unit: "мл"},
+		{ProductID: "oats", Amount: 50, Unit: "г"},
+	}, got.Ingredients)
+}
+
+func TestGetProducts_InvalidRequest(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+
+	client := menu.NewClient(server.URL)
+	tests := []struct {
+		name    string
+		recipes []menu.Recipe
+		field   string
+	}{
+		{"пустой product_id", []menu.Recipe{{Ingredients: []menu.Ingredient{{Amount: 1, Unit: "г"}}}}, "ingredients[0].product_id"},
+		{"нулевое количество", []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "milk", Unit: "мл"}}}}, "ingredients[0].amount"},
+		{"разные единицы", []menu.Recipe{
+			{Ingredients: []menu.Ingredient{{ProductID: "milk", Amount: 200, Unit: "мл"}}},
+			{Ingredients: []menu.Ingredient{{ProductID: "milk", Amount: 1, Unit: "шт"}}},
+		}, "unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.GetProducts(context.Background(), tt.recipes)
+
+			var validationErr *oops.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+	// некорректный запрос не отправляется
+	assert.Empty(t, server.Requests())
+}
+
+func TestGetProducts_NoIngredients(t *testing.T) {
+	server := barnstub.Start(nil)
+	defer server.Close()
+
+	client := menu.NewClient(server.URL)
+
+	products, err := client.GetProducts(context.Background(), []menu.Recipe{{Steps: []string{"Вскипятить воду"}}})
+	assert.NoError(t, err)
+	assert.Empty(t, products.Items)
+	assert.Empty(t, server.Requests())
 }
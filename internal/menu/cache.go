@@ -0,0 +1,213 @@
+package menu
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"menu_manager/internal/cache"
+	"strconv"
+	"time"
+)
+
+// CachedStore кэширует меню пользователей и приемы пищи хранилища.
+// Изменение меню сбрасывает кэш меню пользователя, изменение блюд — кэш приемов пищи, в которые они входят.
+// Изменения, сделанные в обход CachedStore (например, другим экземпляром сервиса), видны по истечении TTL
+type CachedStore struct {
+	Store
+	menus *cache.Loader // ключ — userID
+	meals *cache.Loader // ключ — mealID
+}
+
+// NewCachedStore оборачивает store кэшем c: меню хранятся menuTTL, приемы пищи — mealTTL
+func NewCachedStore(store Store, c cache.Cache, menuTTL, mealTTL time.Duration) *CachedStore {
+	return &CachedStore{
+		Store: store,
+		menus: cache.NewLoader(c, "menu", menuTTL),
+		meals: cache.NewLoader(c, "meal", mealTTL),
+	}
+}
+
+// LoadMenu возвращает меню пользователя из кэша или хранилища
+func (s *CachedStore) LoadMenu(ctx context.Context, userID string) ([]Menu, error) {
+	return cache.Load(ctx, s.menus, userID, func(ctx context.Context) ([]Menu, error) {
+		return s.Store.LoadMenu(ctx, userID)
+	})
+}
+
+// LoadMeal возвращает прием пищи из кэша или хранилища
+func (s *CachedStore) LoadMeal(ctx context.Context, mealID string) (*Meal, error) {
+	return cache.Load(ctx, s.meals, mealID, func(ctx context.Context) (*Meal, error) {
+		return s.Store.LoadMeal(ctx, mealID)
+	})
+}
+
+// LoadMeals возвращает приемы пищи из кэша, недостающие загружаются из хранилища одним запросом
+func (s *CachedStore) LoadMeals(ctx context.Context, mealIDs []string) (map[string]*Meal, error) {
+	return cache.LoadMany(ctx, s.meals, mealIDs, func(ctx context.Context, missing []string) (map[string]*Meal, error) {
+		return s.Store.LoadMeals(ctx, missing)
+	})
+}
+
+// UpdateMenu обновляет меню и сбрасывает его кэш
+func (s *CachedStore) UpdateMenu(ctx context.Context, userID string, menuList []Menu) error {
+	err := s.Store.UpdateMenu(ctx, userID, menuList)
+	s.invalidate(ctx, s.menus, userID)
+	return err
+}
+
+// SaveMenuEntry сохраняет прием пищи и сбрасывает кэш меню пользователя
+func (s *CachedStore) SaveMenuEntry(ctx context.Context, userID string, entry Menu) error {
+	err := s.Store.SaveMenuEntry(ctx, userID, entry)
+	s.invalidate(ctx, s.menus, userID)
+	return err
+}
+
+// UpdateMenuEntry обновляет прием пищи и сбрасывает кэш меню пользователя
+func (s *CachedStore) UpdateMenuEntry(ctx context.Context, userID string, entry Menu) error {
+	err := s.Store.UpdateMenuEntry(ctx, userID, entry)
+	s.invalidate(ctx, s.menus, userID)
+	return err
+}
+
+// DeleteMenuEntry удаляет прием пищи и сбрасывает кэш меню пользователя
+func (s *CachedStore) DeleteMenuEntry(ctx context.Context, userID string, mealID string) error {
+	err := s.Store.DeleteMenuEntry(ctx, userID, mealID)
+	s.invalidate(ctx, s.menus, userID)
+	return err
+}
+
+// ReplaceMenuRange заменяет расписание и сбрасывает кэш меню пользователя и новых приемов пищи
+func (s *CachedStore) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []Menu, dishes []Dish) error {
+	err := s.Store.ReplaceMenuRange(ctx, userID, from, to, entries, dishes)
+	s.invalidate(ctx, s.menus, userID)
+
+	mealIDs := make([]string, 0, len(entries)+len(dishes))
+	for _, entry := range entries {
+		mealIDs = append(mealIDs, entry.MealID)
+	}
+	for _, dish := range dishes {
+		mealIDs = append(mealIDs, dish.MealID)
+	}
+	s.invalidate(ctx, s.meals, mealIDs...)
+	return err
+}
+
+// SaveDish сохраняет блюдо и сбрасывает кэш его приема пищи
+func (s *CachedStore) SaveDish(ctx context.Context, dish Dish) error {
+	err := s.Store.SaveDish(ctx, dish)
+	s.invalidate(ctx, s.meals, dish.MealID)
+	return err
+}
+
+// UpdateDish обновляет блюдо и сбрасывает кэш приема пищи, в который блюдо входило и входит теперь
+func (s *CachedStore) UpdateDish(ctx context.Context, dish Dish) error {
+	mealIDs := []string{dish.MealID}
+	if old, err := s.Store.LoadDish(ctx, dish.DishID); err == nil && old.MealID != dish.MealID {
+		mealIDs = append(mealIDs, old.MealID)
+	}
+
+	err := s.Store.UpdateDish(ctx, dish)
+	s.invalidate(ctx, s.meals, mealIDs...)
+	return err
+}
+
+// DeleteDish удаляет блюдо и сбрасывает кэш его приема пищи
+func (s *CachedStore) DeleteDish(ctx context.Context, dishID string) error {
+	old, loadErr := s.Store.LoadDish(ctx, dishID)
+
+	err := s.Store.DeleteDish(ctx, dishID)
+	if loadErr == nil {
+		s.invalidate(ctx, s.meals, old.MealID)
+	}
+	return err
+}
+
+// CacheStats возвращает счетчики кэша меню и приемов пищи
+func (s *CachedStore) CacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{"menus": s.menus.Stats(), "meals": s.meals.Stats()}
+}
+
+// invalidate сбрасывает ключи кэша. Ошибка кэша не отменяет уже сделанное изменение, поэтому только логируется
+func (s *CachedStore) invalidate(ctx context.Context, l *cache.Loader, keys ...string) {
+	if err := l.Invalidate(ctx, keys...); err != nil {
+		slog.WarnContext(ctx, "не удалось сбросить кэш", "error", err)
+	}
+}
+
+// shoppingGenerationKey ключ текущего поколения списков покупок. Смена поколения делает недоступными
+// все сохраненные списки, так запасы сбрасываются без перебора ключей
+const shoppingGenerationKey = "shopping:generation"
+
+// CachedClient кэширует списки покупок barn manager для одинаковых наборов рецептов.
+// Запасы меняются на стороне barn manager, поэтому об их изменении нужно сообщить через InvalidateInventory
+type CachedClient struct {
+	client Client
+	cache  cache.Cache
+	lists  *cache.Loader // ключ — поколение и хэш рецептов
+}
+
+// NewCachedClient оборачивает client кэшем c, списки покупок хранятся ttl
+func NewCachedClient(client Client, c cache.Cache, ttl time.Duration) *CachedClient {
+	return &CachedClient{
+		client: client,
+		cache:  c,
+		lists:  cache.NewLoader(c, "shopping", ttl),
+	}
+}
+
+// GetProducts возвращает список покупок для рецептов из кэша или barn manager
+func (c *CachedClient) GetProducts(ctx context.Context, recipes []Recipe) (*ShoppingList, error) {
+	key, err := recipesKey(recipes)
+	if err != nil {
+		return c.client.GetProducts(ctx, recipes)
+	}
+
+	return cache.Load(ctx, c.lists, c.generation(ctx)+":"+key, func(ctx context.Context) (*ShoppingList, error) {
+		return c.client.GetProducts(ctx, recipes)
+	})
+}
+
+// InvalidateInventory сбрасывает все сохраненные списки покупок, вызывается при изменении запасов
+func (c *CachedClient) InvalidateInventory(ctx context.Context) error {
+	if err := c.lists.Invalidate(ctx); err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, shoppingGenerationKey, []byte(newGeneration()), 0)
+}
+
+// CacheStats возвращает счетчики кэша списков покупок
+func (c *CachedClient) CacheStats() map[string]cache.Stats {
+	return map[string]cache.Stats{"shopping_lists": c.lists.Stats()}
+}
+
+// generation возвращает текущее поколение списков покупок, создавая его при первом обращении
+func (c *CachedClient) generation(ctx context.Context) string {
+	value, ok, err := c.cache.Get(ctx, shoppingGenerationKey)
+	if err == nil && ok {
+		return string(value)
+	}
+
+	// поколение неизвестно: старые списки могли устареть, поэтому начинаем новое
+	generation := newGeneration()
+	if err := c.cache.Set(ctx, shoppingGenerationKey, []byte(generation), 0); err != nil {
+		slog.WarnContext(ctx, "не удалось сохранить поколение списков покупок", "error", err)
+	}
+	return generation
+}
+
+func newGeneration() string {
+	return strconv.FormatInt(time.Now().UnixNano(), 36)
+}
+
+// recipesKey возвращает хэш рецептов: одинаковые наборы рецептов дают одинаковый список покупок
+func recipesKey(recipes []Recipe) (string, error) {
+	data, err := json.Marshal(recipes)
+	if err != nil {
+		return "", fmt.Errorf("не удалось сериализовать рецепты: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,204 @@
+package menu_test
+
+import (
+	"context"
+	"fmt"
+	"menu_manager/internal/barnstub"
+	"menu_manager/internal/cache"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedStore_LoadMeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	store := menu.NewCachedStore(mockStore, cache.NewMemory(0), time.Minute, time.Minute)
+	ctx := context.Background()
+
+	meal := &menu.Meal{MealID: "meal1", DishIDs: []string{"dish1"}, DishNames: []string{"Омлет"}, Recipes: []menu.Recipe{}}
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal1").Return(meal, nil).Times(1)
+
+	for range 3 {
+		got, err := store.LoadMeal(ctx, "meal1")
+		assert.NoError(t, err)
+		assert.Equal(t, meal, got)
+	}
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 1}, store.CacheStats()["meals"])
+}
+
+func TestCachedStore_DishChangesInvalidateMeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	store := menu.NewCachedStore(mockStore, cache.NewMemory(0), time.Minute, time.Minute)
+	ctx := context.Background()
+
+	dish := menu.Dish{DishID: "dish1", MealID: "meal1", Name: "Омлет"}
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal1").Return(&menu.Meal{MealID: "meal1", DishNames: []string{"Омлет"}}, nil)
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal2").Return(&menu.Meal{MealID: "meal2"}, nil)
+	_, _ = store.LoadMeal(ctx, "meal1")
+	_, _ = store.LoadMeal(ctx, "meal2")
+
+	// блюдо переносится в другой прием пищи: устаревают оба
+	moved := dish
+	moved.MealID = "meal2"
+	mockStore.EXPECT().LoadDish(ctx, "dish1").Return(&dish, nil)
+	mockStore.EXPECT().UpdateDish(ctx, moved).Return(nil)
+	assert.NoError(t, store.UpdateDish(ctx, moved))
+
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal1").Return(&menu.Meal{MealID: "meal1"}, nil)
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal2").Return(&menu.Meal{MealID: "meal2", DishNames: []string{"Омлет"}}, nil)
+	got, err := store.LoadMeal(ctx, "meal1")
+	assert.NoError(t, err)
+	assert.Empty(t, got.DishNames)
+	got, err = store.LoadMeal(ctx, "meal2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Омлет"}, got.DishNames)
+
+	// удаление блюда сбрасывает его прием пищи
+	mockStore.EXPECT().LoadDish(ctx, "dish1").Return(&moved, nil)
+	mockStore.EXPECT().DeleteDish(ctx, "dish1").Return(nil)
+	assert.NoError(t, store.DeleteDish(ctx, "dish1"))
+
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal2").Return(&menu.Meal{MealID: "meal2"}, nil)
+	got, err = store.LoadMeal(ctx, "meal2")
+	assert.NoError(t, err)
+	assert.Empty(t, got.DishNames)
+}
+
+func TestCachedStore_MenuChangesInvalidateMenu(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	store := menu.NewCachedStore(mockStore, cache.NewMemory(0), time.Minute, time.Minute)
+	ctx := context.Background()
+
+	at := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	entry := menu.Menu{MealID: "meal1", Time: at, MealType: "breakfast"}
+	mockStore.EXPECT().LoadMenu(gomock.Any(), "kolya").Return([]menu.Menu{entry}, nil)
+	got, err := store.LoadMenu(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{entry}, got)
+
+	changes := []func() error{
+		func() error { return store.SaveMenuEntry(ctx, "kolya", entry) },
+		func() error { return store.UpdateMenuEntry(ctx, "kolya", entry) },
+		func() error { return store.DeleteMenuEntry(ctx, "kolya", "meal1") },
+		func() error { return store.UpdateMenu(ctx, "kolya", []menu.Menu{entry}) },
+		func() error { return store.ReplaceMenuRange(ctx, "kolya", at, at.Add(24*time.Hour), nil, nil) },
+	}
+	mockStore.EXPECT().SaveMenuEntry(ctx, "kolya", entry).Return(nil)
+	mockStore.EXPECT().UpdateMenuEntry(ctx, "kolya", entry).Return(nil)
+	mockStore.EXPECT().DeleteMenuEntry(ctx, "kolya", "meal1").Return(nil)
+	mockStore.EXPECT().UpdateMenu(ctx, "kolya", []menu.Menu{entry}).Return(nil)
+	mockStore.EXPECT().ReplaceMenuRange(ctx, "kolya", at, at.Add(24*time.Hour), nil, nil).Return(nil)
+
+	for _, change := range changes {
+		assert.NoError(t, change())
+		mockStore.EXPECT().LoadMenu(gomock.Any(), "kolya").Return([]menu.Menu{entry}, nil)
+		_, err := store.LoadMenu(ctx, "kolya")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, uint64(6), store.CacheStats()["menus"].Misses)
+}
+
+func TestCachedStore_ErrorsAreNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	store := menu.NewCachedStore(mockStore, cache.NewMemory(0), time.Minute, time.Minute)
+
+	mockStore.EXPECT().LoadMenu(gomock.Any(), "kolya").Return(nil, oops.ErrNoData).Times(2)
+	for range 2 {
+		_, err := store.LoadMenu(context.Background(), "kolya")
+		assert.ErrorIs(t, err, oops.ErrNoData)
+	}
+}
+
+func TestCachedClient_GetProducts(t *testing.T) {
+	server := barnstub.Start([]common.Product{{ID: "eggs", Name: "Eggs", Amount: 1}})
+	defer server.Close()
+
+	client := menu.NewCachedClient(menu.NewClient(server.URL), cache.NewMemory(0), time.Minute)
+	ctx := context.Background()
+
+	for range 3 {
+		list, err := client.GetProducts(ctx, eggsRecipes)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), list.Items[0].ToBuy)
+	}
+	assert.Len(t, server.Requests(), 1)
+
+	// другие рецепты — другой список покупок
+	_, err := client.GetProducts(ctx, []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "eggs", Amount: 5, Unit: "шт"}}}})
+	assert.NoError(t, err)
+	assert.Len(t, server.Requests(), 2)
+
+	// после изменения запасов список запрашивается заново
+	server.SetProducts([]common.Product{{ID: "eggs", Name: "Eggs", Amount: 2}})
+	assert.NoError(t, client.InvalidateInventory(ctx))
+	list, err := client.GetProducts(ctx, eggsRecipes)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Items)
+	assert.Len(t, server.Requests(), 3)
+
+	assert.Equal(t, cache.Stats{Hits: 2, Misses: 3, Invalidations: 1}, client.CacheStats()["shopping_lists"])
+}
+
+func TestCachedClient_ErrorsAreNotCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockClient := mocks.NewMockClient(ctrl)
+	client := menu.NewCachedClient(mockClient, cache.NewMemory(0), time.Minute)
+	expected := &menu.ShoppingList{Items: []menu.ShoppingItem{{Product: common.Product{ID: "eggs"}, ToBuy: 2, Unit: "шт"}}}
+
+	gomock.InOrder(
+		mockClient.EXPECT().GetProducts(gomock.Any(), eggsRecipes).Return(nil, fmt.Errorf("%w: connection refused", oops.ErrBarnUnavailable)),
+		mockClient.EXPECT().GetProducts(gomock.Any(), eggsRecipes).Return(expected, nil),
+	)
+
+	_, err := client.GetProducts(context.Background(), eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+
+	for range 2 {
+		list, err := client.GetProducts(context.Background(), eggsRecipes)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, list)
+	}
+}
+
+func TestCachedStore_LoadMeals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	store := menu.NewCachedStore(mockStore, cache.NewMemory(0), time.Minute, time.Minute)
+	ctx := context.Background()
+
+	mockStore.EXPECT().LoadMeal(gomock.Any(), "meal1").Return(&menu.Meal{MealID: "meal1"}, nil)
+	_, err := store.LoadMeal(ctx, "meal1")
+	assert.NoError(t, err)
+
+	// закэшированный прием пищи не запрашивается повторно
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal2"}).Return(map[string]*menu.Meal{"meal2": {MealID: "meal2"}}, nil)
+	meals, err := store.LoadMeals(ctx, []string{"meal1", "meal2"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*menu.Meal{"meal1": {MealID: "meal1"}, "meal2": {MealID: "meal2"}}, meals)
+
+	meals, err = store.LoadMeals(ctx, []string{"meal1", "meal2"})
+	assert.NoError(t, err)
+	assert.Len(t, meals, 2)
+}
@@ -0,0 +1,99 @@
+package menu_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Контрактные тесты клиента barn manager. Файлы в testdata/contract описывают запрос и ответ
+// POST /api/v1/products/check-availability так, как их понимают обе стороны:
+// изменение контракта должно сопровождаться новой версией и обновлением этих файлов
+
+// contractRecipes рецепты, из которых получается запрос check_availability_request_v1.json
+var contractRecipes = []menu.Recipe{
+	{Ingredients: []menu.Ingredient{
+		{ProductID: "eggs", Amount: 2, Unit: "шт"},
+		{ProductID: "milk", Amount: 200, Unit: "мл"},
+	}},
+	{Ingredients: []menu.Ingredient{
+		{ProductID: "eggs", Amount: 1, Unit: "шт"},
+		{ProductID: "flour", Amount: 150, Unit: "г"},
+	}},
+}
+
+func readContract(t *testing.T, name string) []byte {
+	data, err := os.ReadFile("testdata/contract/" + name)
+	assert.NoError(t, err)
+	return data
+}
+
+func TestContract_CheckAvailability(t *testing.T) {
+	golden := readContract(t, "check_availability_request_v1.json")
+	response := readContract(t, "check_availability_response_v1.json")
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, common.CheckAvailabilityPath, r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ = io.ReadAll(r.Body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(response)
+	}))
+	defer server.Close()
+
+	list, err := menu.NewClient(server.URL).GetProducts(context.Background(), contractRecipes)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(golden), string(body))
+
+	// яиц в холодильнике не хватает, молока нет совсем, о муке barn manager не знает
+	assert.Equal(t, []menu.ShoppingItem{
+		{
+			Product: common.Product{
+				ID: "eggs", Name: "Яйца", WeightPerPkg: 10, Amount: 1, PricePerPkg: 120,
+				ExpirationDate: "2026-11-01T00:00:00Z", PresentInFridge: true,
+				NutritionalValueRelative: common.NutritionalValueRelative{Proteins: 13, Fats: 11, Carbohydrates: 1, Calories: 155},
+			},
+			Required: 3, ToBuy: 2, Unit: "шт", Packages: 1,
+		},
+		{
+			Product: common.Product{
+				ID: "milk", Name: "Молоко", WeightPerPkg: 1000, PricePerPkg: 90,
+				ExpirationDate:           "2026-10-25T00:00:00Z",
+				NutritionalValueRelative: common.NutritionalValueRelative{Proteins: 3, Fats: 3, Carbohydrates: 5, Calories: 60},
+			},
+			Required: 200, ToBuy: 200, Unit: "мл", Packages: 1,
+		},
+		{
+			Product:  common.Product{ID: "flour", Name: "flour"},
+			Required: 150, ToBuy: 150, Unit: "г",
+		},
+	}, list.Items)
+}
+
+func TestContract_GoldenFilesMatchTypes(t *testing.T) {
+	// каждое поле контракта должно иметь поле в структуре, иначе переименование на стороне barn manager пройдет незамеченным
+	var request common.CheckAvailabilityRequest
+	decoder := json.NewDecoder(bytes.NewReader(readContract(t, "check_availability_request_v1.json")))
+	decoder.DisallowUnknownFields()
+	assert.NoError(t, decoder.Decode(&request))
+	assert.NoError(t, request.Validate())
+	assert.Equal(t, common.CheckAvailabilityVersion, request.Version)
+
+	var response common.CheckAvailabilityResponse
+	decoder = json.NewDecoder(bytes.NewReader(readContract(t, "check_availability_response_v1.json")))
+	decoder.DisallowUnknownFields()
+	assert.NoError(t, decoder.Decode(&response))
+	assert.Len(t, response.Products, 2)
+}
@@ -0,0 +1,58 @@
+package menu
+
+import (
+	"sync"
+	"time"
+)
+
+// shoppingListCache хранит последний успешно полученный список покупок каждого пользователя.
+// Список привязан к приему пищи: для другого приема пищи он не подходит.
+// Методы nil-кэша ничего не делают, так запасной вариант отключается
+type shoppingListCache struct {
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cachedShoppingList // ключ — userID
+}
+
+type cachedShoppingList struct {
+	mealID    string
+	list      *ShoppingList
+	fetchedAt time.Time
+}
+
+func newShoppingListCache(maxAge time.Duration) *shoppingListCache {
+	return &shoppingListCache{maxAge: maxAge, entries: make(map[string]cachedShoppingList)}
+}
+
+// put запоминает список покупок для приема пищи mealID пользователя userID
+func (c *shoppingListCache) put(userID, mealID string, list *ShoppingList, now time.Time) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[userID] = cachedShoppingList{mealID: mealID, list: list, fetchedAt: now}
+}
+
+// get возвращает сохраненный список покупок для приема пищи mealID и время его получения.
+// Список старше maxAge не возвращается, нулевой maxAge означает, что срок не ограничен
+func (c *shoppingListCache) get(userID, mealID string, now time.Time) (*ShoppingList, time.Time, bool) {
+	if c == nil {
+		return nil, time.Time{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[userID]
+	if !ok || entry.mealID != mealID {
+		return nil, time.Time{}, false
+	}
+	if c.maxAge > 0 && now.Sub(entry.fetchedAt) > c.maxAge {
+		delete(c.entries, userID)
+		return nil, time.Time{}, false
+	}
+	return entry.list, entry.fetchedAt, true
+}
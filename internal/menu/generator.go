@@ -0,0 +1,204 @@
+package menu
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"sort"
+	"time"
+)
+
+// mealSlot описывает прием пищи в дне: когда он проходит и какую долю дневной нормы покрывает
+type mealSlot struct {
+	Type   MealType
+	Hour   int
+	Minute int
+	Share  float64
+}
+
+// dailySlots расписание приемов пищи генерируемого меню
+var dailySlots = []mealSlot{
+	{Type: MealTypeBreakfast, Hour: 8, Minute: 0, Share: 0.25},
+	{Type: MealTypeLunch, Hour: 13, Minute: 0, Share: 0.35},
+	{Type: MealTypeSnack, Hour: 16, Minute: 30, Share: 0.10},
+	{Type: MealTypeDinner, Hour: 19, Minute: 0, Share: 0.30},
+}
+
+const (
+	// repeatPenalty штраф за каждое повторение блюда в периоде
+	repeatPenalty = 0.5
+	// sameDayPenalty штраф за повторение блюда в течение одного дня
+	sameDayPenalty = 5.0
+	// mealTypePenalty штраф за блюдо, которое в расписании относилось к другому типу приема пищи
+	mealTypePenalty = 10.0
+)
+
+// GenerateMenu составляет меню на период из каталога блюд под дневную норму пользователя
+// и заменяет им расписание за этот период
+func (s *AppService) GenerateMenu(ctx context.Context, userID string, req GenerateRequest) (*MenuView, error) {
+	if req.Targets.Calories == 0 {
+		return nil, oops.NewValidationError("targets.calories", oops.ErrEmptyValue)
+	}
+
+	now, err := s.userNow(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	start, end, err := viewPeriod(req.From, req.To, now, now.Location())
+	if err != nil {
+		return nil, err
+	}
+
+	catalog, err := s.storage.LoadDishes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err = s.permittedDishes(ctx, userID, uniqueDishes(catalog))
+	if err != nil {
+		return nil, err
+	}
+	if len(catalog) == 0 {
+		return nil, oops.ErrRecipeNotFound
+	}
+
+	entries, dishes, err := PlanMenu(catalog, start, end, req.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.ReplaceMenuRange(ctx, userID, start, end, entries, dishes); err != nil {
+		return nil, err
+	}
+	return s.GetMenuView(ctx, userID, start, end.AddDate(0, 0, -1))
+}
+
+// PlanMenu распределяет блюда каталога по приемам пищи каждого дня в полуинтервале [start, end).
+// Для каждого приема пищи выбирается блюдо, ближе всего подходящее к его доле дневной нормы,
+// предпочтительно того же типа приема пищи; повторы блюд штрафуются.
+// Каждому приему пищи достается копия блюда с новыми ID
+func PlanMenu(catalog []Dish, start, end time.Time, targets NutritionTargets) ([]Menu, []Dish, error) {
+	entries := make([]Menu, 0)
+	dishes := make([]Dish, 0)
+	uses := make(map[string]int)
+
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		usedToday := make(map[string]bool)
+
+		for _, slot := range dailySlots {
+			best := pickDish(catalog, slot.Type, slotTargets(targets, slot.Share), uses, usedToday)
+			uses[best.Name]++
+			usedToday[best.Name] = true
+
+			mealID, err := newID()
+			if err != nil {
+				return nil, nil, err
+			}
+			dishID, err := newID()
+			if err != nil {
+				return nil, nil, err
+			}
+			source := best.SourceDishID
+			if source == "" {
+				source = best.DishID
+			}
+
+			entries = append(entries, Menu{
+				MealID:   mealID,
+				Time:     time.Date(day.Year(), day.Month(), day.Day(), slot.Hour, slot.Minute, 0, 0, day.Location()),
+				MealType: string(slot.Type),
+			})
+			dishes = append(dishes, Dish{
+				DishID:         dishID,
+				MealID:         mealID,
+				Name:           best.Name,
+				Recipe:         best.Recipe,
+				TotalNutrition: best.TotalNutrition,
+				SourceDishID:   source,
+			})
+		}
+	}
+	return entries, dishes, nil
+}
+
+// pickDish выбирает из каталога блюдо с наименьшим отклонением от нормы с учетом повторов.
+// Блюда другого типа приема пищи штрафуются сильнее повторов, блюда без типа штрафа не получают
+func pickDish(catalog []Dish, mealType MealType, target common.NutritionalValueAbsolute, uses map[string]int, usedToday map[string]bool) Dish {
+	best := catalog[0]
+	bestScore := math.Inf(1)
+	for _, dish := range catalog {
+		score := nutritionDistance(dish.TotalNutrition, target) + repeatPenalty*float64(uses[dish.Name])
+		if usedToday[dish.Name] {
+			score += sameDayPenalty
+		}
+		if dish.MealType != "" && dish.MealType != string(mealType) {
+			score += mealTypePenalty
+		}
+		if score < bestScore {
+			best, bestScore = dish, score
+		}
+	}
+	return best
+}
+
+// nutritionDistance считает относительное отклонение пищевой ценности от целевой.
+// Калории весят вдвое больше макронутриентов, незаданные цели не учитываются
+func nutritionDistance(value, target common.NutritionalValueAbsolute) float64 {
+	distance := 2 * relativeDiff(value.Calories, target.Calories)
+	distance += relativeDiff(value.Proteins, target.Proteins)
+	distance += relativeDiff(value.Fats, target.Fats)
+	distance += relativeDiff(value.Carbohydrates, target.Carbohydrates)
+	return distance
+}
+
+func relativeDiff(value, target uint) float64 {
+	if target == 0 {
+		return 0
+	}
+	return math.Abs(float64(value)-float64(target)) / float64(target)
+}
+
+// slotTargets возвращает долю дневной нормы, приходящуюся на один прием пищи
+func slotTargets(targets NutritionTargets, share float64) common.NutritionalValueAbsolute {
+	part := func(v uint) uint { return uint(math.Round(float64(v) * share)) }
+	return common.NutritionalValueAbsolute{
+		Proteins:      part(targets.Proteins),
+		Fats:          part(targets.Fats),
+		Carbohydrates: part(targets.Carbohydrates),
+		Calories:      part(targets.Calories),
+	}
+}
+
+// uniqueDishes убирает из каталога копии блюд, созданные предыдущими генерациями,
+// и оставляет по одному блюду на название и тип приема пищи
+func uniqueDishes(catalog []Dish) []Dish {
+	type key struct{ name, mealType string }
+	seen := make(map[key]bool, len(catalog))
+	unique := make([]Dish, 0, len(catalog))
+	for _, dish := range catalog {
+		k := key{dish.Name, dish.MealType}
+		if dish.SourceDishID != "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		unique = append(unique, dish)
+	}
+	sort.SliceStable(unique, func(i, j int) bool {
+		if unique[i].Name != unique[j].Name {
+			return unique[i].Name < unique[j].Name
+		}
+		return unique[i].MealType < unique[j].MealType
+	})
+	return unique
+}
+
+// newID генерирует случайный идентификатор для новых приемов пищи и блюд
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
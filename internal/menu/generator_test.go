@@ -0,0 +1,145 @@
+package menu_test
+
+import (
+	"context"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func testCatalog() []menu.Dish {
+	dish := func(id, name string, calories, proteins uint) menu.Dish {
+		return menu.Dish{
+			DishID:         id,
+			MealID:         "m" + id,
+			Name:           name,
+			Recipe:         menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: name, Amount: 100, Unit: "г"}}},
+			TotalNutrition: common.NutritionalValueAbsolute{Calories: calories, Proteins: proteins},
+		}
+	}
+	return []menu.Dish{
+		dish("1", "Овсяная каша", 500, 20),
+		dish("2", "Куриный суп", 700, 45),
+		dish("3", "Яблоко", 200, 1),
+		dish("4", "Рыба с овощами", 600, 40),
+		dish("5", "Омлет", 450, 25),
+	}
+}
+
+func TestPlanMenu(t *testing.T) {
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 2)
+	targets := menu.NutritionTargets{Calories: 2000, Proteins: 100}
+
+	entries, dishes, err := menu.PlanMenu(testCatalog(), start, end, targets)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 8)
+	assert.Len(t, dishes, 8)
+
+	// первый день: каждому приему пищи достается блюдо, ближайшее к его доле нормы
+	assert.Equal(t, "Овсяная каша", dishes[0].Name)
+	assert.Equal(t, "Куриный суп", dishes[1].Name)
+	assert.Equal(t, "Яблоко", dishes[2].Name)
+	assert.Equal(t, "Рыба с овощами", dishes[3].Name)
+
+	assert.Equal(t, string(menu.MealTypeBreakfast), entries[0].MealType)
+	assert.Equal(t, time.Date(2024, 3, 18, 8, 0, 0, 0, time.UTC), entries[0].Time)
+	assert.Equal(t, time.Date(2024, 3, 19, 19, 0, 0, 0, time.UTC), entries[7].Time)
+
+	for i := range entries {
+		assert.Equal(t, entries[i].MealID, dishes[i].MealID)
+		assert.NotEmpty(t, dishes[i].DishID)
+		assert.NotEqual(t, dishes[i].DishID, dishes[i].SourceDishID)
+	}
+	assert.Equal(t, "1", dishes[0].SourceDishID)
+
+	// на второй день повторяющийся завтрак вытесняется менее использованным блюдом
+	assert.Equal(t, "Омлет", dishes[4].Name)
+
+	// в пределах одного дня блюда не повторяются
+	for day := 0; day < 2; day++ {
+		names := make(map[string]bool)
+		for _, d := range dishes[day*4 : day*4+4] {
+			assert.False(t, names[d.Name], d.Name)
+			names[d.Name] = true
+		}
+	}
+}
+
+func TestPlanMenu_PrefersMealType(t *testing.T) {
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	targets := menu.NutritionTargets{Calories: 2000}
+
+	dish := func(id, name, mealType string, calories uint) menu.Dish {
+		return menu.Dish{DishID: id, Name: name, MealType: mealType, TotalNutrition: common.NutritionalValueAbsolute{Calories: calories}}
+	}
+	// по калориям сырники и плов одинаково подходят и завтраку, и ужину
+	catalog := []menu.Dish{
+		dish("1", "Плов", string(menu.MealTypeDinner), 550),
+		dish("2", "Сырники", string(menu.MealTypeBreakfast), 550),
+		dish("3", "Яблоко", "", 200),
+	}
+
+	_, dishes, err := menu.PlanMenu(catalog, start, start.AddDate(0, 0, 1), targets)
+	assert.NoError(t, err)
+	assert.Len(t, dishes, 4)
+	assert.Equal(t, "Сырники", dishes[0].Name)
+	assert.Equal(t, "Плов", dishes[3].Name)
+	// блюдо без типа подходит любому приему пищи
+	assert.Equal(t, "Яблоко", dishes[2].Name)
+}
+
+func TestGenerateMenu(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+
+	// копии блюд из прошлых генераций не должны попадать в меню дважды
+	catalog := append(testCatalog(), menu.Dish{DishID: "6", Name: "Омлет", SourceDishID: "5", TotalNutrition: common.NutritionalValueAbsolute{Calories: 450}})
+
+	mockStore.EXPECT().LoadDishes(ctx).Return(catalog, nil)
+	mockStore.EXPECT().ReplaceMenuRange(ctx, "123", start, end, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _, _ time.Time, entries []menu.Menu, dishes []menu.Dish) error {
+			assert.Len(t, entries, 28)
+			assert.Len(t, dishes, 28)
+			for _, dish := range dishes {
+				assert.NotEqual(t, "6", dish.SourceDishID)
+			}
+			return nil
+		})
+	mockStore.EXPECT().LoadMenuRange(ctx, "123", start, end).Return([]menu.Menu{}, nil)
+
+	view, err := service.GenerateMenu(ctx, "123", menu.GenerateRequest{From: from, Targets: menu.NutritionTargets{Calories: 2000}})
+	assert.NoError(t, err)
+	assert.Len(t, view.Days, 7)
+}
+
+func TestGenerateMenu_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	_, err := service.GenerateMenu(context.Background(), "123", menu.GenerateRequest{})
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "targets.calories", validationErr.Field)
+
+	mockStore.EXPECT().LoadDishes(gomock.Any()).Return([]menu.Dish{}, nil)
+	_, err = service.GenerateMenu(context.Background(), "123", menu.GenerateRequest{Targets: menu.NutritionTargets{Calories: 2000}})
+	assert.ErrorIs(t, err, oops.ErrRecipeNotFound)
+}
@@ -2,8 +2,13 @@ package menu
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"menu_manager/internal/oops"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -12,20 +17,54 @@ import (
 type Handler struct {
 	router  *chi.Mux
 	service Service
+	logger  *slog.Logger
+}
+
+// HandlerOption настраивает необязательные параметры обработчика
+type HandlerOption func(*Handler)
+
+// WithHandlerLogger задает журнал обработчика, по умолчанию используется slog.Default
+func WithHandlerLogger(logger *slog.Logger) HandlerOption {
+	return func(h *Handler) {
+		h.logger = logger
+	}
 }
 
 // NewHandler создает новый обработчик HTTP-запросов
-func NewHandler(router *chi.Mux, service Service) *Handler {
-	return &Handler{
+func NewHandler(router *chi.Mux, service Service, opts ...HandlerOption) *Handler {
+	h := &Handler{
 		router:  router,
 		service: service,
+		logger:  slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // Register регистрирует все обработчики маршрутов
 func (h *Handler) Register() {
 	h.router.Route("/api/v1", func(r chi.Router) {
+		r.Get("/menus", h.getMenuView)
 		r.Get("/menus/getMeal", h.getMeal)
+		r.Get("/menus/upcoming", h.getUpcomingMeals)
+		r.Post("/menus/generate", h.generateMenu)
+
+		r.Route("/menus/entries", func(r chi.Router) {
+			r.Get("/", h.listMenuEntries)
+			r.Post("/", h.createMenuEntry)
+			r.Get("/{mealID}", h.getMenuEntry)
+			r.Put("/{mealID}", h.updateMenuEntry)
+			r.Delete("/{mealID}", h.deleteMenuEntry)
+		})
+
+		r.Route("/dishes", func(r chi.Router) {
+			r.Post("/", h.createDish)
+			r.Get("/{dishID}", h.getDish)
+			r.Put("/{dishID}", h.updateDish)
+			r.Delete("/{dishID}", h.deleteDish)
+		})
 	})
 }
 
@@ -35,26 +74,309 @@ func (h *Handler) getMeal(w http.ResponseWriter, r *http.Request) {
 	// Извлекаем userID из query-параметров
 	userID := string(r.URL.Query().Get("user_id"))
 	if userID == "" {
-		http.Error(w, "missing user_id parameter", http.StatusBadRequest)
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
 		return
 	}
 
-	meal, products, err := h.service.GetMeal(r.Context(), userID)
+	details, err := h.service.GetMeal(r.Context(), userID)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		oops.WriteProblem(w, r, err)
 		return
 	}
 
+	// partial и warnings сообщают, что список покупок отсутствует или взят из кэша
 	response := struct {
-		Meal         Meal   `json:"meal"`
-		ShoppingList string `json:"shopping_list"`
+		Meal         Meal          `json:"meal"`
+		ShoppingList *ShoppingList `json:"shopping_list"`
+		Partial      bool          `json:"partial"`
+		Warnings     []string      `json:"warnings,omitempty"`
 	}{
-		Meal:         *meal,
-		ShoppingList: products,
+		Meal:         *details.Meal,
+		ShoppingList: details.ShoppingList,
+		Partial:      details.Partial,
+		Warnings:     details.Warnings,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
-	log.Println(response)
+	// ответ целиком не логируется: в нем рецепты и список покупок пользователя
+	h.logger.DebugContext(r.Context(), "отправлен ближайший прием пищи",
+		"meal_id", details.Meal.MealID, "partial", details.Partial, "warnings", len(details.Warnings))
+}
+
+// getUpcomingMeals возвращает ближайшие приемы пищи пользователя
+func (h *Handler) getUpcomingMeals(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	var limit int
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			oops.WriteProblem(w, r, oops.NewValidationError("limit", oops.ErrInvalidValue))
+			return
+		}
+		limit = parsed
+	}
+
+	meals, err := h.service.GetUpcomingMeals(r.Context(), userID, limit)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, meals)
+}
+
+// getMenuView возвращает приемы пищи пользователя за период, сгруппированные по дням
+func (h *Handler) getMenuView(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	from, err := parseDateParam(r, "from")
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+	to, err := parseDateParam(r, "to")
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	view, err := h.service.GetMenuView(r.Context(), userID, from, to)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, view)
+}
+
+// generateMenu составляет меню на период под дневную норму пользователя
+func (h *Handler) generateMenu(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	var body struct {
+		From    string           `json:"from"`
+		To      string           `json:"to"`
+		Targets NutritionTargets `json:"targets"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+
+	from, err := parseDate("from", body.From)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+	to, err := parseDate("to", body.To)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	view, err := h.service.GenerateMenu(r.Context(), userID, GenerateRequest{From: from, To: to, Targets: body.Targets})
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, view)
+}
+
+// listMenuEntries возвращает все запланированные приемы пищи пользователя
+func (h *Handler) listMenuEntries(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	entries, err := h.service.GetMenu(r.Context(), userID)
+	if err != nil && !errors.Is(err, oops.ErrNoData) {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+	if entries == nil {
+		entries = []Menu{}
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// createMenuEntry добавляет прием пищи в расписание пользователя
+func (h *Handler) createMenuEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	var entry Menu
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+
+	created, err := h.service.CreateMenuEntry(r.Context(), userID, entry)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// getMenuEntry возвращает запланированный прием пищи пользователя
+func (h *Handler) getMenuEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	entry, err := h.service.GetMenuEntry(r.Context(), userID, chi.URLParam(r, "mealID"))
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// updateMenuEntry изменяет тип и время запланированного приема пищи
+func (h *Handler) updateMenuEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	var entry Menu
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+	// идентификатор приема пищи берется из пути, а не из тела запроса
+	entry.MealID = chi.URLParam(r, "mealID")
+
+	updated, err := h.service.UpdateMenuEntry(r.Context(), userID, entry)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// deleteMenuEntry удаляет прием пищи из расписания пользователя
+func (h *Handler) deleteMenuEntry(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		oops.WriteProblem(w, r, oops.NewValidationError("user_id", oops.ErrEmptyValue))
+		return
+	}
+
+	if err := h.service.DeleteMenuEntry(r.Context(), userID, chi.URLParam(r, "mealID")); err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// createDish добавляет новое блюдо
+func (h *Handler) createDish(w http.ResponseWriter, r *http.Request) {
+	var dish Dish
+	if err := json.NewDecoder(r.Body).Decode(&dish); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+
+	created, err := h.service.CreateDish(r.Context(), dish)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// getDish возвращает блюдо вместе с рецептом
+func (h *Handler) getDish(w http.ResponseWriter, r *http.Request) {
+	dish, err := h.service.GetDish(r.Context(), chi.URLParam(r, "dishID"))
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dish)
+}
+
+// updateDish изменяет название, рецепт и пищевую ценность блюда
+func (h *Handler) updateDish(w http.ResponseWriter, r *http.Request) {
+	var dish Dish
+	if err := json.NewDecoder(r.Body).Decode(&dish); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+	// идентификатор блюда берется из пути, а не из тела запроса
+	dish.DishID = chi.URLParam(r, "dishID")
+
+	updated, err := h.service.UpdateDish(r.Context(), dish)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// deleteDish удаляет блюдо
+func (h *Handler) deleteDish(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteDish(r.Context(), chi.URLParam(r, "dishID")); err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseDateParam разбирает необязательный query-параметр с датой в формате YYYY-MM-DD
+func parseDateParam(r *http.Request, name string) (time.Time, error) {
+	return parseDate(name, r.URL.Query().Get(name))
+}
+
+// parseDate разбирает необязательную дату в формате YYYY-MM-DD, name используется в ошибке валидации
+func parseDate(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, oops.NewValidationError(name, oops.ErrInvalidValue)
+	}
+	return date, nil
+}
+
+// writeJSON сериализует ответ в JSON с указанным статусом
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
 }
@@ -1,14 +1,20 @@
 package menu_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"menu_manager/internal/logging"
 	"menu_manager/internal/menu"
 	mocks "menu_manager/internal/menu/mock"
 	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang/mock/gomock"
@@ -24,22 +30,27 @@ func TestGetMeal_Success(t *testing.T) {
 
 	// Данные для теста
 	expectedMeal := menu.Meal{
-		MealID:         "meal1",
-		DishIDs:        []string{"eggs", "bread"},
-		DishNames:      []string{"eggs", "bread"},
-		Type:           "Breakfast",
-		Recipes:        []string{"eggs", "bread"},
+		MealID:    "meal1",
+		DishIDs:   []string{"eggs", "bread"},
+		DishNames: []string{"eggs", "bread"},
+		Type:      "Breakfast",
+		Recipes: []menu.Recipe{
+			{Ingredients: []menu.Ingredient{{ProductID: "eggs", Amount: 2, Unit: "шт"}}},
+			{Ingredients: []menu.Ingredient{{ProductID: "bread", Amount: 50, Unit: "г"}}},
+		},
 		TotalNutrition: common.NutritionalValueAbsolute{Proteins: 10, Fats: 10, Carbohydrates: 30, Calories: 300},
 	}
-	expectedProducts := "eggs, bread"
-	mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(&expectedMeal, expectedProducts, nil)
+	expectedProducts := &menu.ShoppingList{Items: []menu.ShoppingItem{
+		{Product: common.Product{ID: "bread", Name: "Хлеб", WeightPerPkg: 400}, Required: 50, ToBuy: 50, Unit: "г", Packages: 1},
+	}}
+	mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(&menu.MealDetails{Meal: &expectedMeal, ShoppingList: expectedProducts}, nil)
 
 	// Создаем HTTP-реквест и респонс
 	router := chi.NewRouter()
 	handler := menu.NewHandler(router, mockService)
 	handler.Register()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=123", nil)
 	rec := httptest.NewRecorder()
 
 	// Выполняем запрос
@@ -49,13 +60,79 @@ func TestGetMeal_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, rec.Code)
 
 	var response struct {
-		Meal         menu.Meal `json:"meal"`
-		ShoppingList string    `json:"shopping_list"`
+		Meal         menu.Meal         `json:"meal"`
+		ShoppingList menu.ShoppingList `json:"shopping_list"`
 	}
 	err := json.Unmarshal(rec.Body.Bytes(), &response)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedMeal, response.Meal)
-	assert.Equal(t, expectedProducts, response.ShoppingList)
+	assert.Equal(t, *expectedProducts, response.ShoppingList)
+}
+
+func TestGetMeal_LogsWithoutRecipes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	meal := menu.Meal{
+		MealID:  "meal1",
+		Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "truffle", Amount: 1, Unit: "шт"}}, Steps: []string{"Натереть"}}},
+	}
+	mockService.EXPECT().GetMeal(gomock.Any(), "kolya").Return(&menu.MealDetails{Meal: &meal}, nil)
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "debug", "text", "secret")
+	assert.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Use(logging.Middleware(logger))
+	menu.NewHandler(router, mockService, menu.WithHandlerLogger(logger)).Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=kolya", nil)
+	req.Header.Set(logging.RequestIDHeader, "req-7")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// в журнале прием пищи и идентификатор запроса, но не рецепт и не пользователь
+	assert.Contains(t, buf.String(), "meal_id=meal1")
+	assert.Contains(t, buf.String(), "request_id=req-7")
+	assert.NotContains(t, buf.String(), "truffle")
+	assert.NotContains(t, buf.String(), "Натереть")
+	assert.NotContains(t, buf.String(), "kolya")
+}
+
+func TestGetMeal_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(&menu.MealDetails{
+		Meal:     &menu.Meal{MealID: "meal1"},
+		Partial:  true,
+		Warnings: []string{"не удалось получить список покупок: сервис barn manager недоступен"},
+	}, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=123", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var response struct {
+		Meal         menu.Meal          `json:"meal"`
+		ShoppingList *menu.ShoppingList `json:"shopping_list"`
+		Partial      bool               `json:"partial"`
+		Warnings     []string           `json:"warnings"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, "meal1", response.Meal.MealID)
+	assert.Nil(t, response.ShoppingList)
+	assert.True(t, response.Partial)
+	assert.Equal(t, []string{"не удалось получить список покупок: сервис barn manager недоступен"}, response.Warnings)
 }
 
 func TestGetMeal_Error(t *testing.T) {
@@ -66,14 +143,14 @@ func TestGetMeal_Error(t *testing.T) {
 	mockService := mocks.NewMockService(ctrl)
 
 	// Настройка мока для ошибки
-	mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(nil, "", errors.New("service error"))
+	mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(nil, errors.New("service error"))
 
 	// Создаем HTTP-реквест и респонс
 	router := chi.NewRouter()
 	handler := menu.NewHandler(router, mockService)
 	handler.Register()
 
-	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal", nil)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=123", nil)
 	rec := httptest.NewRecorder()
 
 	// Выполняем запрос
@@ -81,5 +158,347 @@ func TestGetMeal_Error(t *testing.T) {
 
 	// Проверяем результат
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	assert.Contains(t, rec.Body.String(), "service error")
+	assert.Equal(t, oops.ProblemContentType, rec.Header().Get("Content-Type"))
+	// текст внутренней ошибки клиенту не отдается
+	assert.Contains(t, rec.Body.String(), oops.CodeInternal)
+	assert.NotContains(t, rec.Body.String(), "service error")
+}
+
+func TestGetMeal_ErrorStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"no data", oops.NewDBError(oops.ErrNoData, "LoadMenu", "123"), http.StatusNotFound, oops.CodeNotFound},
+		{"no upcoming meal", oops.ErrNoUpcomingMeal, http.StatusNotFound, oops.CodeNoUpcomingMeal},
+		{"barn down", fmt.Errorf("%w: connection refused", oops.ErrBarnUnavailable), http.StatusBadGateway, oops.CodeBarnUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockService := mocks.NewMockService(ctrl)
+			mockService.EXPECT().GetMeal(gomock.Any(), "123").Return(nil, tt.err)
+
+			router := chi.NewRouter()
+			handler := menu.NewHandler(router, mockService)
+			handler.Register()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal?user_id=123", nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+
+			var problem oops.Problem
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.code, problem.Code)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, "/api/v1/menus/getMeal", problem.Instance)
+		})
+	}
+}
+
+func TestGetMeal_MissingUserID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mocks.NewMockService(ctrl))
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/getMeal", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var problem oops.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, oops.CodeValidationFailed, problem.Code)
+	assert.Equal(t, "user_id", problem.Field)
+}
+
+func TestCreateMenuEntry_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	eatDate := time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC)
+	entry := menu.Menu{MealID: "meal1", Time: eatDate, MealType: "breakfast"}
+	mockService.EXPECT().CreateMenuEntry(gomock.Any(), "123", entry).Return(&entry, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	body := `{"meal_id":"meal1","meal_type":"breakfast","eat_date":"2024-03-20T08:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/menus/entries?user_id=123", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var created menu.Menu
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, entry, created)
+}
+
+func TestCreateMenuEntry_ValidationError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().CreateMenuEntry(gomock.Any(), "123", gomock.Any()).
+		Return(nil, oops.NewValidationError("meal_type", oops.ErrInvalidValue))
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	body := `{"meal_id":"meal1","meal_type":"brunch","eat_date":"2024-03-20T08:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/menus/entries?user_id=123", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "meal_type")
+}
+
+func TestListMenuEntries_Empty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().GetMenu(gomock.Any(), "123").Return(nil, oops.ErrNoData)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/entries?user_id=123", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func TestUpdateMenuEntry_UsesPathID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	eatDate := time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC)
+	entry := menu.Menu{MealID: "meal1", Time: eatDate, MealType: "dinner"}
+	mockService.EXPECT().UpdateMenuEntry(gomock.Any(), "123", entry).Return(&entry, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	body := `{"meal_id":"other","meal_type":"dinner","eat_date":"2024-03-20T19:00:00Z"}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/menus/entries/meal1?user_id=123", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestDeleteMenuEntry_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().DeleteMenuEntry(gomock.Any(), "123", "meal1").
+		Return(oops.NewDBError(oops.ErrNoData, "DeleteMenuEntry", "meal1"))
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/menus/entries/meal1?user_id=123", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCreateDish_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	dish := menu.Dish{
+		DishID: "dish1",
+		MealID: "meal1",
+		Name:   "Омлет",
+		Recipe: menu.Recipe{
+			Ingredients: []menu.Ingredient{{ProductID: "яйцо", Amount: 2, Unit: "шт"}},
+			Steps:       []string{"Взбить яйца", "Жарить 5 минут"},
+		},
+		TotalNutrition: common.NutritionalValueAbsolute{Proteins: 12, Fats: 10, Calories: 150},
+	}
+	mockService.EXPECT().CreateDish(gomock.Any(), dish).Return(&dish, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	body, _ := json.Marshal(dish)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dishes", strings.NewReader(string(body)))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.JSONEq(t, string(body), rec.Body.String())
+}
+
+func TestGetDish_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().GetDish(gomock.Any(), "dish1").
+		Return(nil, oops.NewDBError(oops.ErrNoData, "LoadDish", "dish1"))
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/dishes/dish1", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestGetMenuView_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)
+	view := &menu.MenuView{From: "2024-03-18", To: "2024-03-24", Days: []menu.DayMenu{}}
+	mockService.EXPECT().GetMenuView(gomock.Any(), "123", from, to).Return(view, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus?user_id=123&from=2024-03-18&to=2024-03-24", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"from":"2024-03-18","to":"2024-03-24","days":[]}`, rec.Body.String())
+}
+
+func TestGetMenuView_InvalidDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mocks.NewMockService(ctrl))
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus?user_id=123&from=18.03.2024", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"field":"from"`)
+}
+
+func TestGenerateMenu_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	req := menu.GenerateRequest{
+		From:    time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC),
+		Targets: menu.NutritionTargets{Calories: 2000, Proteins: 100, Fats: 70, Carbohydrates: 250},
+	}
+	view := &menu.MenuView{From: "2024-03-18", To: "2024-03-24", Days: []menu.DayMenu{}}
+	mockService.EXPECT().GenerateMenu(gomock.Any(), "123", req).Return(view, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	body := `{"from":"2024-03-18","to":"2024-03-24","targets":{"calories":2000,"proteins":100,"fats":70,"carbohydrates":250}}`
+	httpReq := httptest.NewRequest(http.MethodPost, "/api/v1/menus/generate?user_id=123", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, httpReq)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestGetUpcomingMeals_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	meals := []menu.ScheduledMeal{
+		{MealID: "meal1", MealType: menu.MealTypeLunch, Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), DishNames: []string{"Борщ"}},
+	}
+	mockService.EXPECT().GetUpcomingMeals(gomock.Any(), "123", 3).Return(meals, nil)
+
+	router := chi.NewRouter()
+	handler := menu.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/upcoming?user_id=123&limit=3", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var got []menu.ScheduledMeal
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, meals, got)
+}
+
+func TestGetUpcomingMeals_InvalidLimit(t *testing.T) {
+	for _, limit := range []string{"abc", "0", "-2"} {
+		t.Run(limit, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			router := chi.NewRouter()
+			handler := menu.NewHandler(router, mocks.NewMockService(ctrl))
+			handler.Register()
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/menus/upcoming?user_id=123&limit="+limit, nil)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Contains(t, rec.Body.String(), `"field":"limit"`)
+		})
+	}
 }
@@ -0,0 +1,418 @@
+// Package memory хранит меню и блюда в памяти процесса.
+// Используется для локальной разработки и тестов без MySQL, данные не переживают перезапуск
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+)
+
+// menuRow запись расписания вместе с владельцем, как строка таблицы menu
+type menuRow struct {
+	userID string
+	entry  menu.Menu
+}
+
+// lock блокировка фоновой задачи, как строка таблицы scheduler_locks
+type lock struct {
+	owner     string
+	expiresAt time.Time
+}
+
+// Storage реализует menu.Store в памяти, безопасен для конкурентного использования
+type Storage struct {
+	mu     sync.RWMutex
+	menus  map[string]menuRow   // ключ — meal_id
+	dishes map[string]menu.Dish // ключ — dish_id
+	locks  map[string]lock      // ключ — имя блокировки
+	now    func() time.Time
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		menus:  make(map[string]menuRow),
+		dishes: make(map[string]menu.Dish),
+		locks:  make(map[string]lock),
+		now:    time.Now,
+	}
+}
+
+// Fixture описывает начальные данные хранилища в JSON
+type Fixture struct {
+	Menus  []FixtureMenu `json:"menus"`
+	Dishes []menu.Dish   `json:"dishes"`
+}
+
+// FixtureMenu запись расписания пользователя в фикстуре
+type FixtureMenu struct {
+	UserID string `json:"user_id"`
+	menu.Menu
+}
+
+// LoadFixtureFile добавляет в хранилище данные из JSON-файла фикстуры.
+// Ключи, относящиеся к другим хранилищам (например, profiles), игнорируются
+func (s *Storage) LoadFixtureFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать фикстуру: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fmt.Errorf("не удалось разобрать фикстуру %s: %w", path, err)
+	}
+	return s.Seed(fixture)
+}
+
+// Seed добавляет в хранилище данные фикстуры
+func (s *Storage) Seed(fixture Fixture) error {
+	ctx := context.Background()
+	for _, m := range fixture.Menus {
+		if err := s.SaveMenuEntry(ctx, m.UserID, m.Menu); err != nil {
+			return err
+		}
+	}
+	for _, dish := range fixture.Dishes {
+		if err := s.SaveDish(ctx, dish); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadMenu возвращает меню пользователя, упорядоченное по времени
+func (s *Storage) LoadMenu(ctx context.Context, userID string) ([]menu.Menu, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	menuList := s.userMenu(userID, func(menu.Menu) bool { return true })
+	if len(menuList) == 0 {
+		return nil, oops.ErrNoData
+	}
+	return menuList, nil
+}
+
+// LoadMenuRange возвращает приемы пищи пользователя в полуинтервале [from, to), упорядоченные по времени
+func (s *Storage) LoadMenuRange(ctx context.Context, userID string, from, to time.Time) ([]menu.Menu, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.userMenu(userID, func(m menu.Menu) bool {
+		return !m.Time.Before(from) && m.Time.Before(to)
+	}), nil
+}
+
+// LoadMeal возвращает прием пищи с описанием составляющих его блюд.
+// Как и в MySQL, прием пищи без блюд возвращается пустым, а не ошибкой
+func (s *Storage) LoadMeal(ctx context.Context, mealID string) (*menu.Meal, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	meal := menu.Meal{
+		MealID:         mealID,
+		DishIDs:        make([]string, 0),
+		DishNames:      make([]string, 0),
+		Recipes:        make([]menu.Recipe, 0),
+		TotalNutrition: common.NutritionalValueAbsolute{},
+	}
+	for _, dish := range s.sortedDishes() {
+		if dish.MealID != mealID {
+			continue
+		}
+		meal.DishIDs = append(meal.DishIDs, dish.DishID)
+		meal.DishNames = append(meal.DishNames, dish.Name)
+		meal.Recipes = append(meal.Recipes, dish.Recipe)
+		meal.TotalNutrition = meal.TotalNutrition.AddAbsoluteValue(dish.TotalNutrition)
+	}
+	return &meal, nil
+}
+
+// LoadMeals возвращает приемы пищи по списку id, ключ — meal_id
+func (s *Storage) LoadMeals(ctx context.Context, mealIDs []string) (map[string]*menu.Meal, error) {
+	meals := make(map[string]*menu.Meal, len(mealIDs))
+	for _, mealID := range mealIDs {
+		meal, err := s.LoadMeal(ctx, mealID)
+		if err != nil {
+			return nil, err
+		}
+		meals[mealID] = meal
+	}
+	return meals, nil
+}
+
+// UpdateMenu обновляет время приемов пищи пользователя, неизвестные записи пропускаются
+func (s *Storage) UpdateMenu(ctx context.Context, userID string, menuList []menu.Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, m := range menuList {
+		row, ok := s.menus[m.MealID]
+		if !ok || row.userID != userID {
+			continue
+		}
+		row.entry.Time = m.Time.UTC()
+		s.menus[m.MealID] = row
+	}
+	return nil
+}
+
+// SaveMenuEntry сохраняет новый прием пищи в расписании пользователя
+func (s *Storage) SaveMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.menus[entry.MealID]; ok {
+		return oops.NewDBError(oops.ErrDuplicateKey, "SaveMenuEntry", entry.MealID)
+	}
+	entry.Time = entry.Time.UTC()
+	s.menus[entry.MealID] = menuRow{userID: userID, entry: entry}
+	return nil
+}
+
+// LoadMenuEntry возвращает запланированный прием пищи пользователя
+func (s *Storage) LoadMenuEntry(ctx context.Context, userID string, mealID string) (*menu.Menu, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row, ok := s.menus[mealID]
+	if !ok || row.userID != userID {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadMenuEntry", mealID)
+	}
+	entry := row.entry
+	return &entry, nil
+}
+
+// UpdateMenuEntry обновляет тип и время запланированного приема пищи
+func (s *Storage) UpdateMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.menus[entry.MealID]
+	if !ok || row.userID != userID {
+		return nil
+	}
+	row.entry.MealType = entry.MealType
+	row.entry.Time = entry.Time.UTC()
+	s.menus[entry.MealID] = row
+	return nil
+}
+
+// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+func (s *Storage) DeleteMenuEntry(ctx context.Context, userID string, mealID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row, ok := s.menus[mealID]
+	if !ok || row.userID != userID {
+		return oops.NewDBError(oops.ErrNoData, "DeleteMenuEntry", mealID)
+	}
+	delete(s.menus, mealID)
+	return nil
+}
+
+// SaveDish сохраняет новое блюдо
+func (s *Storage) SaveDish(ctx context.Context, dish menu.Dish) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.dishes[dish.DishID]; ok {
+		return oops.NewDBError(oops.ErrDuplicateKey, "SaveDish", dish.DishID)
+	}
+	// SourceDishID сохраняет только ReplaceMenuRange, как и в SQL-хранилищах
+	dish.SourceDishID = ""
+	s.dishes[dish.DishID] = cloneDish(dish)
+	return nil
+}
+
+// LoadDish возвращает блюдо по ID
+func (s *Storage) LoadDish(ctx context.Context, dishID string) (*menu.Dish, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dish, ok := s.dishes[dishID]
+	if !ok {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadDish", dishID)
+	}
+	dish = cloneDish(dish)
+	return &dish, nil
+}
+
+// UpdateDish обновляет блюдо, неизвестное блюдо пропускается
+func (s *Storage) UpdateDish(ctx context.Context, dish menu.Dish) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if old, ok := s.dishes[dish.DishID]; ok {
+		dish.SourceDishID = old.SourceDishID
+		s.dishes[dish.DishID] = cloneDish(dish)
+	}
+	return nil
+}
+
+// DeleteDish удаляет блюдо
+func (s *Storage) DeleteDish(ctx context.Context, dishID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.dishes[dishID]; !ok {
+		return oops.NewDBError(oops.ErrNoData, "DeleteDish", dishID)
+	}
+	delete(s.dishes, dishID)
+	return nil
+}
+
+// LoadDishes возвращает каталог всех блюд, упорядоченный по названию,
+// с типом приема пищи, к которому они относятся
+func (s *Storage) LoadDishes(ctx context.Context) ([]menu.Dish, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	dishes := s.sortedDishes()
+	for i := range dishes {
+		dishes[i].MealType = s.menus[dishes[i].MealID].entry.MealType
+	}
+	return dishes, nil
+}
+
+// ReplaceMenuRange атомарно заменяет расписание пользователя в полуинтервале [from, to)
+// новыми приемами пищи и их блюдами
+func (s *Storage) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []menu.Menu, dishes []menu.Dish) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// проверяем ключи до изменений, чтобы при ошибке хранилище осталось прежним, как после отката транзакции
+	replaced := make(map[string]bool)
+	for mealID, row := range s.menus {
+		if row.userID == userID && !row.entry.Time.Before(from) && row.entry.Time.Before(to) {
+			replaced[mealID] = true
+		}
+	}
+	newMeals := make(map[string]bool, len(entries))
+	for _, m := range entries {
+		if _, ok := s.menus[m.MealID]; (ok && !replaced[m.MealID]) || newMeals[m.MealID] {
+			return oops.NewDBError(oops.ErrDuplicateKey, "ReplaceMenuRange.InsertMenu", m.MealID)
+		}
+		newMeals[m.MealID] = true
+	}
+	newDishes := make(map[string]bool, len(dishes))
+	for _, dish := range dishes {
+		if _, ok := s.dishes[dish.DishID]; ok || newDishes[dish.DishID] {
+			return oops.NewDBError(oops.ErrDuplicateKey, "ReplaceMenuRange.InsertDish", dish.DishID)
+		}
+		newDishes[dish.DishID] = true
+	}
+
+	// копии блюд, созданные генератором для заменяемых приемов пищи, удаляются вместе с расписанием
+	for dishID, dish := range s.dishes {
+		if dish.SourceDishID != "" && replaced[dish.MealID] {
+			delete(s.dishes, dishID)
+		}
+	}
+	for mealID := range replaced {
+		delete(s.menus, mealID)
+	}
+	for _, m := range entries {
+		m.Time = m.Time.UTC()
+		s.menus[m.MealID] = menuRow{userID: userID, entry: m}
+	}
+	for _, dish := range dishes {
+		s.dishes[dish.DishID] = cloneDish(dish)
+	}
+	return nil
+}
+
+// LoadStaleUsers возвращает пользователей, последний прием пищи которых запланирован раньше before
+func (s *Storage) LoadStaleUsers(ctx context.Context, before time.Time) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	latest := make(map[string]time.Time)
+	for _, row := range s.menus {
+		if row.entry.Time.After(latest[row.userID]) {
+			latest[row.userID] = row.entry.Time
+		}
+	}
+
+	users := make([]string, 0)
+	for userID, t := range latest {
+		if t.Before(before) {
+			users = append(users, userID)
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+// AcquireLock захватывает блокировку name для owner на ttl. Истекшая блокировка перехватывается
+func (s *Storage) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if l, ok := s.locks[name]; ok && l.owner != owner && !l.expiresAt.Before(now) {
+		return false, nil
+	}
+	s.locks[name] = lock{owner: owner, expiresAt: now.Add(ttl)}
+	return true, nil
+}
+
+// ReleaseLock освобождает блокировку, если ее держит owner
+func (s *Storage) ReleaseLock(ctx context.Context, name, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.locks[name]; ok && l.owner == owner {
+		delete(s.locks, name)
+	}
+	return nil
+}
+
+// userMenu возвращает записи расписания пользователя, прошедшие фильтр, упорядоченные по времени
+func (s *Storage) userMenu(userID string, keep func(menu.Menu) bool) []menu.Menu {
+	menuList := make([]menu.Menu, 0)
+	for _, row := range s.menus {
+		if row.userID == userID && keep(row.entry) {
+			menuList = append(menuList, row.entry)
+		}
+	}
+	sort.Slice(menuList, func(i, j int) bool {
+		if !menuList[i].Time.Equal(menuList[j].Time) {
+			return menuList[i].Time.Before(menuList[j].Time)
+		}
+		return menuList[i].MealID < menuList[j].MealID
+	})
+	return menuList
+}
+
+// sortedDishes возвращает копии всех блюд, упорядоченные по названию и dish_id, как LoadDishes в MySQL
+func (s *Storage) sortedDishes() []menu.Dish {
+	dishes := make([]menu.Dish, 0, len(s.dishes))
+	for _, dish := range s.dishes {
+		dishes = append(dishes, cloneDish(dish))
+	}
+	sort.Slice(dishes, func(i, j int) bool {
+		if dishes[i].Name != dishes[j].Name {
+			return dishes[i].Name < dishes[j].Name
+		}
+		return dishes[i].DishID < dishes[j].DishID
+	})
+	return dishes
+}
+
+// cloneDish копирует срезы рецепта, чтобы вызывающий код не мог изменить данные хранилища.
+// Тип приема пищи, как и в SQL-хранилищах, не хранится в блюде
+func cloneDish(dish menu.Dish) menu.Dish {
+	dish.MealType = ""
+	dish.Recipe.Ingredients = append([]menu.Ingredient(nil), dish.Recipe.Ingredients...)
+	dish.Recipe.Steps = append([]string(nil), dish.Recipe.Steps...)
+	return dish
+}
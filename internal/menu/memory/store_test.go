@@ -0,0 +1,317 @@
+package memory_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/memory"
+	"menu_manager/internal/menu/storetest"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(hour int) time.Time {
+	return time.Date(2024, 3, 20, hour, 0, 0, 0, time.UTC)
+}
+
+func seeded(t *testing.T) *memory.Storage {
+	t.Helper()
+	store := memory.NewStorage()
+	assert.NoError(t, store.Seed(memory.Fixture{
+		Menus: []memory.FixtureMenu{
+			{UserID: "kolya", Menu: menu.Menu{MealID: "2", Time: at(13), MealType: "lunch"}},
+			{UserID: "kolya", Menu: menu.Menu{MealID: "1", Time: at(8), MealType: "breakfast"}},
+			{UserID: "dan", Menu: menu.Menu{MealID: "3", Time: at(19), MealType: "dinner"}},
+		},
+		Dishes: []menu.Dish{
+			{DishID: "2", MealID: "2", Name: "Куриный суп", TotalNutrition: common.NutritionalValueAbsolute{Calories: 450, Proteins: 35}},
+			{DishID: "4", MealID: "2", Name: "Рататуй", TotalNutrition: common.NutritionalValueAbsolute{Calories: 40, Proteins: 335}},
+		},
+	}))
+	return store
+}
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) menu.Store {
+		return memory.NewStorage()
+	})
+}
+
+func TestLoadFixtureFile(t *testing.T) {
+	store := memory.NewStorage()
+	assert.NoError(t, store.LoadFixtureFile("../../../configs/fixture.json"))
+
+	menus, err := store.LoadMenu(context.Background(), "kolya")
+	assert.NoError(t, err)
+	assert.Len(t, menus, 2)
+
+	dishes, err := store.LoadDishes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, dishes, 4)
+}
+
+func TestLoadFixtureFile_Missing(t *testing.T) {
+	store := memory.NewStorage()
+	assert.Error(t, store.LoadFixtureFile("missing.json"))
+}
+
+func TestLoadMenu(t *testing.T) {
+	store := seeded(t)
+
+	menus, err := store.LoadMenu(context.Background(), "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{
+		{MealID: "1", Time: at(8), MealType: "breakfast"},
+		{MealID: "2", Time: at(13), MealType: "lunch"},
+	}, menus)
+
+	_, err = store.LoadMenu(context.Background(), "nobody")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestLoadMenuRange(t *testing.T) {
+	store := seeded(t)
+
+	menus, err := store.LoadMenuRange(context.Background(), "kolya", at(8), at(13))
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{{MealID: "1", Time: at(8), MealType: "breakfast"}}, menus)
+
+	menus, err = store.LoadMenuRange(context.Background(), "kolya", at(20), at(23))
+	assert.NoError(t, err)
+	assert.Empty(t, menus)
+}
+
+func TestLoadMeal(t *testing.T) {
+	store := seeded(t)
+
+	meal, err := store.LoadMeal(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2", "4"}, meal.DishIDs)
+	assert.Equal(t, []string{"Куриный суп", "Рататуй"}, meal.DishNames)
+	assert.Equal(t, common.NutritionalValueAbsolute{Calories: 490, Proteins: 370}, meal.TotalNutrition)
+
+	meal, err = store.LoadMeal(context.Background(), "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, meal.DishIDs)
+}
+
+func TestMenuEntryLifecycle(t *testing.T) {
+	store := seeded(t)
+	ctx := context.Background()
+
+	entry := menu.Menu{MealID: "5", Time: at(21), MealType: "snack"}
+	assert.NoError(t, store.SaveMenuEntry(ctx, "kolya", entry))
+
+	err := store.SaveMenuEntry(ctx, "kolya", entry)
+	assert.ErrorIs(t, err, oops.ErrDuplicateKey)
+
+	entry.MealType = "dinner"
+	assert.NoError(t, store.UpdateMenuEntry(ctx, "kolya", entry))
+	loaded, err := store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, "dinner", loaded.MealType)
+
+	// чужой прием пищи не виден и не удаляется
+	_, err = store.LoadMenuEntry(ctx, "dan", "5")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	assert.ErrorIs(t, store.DeleteMenuEntry(ctx, "dan", "5"), oops.ErrNoData)
+
+	assert.NoError(t, store.DeleteMenuEntry(ctx, "kolya", "5"))
+	_, err = store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestUpdateMenu(t *testing.T) {
+	store := seeded(t)
+	ctx := context.Background()
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	err := store.UpdateMenu(ctx, "kolya", []menu.Menu{
+		{MealID: "1", Time: time.Date(2024, 3, 27, 11, 0, 0, 0, moscow)},
+		{MealID: "3", Time: at(1)}, // прием пищи другого пользователя
+	})
+	assert.NoError(t, err)
+
+	entry, err := store.LoadMenuEntry(ctx, "kolya", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 27, 8, 0, 0, 0, time.UTC), entry.Time)
+
+	entry, err = store.LoadMenuEntry(ctx, "dan", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, at(19), entry.Time)
+}
+
+func TestDishLifecycle(t *testing.T) {
+	store := seeded(t)
+	ctx := context.Background()
+
+	dish := menu.Dish{
+		DishID: "10",
+		MealID: "1",
+		Name:   "Омлет",
+		Recipe: menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: "яйцо", Amount: 2, Unit: "шт"}}},
+	}
+	assert.NoError(t, store.SaveDish(ctx, dish))
+	assert.ErrorIs(t, store.SaveDish(ctx, dish), oops.ErrDuplicateKey)
+
+	// изменение возвращенного блюда не меняет хранилище
+	loaded, err := store.LoadDish(ctx, "10")
+	assert.NoError(t, err)
+	loaded.Recipe.Ingredients[0].Amount = 100
+	loaded, err = store.LoadDish(ctx, "10")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(2), loaded.Recipe.Ingredients[0].Amount)
+
+	dish.Name = "Яичница"
+	assert.NoError(t, store.UpdateDish(ctx, dish))
+	loaded, err = store.LoadDish(ctx, "10")
+	assert.NoError(t, err)
+	assert.Equal(t, "Яичница", loaded.Name)
+
+	assert.NoError(t, store.DeleteDish(ctx, "10"))
+	_, err = store.LoadDish(ctx, "10")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	assert.ErrorIs(t, store.DeleteDish(ctx, "10"), oops.ErrNoData)
+}
+
+func TestLoadDishes_SortedByName(t *testing.T) {
+	store := seeded(t)
+
+	dishes, err := store.LoadDishes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, dishes, 2)
+	assert.Equal(t, "Куриный суп", dishes[0].Name)
+	assert.Equal(t, "Рататуй", dishes[1].Name)
+}
+
+func TestReplaceMenuRange(t *testing.T) {
+	store := seeded(t)
+	ctx := context.Background()
+
+	entries := []menu.Menu{{MealID: "g1", Time: at(9), MealType: "breakfast"}}
+	dishes := []menu.Dish{{DishID: "g1-1", MealID: "g1", Name: "Сырники"}}
+	err := store.ReplaceMenuRange(ctx, "kolya", at(0), at(12), entries, dishes)
+	assert.NoError(t, err)
+
+	menus, err := store.LoadMenu(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{
+		{MealID: "g1", Time: at(9), MealType: "breakfast"},
+		{MealID: "2", Time: at(13), MealType: "lunch"},
+	}, menus)
+
+	dish, err := store.LoadDish(ctx, "g1-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Сырники", dish.Name)
+}
+
+func TestReplaceMenuRange_DuplicateLeavesStoreUnchanged(t *testing.T) {
+	store := seeded(t)
+	ctx := context.Background()
+
+	// meal_id 3 принадлежит другому пользователю, замена должна откатиться целиком
+	entries := []menu.Menu{
+		{MealID: "g1", Time: at(9), MealType: "breakfast"},
+		{MealID: "3", Time: at(10), MealType: "snack"},
+	}
+	err := store.ReplaceMenuRange(ctx, "kolya", at(0), at(23), entries, nil)
+	assert.ErrorIs(t, err, oops.ErrDuplicateKey)
+
+	menus, err := store.LoadMenu(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Len(t, menus, 2)
+	_, err = store.LoadMenuEntry(ctx, "kolya", "g1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestLoadStaleUsers(t *testing.T) {
+	store := seeded(t)
+
+	users, err := store.LoadStaleUsers(context.Background(), at(14))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kolya"}, users)
+
+	users, err = store.LoadStaleUsers(context.Background(), at(20))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dan", "kolya"}, users)
+}
+
+func TestLocks(t *testing.T) {
+	store := memory.NewStorage()
+	ctx := context.Background()
+
+	acquired, err := store.AcquireLock(ctx, "job", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = store.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// владелец может продлить свою блокировку
+	acquired, err = store.AcquireLock(ctx, "job", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// чужой владелец не освобождает блокировку
+	assert.NoError(t, store.ReleaseLock(ctx, "job", "b"))
+	acquired, err = store.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	assert.NoError(t, store.ReleaseLock(ctx, "job", "a"))
+	acquired, err = store.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestLocks_Expired(t *testing.T) {
+	store := memory.NewStorage()
+	ctx := context.Background()
+
+	acquired, err := store.AcquireLock(ctx, "job", "a", -time.Second)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = store.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	store := memory.NewStorage()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			errs <- store.SaveMenuEntry(ctx, "kolya", menu.Menu{MealID: fmt.Sprint(i), Time: at(i % 24), MealType: "snack"})
+		}(i)
+		go func() {
+			defer wg.Done()
+			_, err := store.LoadMenu(ctx, "kolya")
+			if errors.Is(err, oops.ErrNoData) {
+				err = nil
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	menus, err := store.LoadMenu(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Len(t, menus, 50)
+}
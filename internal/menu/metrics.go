@@ -0,0 +1,220 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/oops"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// metricsNamespace общий префикс метрик сервиса
+const metricsNamespace = "menu_manager"
+
+// MeteredStore измеряет длительность операций хранилища и считает их ошибки.
+// Ошибки помечаются значением DBError.Op, поэтому видно, на каком шаге операции она произошла
+type MeteredStore struct {
+	store    Store
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewMeteredStore оборачивает store и регистрирует его метрики в reg
+func NewMeteredStore(store Store, reg prometheus.Registerer) *MeteredStore {
+	factory := promauto.With(reg)
+	return &MeteredStore{
+		store: store,
+		duration: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Длительность операций хранилища меню.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"op"}),
+		errors: factory.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "store_errors_total",
+			Help:      "Ошибки операций хранилища меню по DBError.Op и причине.",
+		}, []string{"op", "reason"}),
+	}
+}
+
+func (s *MeteredStore) LoadMenu(ctx context.Context, userID string) (menu []Menu, err error) {
+	defer s.observe("LoadMenu", time.Now(), &err)
+	return s.store.LoadMenu(ctx, userID)
+}
+
+func (s *MeteredStore) LoadMenuRange(ctx context.Context, userID string, from, to time.Time) (menu []Menu, err error) {
+	defer s.observe("LoadMenuRange", time.Now(), &err)
+	return s.store.LoadMenuRange(ctx, userID, from, to)
+}
+
+func (s *MeteredStore) LoadMeal(ctx context.Context, mealID string) (meal *Meal, err error) {
+	defer s.observe("LoadMeal", time.Now(), &err)
+	return s.store.LoadMeal(ctx, mealID)
+}
+
+func (s *MeteredStore) LoadMeals(ctx context.Context, mealIDs []string) (meals map[string]*Meal, err error) {
+	defer s.observe("LoadMeals", time.Now(), &err)
+	return s.store.LoadMeals(ctx, mealIDs)
+}
+
+func (s *MeteredStore) UpdateMenu(ctx context.Context, userID string, menuList []Menu) (err error) {
+	defer s.observe("UpdateMenu", time.Now(), &err)
+	return s.store.UpdateMenu(ctx, userID, menuList)
+}
+
+func (s *MeteredStore) SaveMenuEntry(ctx context.Context, userID string, entry Menu) (err error) {
+	defer s.observe("SaveMenuEntry", time.Now(), &err)
+	return s.store.SaveMenuEntry(ctx, userID, entry)
+}
+
+func (s *MeteredStore) LoadMenuEntry(ctx context.Context, userID string, mealID string) (entry *Menu, err error) {
+	defer s.observe("LoadMenuEntry", time.Now(), &err)
+	return s.store.LoadMenuEntry(ctx, userID, mealID)
+}
+
+func (s *MeteredStore) UpdateMenuEntry(ctx context.Context, userID string, entry Menu) (err error) {
+	defer s.observe("UpdateMenuEntry", time.Now(), &err)
+	return s.store.UpdateMenuEntry(ctx, userID, entry)
+}
+
+func (s *MeteredStore) DeleteMenuEntry(ctx context.Context, userID string, mealID string) (err error) {
+	defer s.observe("DeleteMenuEntry", time.Now(), &err)
+	return s.store.DeleteMenuEntry(ctx, userID, mealID)
+}
+
+func (s *MeteredStore) SaveDish(ctx context.Context, dish Dish) (err error) {
+	defer s.observe("SaveDish", time.Now(), &err)
+	return s.store.SaveDish(ctx, dish)
+}
+
+func (s *MeteredStore) LoadDish(ctx context.Context, dishID string) (dish *Dish, err error) {
+	defer s.observe("LoadDish", time.Now(), &err)
+	return s.store.LoadDish(ctx, dishID)
+}
+
+func (s *MeteredStore) UpdateDish(ctx context.Context, dish Dish) (err error) {
+	defer s.observe("UpdateDish", time.Now(), &err)
+	return s.store.UpdateDish(ctx, dish)
+}
+
+func (s *MeteredStore) DeleteDish(ctx context.Context, dishID string) (err error) {
+	defer s.observe("DeleteDish", time.Now(), &err)
+	return s.store.DeleteDish(ctx, dishID)
+}
+
+func (s *MeteredStore) LoadDishes(ctx context.Context) (dishes []Dish, err error) {
+	defer s.observe("LoadDishes", time.Now(), &err)
+	return s.store.LoadDishes(ctx)
+}
+
+func (s *MeteredStore) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []Menu, dishes []Dish) (err error) {
+	defer s.observe("ReplaceMenuRange", time.Now(), &err)
+	return s.store.ReplaceMenuRange(ctx, userID, from, to, entries, dishes)
+}
+
+func (s *MeteredStore) LoadStaleUsers(ctx context.Context, before time.Time) (users []string, err error) {
+	defer s.observe("LoadStaleUsers", time.Now(), &err)
+	return s.store.LoadStaleUsers(ctx, before)
+}
+
+// observe учитывает операцию method, начатую в start. Ошибка без DBError помечается именем метода
+func (s *MeteredStore) observe(method string, start time.Time, err *error) {
+	s.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err == nil {
+		return
+	}
+
+	op := method
+	var dbErr *oops.DBError
+	if errors.As(*err, &dbErr) {
+		op = dbErr.Op
+	}
+	s.errors.WithLabelValues(op, storeErrorReason(*err)).Inc()
+}
+
+// storeErrorReason отделяет ожидаемые ошибки (нет записи, дубликат) от сбоев базы
+func storeErrorReason(err error) string {
+	switch {
+	case errors.Is(err, oops.ErrNoData):
+		return "no_data"
+	case errors.Is(err, oops.ErrDuplicateKey):
+		return "duplicate_key"
+	case errors.Is(err, oops.ErrDBConnection):
+		return "connection"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	}
+	return "other"
+}
+
+// MeteredClient измеряет длительность запросов списка покупок к barn manager по коду ответа
+type MeteredClient struct {
+	client   Client
+	duration *prometheus.HistogramVec
+}
+
+// NewMeteredClient оборачивает client и регистрирует его метрики в reg
+func NewMeteredClient(client Client, reg prometheus.Registerer) *MeteredClient {
+	return &MeteredClient{
+		client: client,
+		duration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "barn_request_duration_seconds",
+			Help:      "Длительность запросов списка покупок к barn manager вместе с повторами, по коду ответа.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"status"}),
+	}
+}
+
+// GetProducts запрашивает список покупок и учитывает длительность и итог запроса
+func (c *MeteredClient) GetProducts(ctx context.Context, recipes []Recipe) (*ShoppingList, error) {
+	start := time.Now()
+	list, err := c.client.GetProducts(ctx, recipes)
+	c.duration.WithLabelValues(barnStatus(err)).Observe(time.Since(start).Seconds())
+	return list, err
+}
+
+// barnStatus возвращает код последнего ответа barn manager или причину, по которой ответа не было
+func barnStatus(err error) string {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		return "200"
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode)
+	case errors.Is(err, oops.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	}
+	return "error"
+}
+
+// WithRescheduleMetrics считает переносы меню по стратегии и результату и регистрирует счетчик в reg
+func WithRescheduleMetrics(reg prometheus.Registerer) Option {
+	return func(s *AppService) {
+		s.rescheduleCount = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "reschedules_total",
+			Help:      "Переносы устаревших меню по стратегии и результату.",
+		}, []string{"strategy", "result"})
+	}
+}
+
+// countReschedule учитывает перенос меню, если счетчик включен
+func (s *AppService) countReschedule(strategy string, err error) {
+	if s.rescheduleCount == nil {
+		return
+	}
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	s.rescheduleCount.WithLabelValues(strategy, result).Inc()
+}
@@ -0,0 +1,136 @@
+package menu_test
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/barnstub"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// sampleCounts возвращает число наблюдений гистограммы name по значению метки label
+func sampleCounts(t *testing.T, reg *prometheus.Registry, name, label string) map[string]uint64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+
+	counts := make(map[string]uint64)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, pair := range metric.GetLabel() {
+				if pair.GetName() == label {
+					counts[pair.GetValue()] = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+	return counts
+}
+
+func TestMeteredStore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	reg := prometheus.NewRegistry()
+	store := menu.NewMeteredStore(mockStore, reg)
+	ctx := context.Background()
+
+	mockStore.EXPECT().LoadMeal(ctx, "meal1").Return(&menu.Meal{MealID: "meal1"}, nil)
+	mockStore.EXPECT().LoadMeal(ctx, "meal2").Return(nil, oops.NewDBError(errors.New("bad row"), "LoadMeal.Scan", "meal2"))
+	mockStore.EXPECT().LoadDish(ctx, "dish1").Return(nil, oops.NewDBError(oops.ErrNoData, "LoadDish", "dish1"))
+	mockStore.EXPECT().LoadDishes(ctx).Return(nil, context.Canceled)
+
+	meal, err := store.LoadMeal(ctx, "meal1")
+	assert.NoError(t, err)
+	assert.Equal(t, "meal1", meal.MealID)
+	_, err = store.LoadMeal(ctx, "meal2")
+	assert.Error(t, err)
+	_, err = store.LoadDish(ctx, "dish1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	_, err = store.LoadDishes(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	assert.Equal(t, map[string]uint64{"LoadMeal": 2, "LoadDish": 1, "LoadDishes": 1},
+		sampleCounts(t, reg, "menu_manager_store_operation_duration_seconds", "op"))
+
+	// ошибки помечаются шагом операции из DBError.Op, ошибки без DBError — именем метода
+	expected := `
+# HELP menu_manager_store_errors_total Ошибки операций хранилища меню по DBError.Op и причине.
+# TYPE menu_manager_store_errors_total counter
+menu_manager_store_errors_total{op="LoadDish",reason="no_data"} 1
+menu_manager_store_errors_total{op="LoadDishes",reason="canceled"} 1
+menu_manager_store_errors_total{op="LoadMeal.Scan",reason="other"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "menu_manager_store_errors_total"))
+}
+
+func TestMeteredClient(t *testing.T) {
+	server := barnstub.Start([]common.Product{{ID: "eggs", Name: "Eggs", Amount: 1}})
+	defer server.Close()
+
+	reg := prometheus.NewRegistry()
+	client := menu.NewMeteredClient(menu.NewClient(server.URL,
+		menu.WithRetries(0, 0, 0),
+		menu.WithCircuitBreaker(1, time.Minute)), reg)
+	ctx := context.Background()
+
+	_, err := client.GetProducts(ctx, eggsRecipes)
+	assert.NoError(t, err)
+
+	server.SetBehavior(barnstub.Behavior{FailNext: 1, ErrorStatus: 500})
+	_, err = client.GetProducts(ctx, eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrBarnUnavailable)
+
+	// после ошибки breaker разомкнут, запрос до barn manager не доходит
+	_, err = client.GetProducts(ctx, eggsRecipes)
+	assert.ErrorIs(t, err, oops.ErrCircuitOpen)
+
+	assert.Equal(t, map[string]uint64{"200": 1, "500": 1, "circuit_open": 1},
+		sampleCounts(t, reg, "menu_manager_barn_request_duration_seconds", "status"))
+}
+
+func TestRescheduleMenu_Metrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	reg := prometheus.NewRegistry()
+	clock := func() time.Time { return time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, nil, menu.WithClock(clock), menu.WithRescheduleMetrics(reg))
+
+	ctx := context.Background()
+	stale := []menu.Menu{{MealID: "meal1", Time: time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC), MealType: "lunch"}}
+
+	gomock.InOrder(
+		mockStore.EXPECT().UpdateMenu(ctx, "123", gomock.Any()).Return(nil),
+		mockStore.EXPECT().UpdateMenu(ctx, "123", gomock.Any()).Return(oops.ErrDBConnection),
+	)
+
+	_, err := service.RescheduleMenu(ctx, stale, "123")
+	assert.NoError(t, err)
+	_, err = service.RescheduleMenu(ctx, stale, "123")
+	assert.ErrorIs(t, err, oops.ErrDBConnection)
+
+	expected := `
+# HELP menu_manager_reschedules_total Переносы устаревших меню по стратегии и результату.
+# TYPE menu_manager_reschedules_total counter
+menu_manager_reschedules_total{result="error",strategy="keep-slot"} 1
+menu_manager_reschedules_total{result="ok",strategy="keep-slot"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "menu_manager_reschedules_total"))
+}
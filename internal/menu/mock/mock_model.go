@@ -8,6 +8,7 @@ import (
 	context "context"
 	menu "menu_manager/internal/menu"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,14 +36,101 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// CreateDish mocks base method.
+func (m *MockService) CreateDish(ctx context.Context, dish menu.Dish) (*menu.Dish, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDish", ctx, dish)
+	ret0, _ := ret[0].(*menu.Dish)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDish indicates an expected call of CreateDish.
+func (mr *MockServiceMockRecorder) CreateDish(ctx, dish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDish", reflect.TypeOf((*MockService)(nil).CreateDish), ctx, dish)
+}
+
+// CreateMenuEntry mocks base method.
+func (m *MockService) CreateMenuEntry(ctx context.Context, userID string, entry menu.Menu) (*menu.Menu, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMenuEntry", ctx, userID, entry)
+	ret0, _ := ret[0].(*menu.Menu)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMenuEntry indicates an expected call of CreateMenuEntry.
+func (mr *MockServiceMockRecorder) CreateMenuEntry(ctx, userID, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMenuEntry", reflect.TypeOf((*MockService)(nil).CreateMenuEntry), ctx, userID, entry)
+}
+
+// DeleteDish mocks base method.
+func (m *MockService) DeleteDish(ctx context.Context, dishID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDish", ctx, dishID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDish indicates an expected call of DeleteDish.
+func (mr *MockServiceMockRecorder) DeleteDish(ctx, dishID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDish", reflect.TypeOf((*MockService)(nil).DeleteDish), ctx, dishID)
+}
+
+// DeleteMenuEntry mocks base method.
+func (m *MockService) DeleteMenuEntry(ctx context.Context, userID, mealID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMenuEntry", ctx, userID, mealID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMenuEntry indicates an expected call of DeleteMenuEntry.
+func (mr *MockServiceMockRecorder) DeleteMenuEntry(ctx, userID, mealID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMenuEntry", reflect.TypeOf((*MockService)(nil).DeleteMenuEntry), ctx, userID, mealID)
+}
+
+// GenerateMenu mocks base method.
+func (m *MockService) GenerateMenu(ctx context.Context, userID string, req menu.GenerateRequest) (*menu.MenuView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GenerateMenu", ctx, userID, req)
+	ret0, _ := ret[0].(*menu.MenuView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GenerateMenu indicates an expected call of GenerateMenu.
+func (mr *MockServiceMockRecorder) GenerateMenu(ctx, userID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateMenu", reflect.TypeOf((*MockService)(nil).GenerateMenu), ctx, userID, req)
+}
+
+// GetDish mocks base method.
+func (m *MockService) GetDish(ctx context.Context, dishID string) (*menu.Dish, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDish", ctx, dishID)
+	ret0, _ := ret[0].(*menu.Dish)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDish indicates an expected call of GetDish.
+func (mr *MockServiceMockRecorder) GetDish(ctx, dishID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDish", reflect.TypeOf((*MockService)(nil).GetDish), ctx, dishID)
+}
+
 // GetMeal mocks base method.
-func (m *MockService) GetMeal(ctx context.Context, userID string) (*menu.Meal, string, error) {
+func (m *MockService) GetMeal(ctx context.Context, userID string) (*menu.MealDetails, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetMeal", ctx, userID)
-	ret0, _ := ret[0].(*menu.Meal)
-	ret1, _ := ret[1].(string)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret0, _ := ret[0].(*menu.MealDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // GetMeal indicates an expected call of GetMeal.
@@ -66,6 +154,51 @@ func (mr *MockServiceMockRecorder) GetMenu(ctx, userID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMenu", reflect.TypeOf((*MockService)(nil).GetMenu), ctx, userID)
 }
 
+// GetMenuEntry mocks base method.
+func (m *MockService) GetMenuEntry(ctx context.Context, userID, mealID string) (*menu.Menu, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMenuEntry", ctx, userID, mealID)
+	ret0, _ := ret[0].(*menu.Menu)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMenuEntry indicates an expected call of GetMenuEntry.
+func (mr *MockServiceMockRecorder) GetMenuEntry(ctx, userID, mealID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMenuEntry", reflect.TypeOf((*MockService)(nil).GetMenuEntry), ctx, userID, mealID)
+}
+
+// GetMenuView mocks base method.
+func (m *MockService) GetMenuView(ctx context.Context, userID string, from, to time.Time) (*menu.MenuView, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMenuView", ctx, userID, from, to)
+	ret0, _ := ret[0].(*menu.MenuView)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMenuView indicates an expected call of GetMenuView.
+func (mr *MockServiceMockRecorder) GetMenuView(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMenuView", reflect.TypeOf((*MockService)(nil).GetMenuView), ctx, userID, from, to)
+}
+
+// GetUpcomingMeals mocks base method.
+func (m *MockService) GetUpcomingMeals(ctx context.Context, userID string, limit int) ([]menu.ScheduledMeal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUpcomingMeals", ctx, userID, limit)
+	ret0, _ := ret[0].([]menu.ScheduledMeal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUpcomingMeals indicates an expected call of GetUpcomingMeals.
+func (mr *MockServiceMockRecorder) GetUpcomingMeals(ctx, userID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUpcomingMeals", reflect.TypeOf((*MockService)(nil).GetUpcomingMeals), ctx, userID, limit)
+}
+
 // RescheduleMenu mocks base method.
 func (m *MockService) RescheduleMenu(ctx context.Context, currentMenu []menu.Menu, userID string) ([]menu.Menu, error) {
 	m.ctrl.T.Helper()
@@ -81,6 +214,51 @@ func (mr *MockServiceMockRecorder) RescheduleMenu(ctx, currentMenu, userID inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleMenu", reflect.TypeOf((*MockService)(nil).RescheduleMenu), ctx, currentMenu, userID)
 }
 
+// RescheduleStale mocks base method.
+func (m *MockService) RescheduleStale(ctx context.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleStale", ctx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RescheduleStale indicates an expected call of RescheduleStale.
+func (mr *MockServiceMockRecorder) RescheduleStale(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleStale", reflect.TypeOf((*MockService)(nil).RescheduleStale), ctx)
+}
+
+// UpdateDish mocks base method.
+func (m *MockService) UpdateDish(ctx context.Context, dish menu.Dish) (*menu.Dish, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDish", ctx, dish)
+	ret0, _ := ret[0].(*menu.Dish)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDish indicates an expected call of UpdateDish.
+func (mr *MockServiceMockRecorder) UpdateDish(ctx, dish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDish", reflect.TypeOf((*MockService)(nil).UpdateDish), ctx, dish)
+}
+
+// UpdateMenuEntry mocks base method.
+func (m *MockService) UpdateMenuEntry(ctx context.Context, userID string, entry menu.Menu) (*menu.Menu, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMenuEntry", ctx, userID, entry)
+	ret0, _ := ret[0].(*menu.Menu)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateMenuEntry indicates an expected call of UpdateMenuEntry.
+func (mr *MockServiceMockRecorder) UpdateMenuEntry(ctx, userID, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMenuEntry", reflect.TypeOf((*MockService)(nil).UpdateMenuEntry), ctx, userID, entry)
+}
+
 // MockStore is a mock of Store interface.
 type MockStore struct {
 	ctrl     *gomock.Controller
@@ -104,6 +282,64 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// DeleteDish mocks base method.
+func (m *MockStore) DeleteDish(ctx context.Context, dishID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteDish", ctx, dishID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteDish indicates an expected call of DeleteDish.
+func (mr *MockStoreMockRecorder) DeleteDish(ctx, dishID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDish", reflect.TypeOf((*MockStore)(nil).DeleteDish), ctx, dishID)
+}
+
+// DeleteMenuEntry mocks base method.
+func (m *MockStore) DeleteMenuEntry(ctx context.Context, userID, mealID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMenuEntry", ctx, userID, mealID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMenuEntry indicates an expected call of DeleteMenuEntry.
+func (mr *MockStoreMockRecorder) DeleteMenuEntry(ctx, userID, mealID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMenuEntry", reflect.TypeOf((*MockStore)(nil).DeleteMenuEntry), ctx, userID, mealID)
+}
+
+// LoadDish mocks base method.
+func (m *MockStore) LoadDish(ctx context.Context, dishID string) (*menu.Dish, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDish", ctx, dishID)
+	ret0, _ := ret[0].(*menu.Dish)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDish indicates an expected call of LoadDish.
+func (mr *MockStoreMockRecorder) LoadDish(ctx, dishID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDish", reflect.TypeOf((*MockStore)(nil).LoadDish), ctx, dishID)
+}
+
+// LoadDishes mocks base method.
+func (m *MockStore) LoadDishes(ctx context.Context) ([]menu.Dish, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadDishes", ctx)
+	ret0, _ := ret[0].([]menu.Dish)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadDishes indicates an expected call of LoadDishes.
+func (mr *MockStoreMockRecorder) LoadDishes(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadDishes", reflect.TypeOf((*MockStore)(nil).LoadDishes), ctx)
+}
+
 // LoadMeal mocks base method.
 func (m *MockStore) LoadMeal(ctx context.Context, MealID string) (*menu.Meal, error) {
 	m.ctrl.T.Helper()
@@ -119,6 +355,21 @@ func (mr *MockStoreMockRecorder) LoadMeal(ctx, MealID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadMeal", reflect.TypeOf((*MockStore)(nil).LoadMeal), ctx, MealID)
 }
 
+// LoadMeals mocks base method.
+func (m *MockStore) LoadMeals(ctx context.Context, mealIDs []string) (map[string]*menu.Meal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadMeals", ctx, mealIDs)
+	ret0, _ := ret[0].(map[string]*menu.Meal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadMeals indicates an expected call of LoadMeals.
+func (mr *MockStoreMockRecorder) LoadMeals(ctx, mealIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadMeals", reflect.TypeOf((*MockStore)(nil).LoadMeals), ctx, mealIDs)
+}
+
 // LoadMenu mocks base method.
 func (m *MockStore) LoadMenu(ctx context.Context, userID string) ([]menu.Menu, error) {
 	m.ctrl.T.Helper()
@@ -134,6 +385,107 @@ func (mr *MockStoreMockRecorder) LoadMenu(ctx, userID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadMenu", reflect.TypeOf((*MockStore)(nil).LoadMenu), ctx, userID)
 }
 
+// LoadMenuEntry mocks base method.
+func (m *MockStore) LoadMenuEntry(ctx context.Context, userID, mealID string) (*menu.Menu, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadMenuEntry", ctx, userID, mealID)
+	ret0, _ := ret[0].(*menu.Menu)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadMenuEntry indicates an expected call of LoadMenuEntry.
+func (mr *MockStoreMockRecorder) LoadMenuEntry(ctx, userID, mealID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadMenuEntry", reflect.TypeOf((*MockStore)(nil).LoadMenuEntry), ctx, userID, mealID)
+}
+
+// LoadMenuRange mocks base method.
+func (m *MockStore) LoadMenuRange(ctx context.Context, userID string, from, to time.Time) ([]menu.Menu, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadMenuRange", ctx, userID, from, to)
+	ret0, _ := ret[0].([]menu.Menu)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadMenuRange indicates an expected call of LoadMenuRange.
+func (mr *MockStoreMockRecorder) LoadMenuRange(ctx, userID, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadMenuRange", reflect.TypeOf((*MockStore)(nil).LoadMenuRange), ctx, userID, from, to)
+}
+
+// LoadStaleUsers mocks base method.
+func (m *MockStore) LoadStaleUsers(ctx context.Context, before time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadStaleUsers", ctx, before)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadStaleUsers indicates an expected call of LoadStaleUsers.
+func (mr *MockStoreMockRecorder) LoadStaleUsers(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadStaleUsers", reflect.TypeOf((*MockStore)(nil).LoadStaleUsers), ctx, before)
+}
+
+// ReplaceMenuRange mocks base method.
+func (m *MockStore) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []menu.Menu, dishes []menu.Dish) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceMenuRange", ctx, userID, from, to, entries, dishes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceMenuRange indicates an expected call of ReplaceMenuRange.
+func (mr *MockStoreMockRecorder) ReplaceMenuRange(ctx, userID, from, to, entries, dishes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceMenuRange", reflect.TypeOf((*MockStore)(nil).ReplaceMenuRange), ctx, userID, from, to, entries, dishes)
+}
+
+// SaveDish mocks base method.
+func (m *MockStore) SaveDish(ctx context.Context, dish menu.Dish) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveDish", ctx, dish)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveDish indicates an expected call of SaveDish.
+func (mr *MockStoreMockRecorder) SaveDish(ctx, dish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveDish", reflect.TypeOf((*MockStore)(nil).SaveDish), ctx, dish)
+}
+
+// SaveMenuEntry mocks base method.
+func (m *MockStore) SaveMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveMenuEntry", ctx, userID, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveMenuEntry indicates an expected call of SaveMenuEntry.
+func (mr *MockStoreMockRecorder) SaveMenuEntry(ctx, userID, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMenuEntry", reflect.TypeOf((*MockStore)(nil).SaveMenuEntry), ctx, userID, entry)
+}
+
+// UpdateDish mocks base method.
+func (m *MockStore) UpdateDish(ctx context.Context, dish menu.Dish) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDish", ctx, dish)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateDish indicates an expected call of UpdateDish.
+func (mr *MockStoreMockRecorder) UpdateDish(ctx, dish interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDish", reflect.TypeOf((*MockStore)(nil).UpdateDish), ctx, dish)
+}
+
 // UpdateMenu mocks base method.
 func (m *MockStore) UpdateMenu(ctx context.Context, userID string, menuList []menu.Menu) error {
 	m.ctrl.T.Helper()
@@ -148,6 +500,89 @@ func (mr *MockStoreMockRecorder) UpdateMenu(ctx, userID, menuList interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMenu", reflect.TypeOf((*MockStore)(nil).UpdateMenu), ctx, userID, menuList)
 }
 
+// UpdateMenuEntry mocks base method.
+func (m *MockStore) UpdateMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMenuEntry", ctx, userID, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateMenuEntry indicates an expected call of UpdateMenuEntry.
+func (mr *MockStoreMockRecorder) UpdateMenuEntry(ctx, userID, entry interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMenuEntry", reflect.TypeOf((*MockStore)(nil).UpdateMenuEntry), ctx, userID, entry)
+}
+
+// MockProfileProvider is a mock of ProfileProvider interface.
+type MockProfileProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockProfileProviderMockRecorder
+}
+
+// MockProfileProviderMockRecorder is the mock recorder for MockProfileProvider.
+type MockProfileProviderMockRecorder struct {
+	mock *MockProfileProvider
+}
+
+// NewMockProfileProvider creates a new mock instance.
+func NewMockProfileProvider(ctrl *gomock.Controller) *MockProfileProvider {
+	mock := &MockProfileProvider{ctrl: ctrl}
+	mock.recorder = &MockProfileProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProfileProvider) EXPECT() *MockProfileProviderMockRecorder {
+	return m.recorder
+}
+
+// Location mocks base method.
+func (m *MockProfileProvider) Location(ctx context.Context, userID string) (*time.Location, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Location", ctx, userID)
+	ret0, _ := ret[0].(*time.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Location indicates an expected call of Location.
+func (mr *MockProfileProviderMockRecorder) Location(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Location", reflect.TypeOf((*MockProfileProvider)(nil).Location), ctx, userID)
+}
+
+// RescheduleStrategy mocks base method.
+func (m *MockProfileProvider) RescheduleStrategy(ctx context.Context, userID string) (string, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleStrategy", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RescheduleStrategy indicates an expected call of RescheduleStrategy.
+func (mr *MockProfileProviderMockRecorder) RescheduleStrategy(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleStrategy", reflect.TypeOf((*MockProfileProvider)(nil).RescheduleStrategy), ctx, userID)
+}
+
+// Violations mocks base method.
+func (m *MockProfileProvider) Violations(ctx context.Context, userID string, productIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Violations", ctx, userID, productIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Violations indicates an expected call of Violations.
+func (mr *MockProfileProviderMockRecorder) Violations(ctx, userID, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Violations", reflect.TypeOf((*MockProfileProvider)(nil).Violations), ctx, userID, productIDs)
+}
+
 // MockClient is a mock of Client interface.
 type MockClient struct {
 	ctrl     *gomock.Controller
@@ -172,10 +607,10 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // GetProducts mocks base method.
-func (m *MockClient) GetProducts(ctx context.Context, recipes []string) (string, error) {
+func (m *MockClient) GetProducts(ctx context.Context, recipes []menu.Recipe) (*menu.ShoppingList, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetProducts", ctx, recipes)
-	ret0, _ := ret[0].(string)
+	ret0, _ := ret[0].(*menu.ShoppingList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -8,9 +8,9 @@ import (
 
 // Menu представляет план питания на определенный период
 type Menu struct {
-	MealID   string
-	Time     time.Time // когда надо кушать
-	MealType string    // завтрак, обед, ужин
+	MealID   string    `json:"meal_id"`
+	Time     time.Time `json:"eat_date"`  // когда надо кушать
+	MealType string    `json:"meal_type"` // завтрак, обед, ужин
 }
 
 // Meal представляет прием пищи
@@ -19,10 +19,99 @@ type Meal struct {
 	DishIDs        []string                        `json:"ID_dish"`
 	DishNames      []string                        `json:"dishname"`
 	Type           MealType                        `json:"type"`   // завтрак, обед, ужин
-	Recipes        []string                        `json:"recipe"` // json с рецептом и списком продуктов
+	Recipes        []Recipe                        `json:"recipe"` // рецепты блюд со списком продуктов
 	TotalNutrition common.NutritionalValueAbsolute `json:"total_nutrition"`
 }
 
+// MealDetails ближайший прием пищи со списком продуктов, которые нужно докупить
+type MealDetails struct {
+	Meal         *Meal
+	ShoppingList *ShoppingList
+	// Partial означает, что актуальный список покупок получить не удалось:
+	// ShoppingList отсутствует или взят из кэша, причина описана в Warnings
+	Partial  bool
+	Warnings []string
+}
+
+// Dish представляет блюдо, входящее в прием пищи
+type Dish struct {
+	DishID         string                          `json:"id"`
+	MealID         string                          `json:"meal_id"`
+	Name           string                          `json:"name"`
+	Recipe         Recipe                          `json:"recipe"`
+	TotalNutrition common.NutritionalValueAbsolute `json:"total_nutrition"`
+	// MealType тип приема пищи, к которому относится блюдо. Заполняется LoadDishes по расписанию и не сохраняется
+	MealType string `json:"meal_type,omitempty"`
+	// SourceDishID id блюда каталога, копией которого является блюдо, созданное GenerateMenu.
+	// Пусто у блюд, заведенных пользователем. Сохраняется ReplaceMenuRange и читается LoadDishes
+	SourceDishID string `json:"source_dish_id,omitempty"`
+}
+
+// Recipe представляет рецепт блюда: список продуктов и шаги приготовления
+type Recipe struct {
+	Ingredients []Ingredient `json:"ingredients"`
+	Steps       []string     `json:"steps"`
+}
+
+// Ingredient представляет продукт, необходимый для приготовления блюда
+type Ingredient struct {
+	ProductID string `json:"product_id"`
+	Amount    uint   `json:"amount"` // количество в единицах unit
+	Unit      string `json:"unit"`   // г, мл, шт
+}
+
+// MenuView представляет расписание приемов пищи за период, сгруппированное по дням
+type MenuView struct {
+	From string    `json:"from"` // первый день периода, YYYY-MM-DD
+	To   string    `json:"to"`   // последний день периода включительно, YYYY-MM-DD
+	Days []DayMenu `json:"days"`
+}
+
+// DayMenu представляет приемы пищи одного дня и их суммарную пищевую ценность
+type DayMenu struct {
+	Date           string                          `json:"date"` // YYYY-MM-DD
+	Meals          []ScheduledMeal                 `json:"meals"`
+	TotalNutrition common.NutritionalValueAbsolute `json:"total_nutrition"`
+}
+
+// ScheduledMeal представляет запланированный прием пищи вместе с составом блюд
+type ScheduledMeal struct {
+	MealID         string                          `json:"meal_id"`
+	MealType       MealType                        `json:"meal_type"`
+	Time           time.Time                       `json:"eat_date"`
+	DishNames      []string                        `json:"dish_names"`
+	TotalNutrition common.NutritionalValueAbsolute `json:"total_nutrition"`
+}
+
+// NutritionTargets задает дневную норму калорий и макронутриентов пользователя
+type NutritionTargets struct {
+	Calories      uint `json:"calories"`
+	Proteins      uint `json:"proteins"`
+	Fats          uint `json:"fats"`
+	Carbohydrates uint `json:"carbohydrates"`
+}
+
+// GenerateRequest описывает параметры автоматического составления меню
+type GenerateRequest struct {
+	From    time.Time        // первый день периода
+	To      time.Time        // последний день периода включительно
+	Targets NutritionTargets // дневная норма
+}
+
+// ShoppingList представляет список продуктов, которые нужно докупить для приема пищи
+type ShoppingList struct {
+	Items []ShoppingItem `json:"items"`
+}
+
+// ShoppingItem представляет позицию списка покупок
+type ShoppingItem struct {
+	Product  common.Product `json:"product"`
+	Required uint           `json:"required"` // сколько продукта нужно по рецептам
+	ToBuy    uint           `json:"to_buy"`   // сколько продукта не хватает
+	Unit     string         `json:"unit"`
+	Packages uint           `json:"packages"` // сколько упаковок нужно купить
+}
+
 // MealType определяет тип приема пищи
 type MealType string
 
@@ -33,27 +122,105 @@ const (
 	MealTypeSnack     MealType = "snack"
 )
 
+// IsValid проверяет, что тип приема пищи входит в список допустимых
+func (t MealType) IsValid() bool {
+	switch t {
+	case MealTypeBreakfast, MealTypeLunch, MealTypeDinner, MealTypeSnack:
+		return true
+	}
+	return false
+}
+
 // Service определяет интерфейс для работы с меню
 type Service interface {
-	// GetMeal возвращает прием пищи и его рецепт со списком продуктов, которые нужно докупить
-	GetMeal(ctx context.Context, userID string) (*Meal, string, error)
+	// GetMeal возвращает прием пищи и его рецепт со списком продуктов, которые нужно докупить.
+	// Если barn manager недоступен, прием пищи возвращается без списка покупок с флагом Partial
+	GetMeal(ctx context.Context, userID string) (*MealDetails, error)
+	// GetUpcomingMeals возвращает до limit ближайших приемов пищи, в том числе в следующие дни.
+	// Нулевой limit означает значение по умолчанию
+	GetUpcomingMeals(ctx context.Context, userID string, limit int) ([]ScheduledMeal, error)
+	// RescheduleStale заранее переносит меню всех пользователей, у которых скоро закончатся предстоящие приемы пищи.
+	// Возвращает число перенесенных меню
+	RescheduleStale(ctx context.Context) (int, error)
 	// rescheduleMenu обновляет время и даты приемов пищи
 	RescheduleMenu(ctx context.Context, currentMenu []Menu, userID string) ([]Menu, error)
 	// GetMenu возвращает меню по ID
 	GetMenu(ctx context.Context, userID string) ([]Menu, error)
+	// GetMenuView возвращает приемы пищи за период с блюдами и пищевой ценностью, сгруппированные по дням.
+	// Нулевые from и to означают текущую неделю, начиная с сегодняшнего дня
+	GetMenuView(ctx context.Context, userID string, from, to time.Time) (*MenuView, error)
+	// GenerateMenu составляет меню на период из каталога блюд под дневную норму пользователя
+	// и заменяет им расписание за этот период
+	GenerateMenu(ctx context.Context, userID string, req GenerateRequest) (*MenuView, error)
+	// CreateMenuEntry добавляет прием пищи в расписание пользователя
+	CreateMenuEntry(ctx context.Context, userID string, entry Menu) (*Menu, error)
+	// GetMenuEntry возвращает запланированный прием пищи пользователя
+	GetMenuEntry(ctx context.Context, userID string, mealID string) (*Menu, error)
+	// UpdateMenuEntry изменяет тип и время запланированного приема пищи
+	UpdateMenuEntry(ctx context.Context, userID string, entry Menu) (*Menu, error)
+	// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+	DeleteMenuEntry(ctx context.Context, userID string, mealID string) error
+	// CreateDish добавляет новое блюдо
+	CreateDish(ctx context.Context, dish Dish) (*Dish, error)
+	// GetDish возвращает блюдо по ID
+	GetDish(ctx context.Context, dishID string) (*Dish, error)
+	// UpdateDish изменяет название, рецепт и пищевую ценность блюда
+	UpdateDish(ctx context.Context, dish Dish) (*Dish, error)
+	// DeleteDish удаляет блюдо
+	DeleteDish(ctx context.Context, dishID string) error
 }
 
 // Store определяет интерфейс для хранения меню
 type Store interface {
 	// LoadMenu возвращает меню из БД со списком id приемов пиши и их запланированного времени
 	LoadMenu(ctx context.Context, userID string) ([]Menu, error)
+	// LoadMenuRange возвращает приемы пищи пользователя в полуинтервале [from, to), упорядоченные по времени.
+	// Пустой период не считается ошибкой
+	LoadMenuRange(ctx context.Context, userID string, from, to time.Time) ([]Menu, error)
 	// LoadMeal возвращает из базы прием пищи с описанием составляющих его блюд и продуктов
 	LoadMeal(ctx context.Context, MealID string) (*Meal, error)
+	// LoadMeals возвращает приемы пищи по списку id одним обращением к БД, ключ — MealID.
+	// Каждый запрошенный прием пищи есть в результате, прием пищи без блюд возвращается пустым
+	LoadMeals(ctx context.Context, mealIDs []string) (map[string]*Meal, error)
 	// UpdateMenu обновляет время и даты приемов пищи
 	UpdateMenu(ctx context.Context, userID string, menuList []Menu) error
+	// SaveMenuEntry сохраняет новый прием пищи в расписании пользователя
+	SaveMenuEntry(ctx context.Context, userID string, entry Menu) error
+	// LoadMenuEntry возвращает запланированный прием пищи пользователя
+	LoadMenuEntry(ctx context.Context, userID string, mealID string) (*Menu, error)
+	// UpdateMenuEntry обновляет тип и время запланированного приема пищи
+	UpdateMenuEntry(ctx context.Context, userID string, entry Menu) error
+	// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+	DeleteMenuEntry(ctx context.Context, userID string, mealID string) error
+	// SaveDish сохраняет новое блюдо
+	SaveDish(ctx context.Context, dish Dish) error
+	// LoadDish возвращает блюдо по ID
+	LoadDish(ctx context.Context, dishID string) (*Dish, error)
+	// UpdateDish обновляет блюдо
+	UpdateDish(ctx context.Context, dish Dish) error
+	// DeleteDish удаляет блюдо
+	DeleteDish(ctx context.Context, dishID string) error
+	// LoadDishes возвращает каталог всех блюд с типом приема пищи, к которому они относятся
+	LoadDishes(ctx context.Context) ([]Dish, error)
+	// ReplaceMenuRange атомарно заменяет расписание пользователя в полуинтервале [from, to)
+	// новыми приемами пищи и их блюдами. Блюда замененных приемов пищи с SourceDishID удаляются
+	ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []Menu, dishes []Dish) error
+	// LoadStaleUsers возвращает пользователей, последний прием пищи которых запланирован раньше before
+	LoadStaleUsers(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// ProfileProvider предоставляет данные профиля пользователя: ограничения в питании, часовой пояс
+// и стратегию переноса меню
+type ProfileProvider interface {
+	// Violations возвращает продукты из списка, которые пользователю нельзя включать в меню
+	Violations(ctx context.Context, userID string, productIDs []string) ([]string, error)
+	// Location возвращает часовой пояс пользователя
+	Location(ctx context.Context, userID string) (*time.Location, error)
+	// RescheduleStrategy возвращает имя стратегии переноса меню и длину цикла ротации в неделях
+	RescheduleStrategy(ctx context.Context, userID string) (string, int, error)
 }
 
 type Client interface {
 	// GetProducts получает список продуктов для покупки у сервиса barn manager
-	GetProducts(ctx context.Context, recipes []string) (string, error)
+	GetProducts(ctx context.Context, recipes []Recipe) (*ShoppingList, error)
 }
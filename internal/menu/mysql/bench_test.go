@@ -0,0 +1,161 @@
+package mysql_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/mysql"
+	common "menu_manager/internal/models"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+)
+
+// Бенчмарки загрузки недели меню: 28 приемов пищи по 3 блюда.
+// С MENU_MANAGER_TEST_MYSQL_DSN они идут на настоящую базу (таблицы меню будут очищены),
+// без нее база заменяется sqlmock с задержкой roundTrip на каждый запрос:
+// цена N+1 определяется числом обращений к серверу, а не разбором строк.
+//
+//	go test -run '^$' -bench LoadWeek ./internal/menu/mysql
+
+const (
+	weekMeals     = 28
+	dishesPerMeal = 3
+	// roundTrip типичная задержка запроса к MySQL в той же сети
+	roundTrip = 200 * time.Microsecond
+)
+
+func weekMealIDs() []string {
+	ids := make([]string, weekMeals)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("meal-%02d", i)
+	}
+	return ids
+}
+
+func weekDish(mealID string, i int) menu.Dish {
+	return menu.Dish{
+		DishID: fmt.Sprintf("%s-dish-%d", mealID, i),
+		MealID: mealID,
+		Name:   fmt.Sprintf("Блюдо %d", i),
+		Recipe: menu.Recipe{
+			Ingredients: []menu.Ingredient{{ProductID: "яйцо", Amount: 2, Unit: "шт"}, {ProductID: "молоко", Amount: 100, Unit: "мл"}},
+			Steps:       []string{"Смешать", "Пожарить"},
+		},
+		TotalNutrition: common.NutritionalValueAbsolute{Proteins: 15, Fats: 12, Carbohydrates: 5, Calories: 190},
+	}
+}
+
+func BenchmarkLoadWeek(b *testing.B) {
+	ctx := context.Background()
+	mealIDs := weekMealIDs()
+
+	perMeal := func(store *mysql.Storage) {
+		for _, mealID := range mealIDs {
+			if _, err := store.LoadMeal(ctx, mealID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	batch := func(store *mysql.Storage) {
+		if _, err := store.LoadMeals(ctx, mealIDs); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	if dsn := os.Getenv(mysqlDSNEnv); dsn != "" {
+		store := seedWeek(b, dsn, mealIDs)
+		b.Run("LoadMeal", func(b *testing.B) {
+			for range b.N {
+				perMeal(store)
+			}
+		})
+		b.Run("LoadMeals", func(b *testing.B) {
+			for range b.N {
+				batch(store)
+			}
+		})
+		return
+	}
+
+	b.Run("LoadMeal", func(b *testing.B) {
+		store, mock := newDelayedStore(b)
+		for range b.N {
+			b.StopTimer()
+			for _, mealID := range mealIDs {
+				rows := sqlmock.NewRows([]string{"dish_id", "name", "recipie", "total_nutrition"})
+				for i := range dishesPerMeal {
+					dish := weekDish(mealID, i)
+					recipe, nutrition := dishJSON(dish)
+					rows.AddRow(dish.DishID, dish.Name, recipe, nutrition)
+				}
+				mock.ExpectQuery(`FROM dishes WHERE meal_id = \?`).WithArgs(mealID).WillDelayFor(roundTrip).WillReturnRows(rows)
+			}
+			b.StartTimer()
+
+			perMeal(store)
+		}
+	})
+	b.Run("LoadMeals", func(b *testing.B) {
+		store, mock := newDelayedStore(b)
+		for range b.N {
+			b.StopTimer()
+			rows := sqlmock.NewRows([]string{"meal_id", "dish_id", "name", "recipie", "total_nutrition"})
+			for _, mealID := range mealIDs {
+				for i := range dishesPerMeal {
+					dish := weekDish(mealID, i)
+					recipe, nutrition := dishJSON(dish)
+					rows.AddRow(dish.MealID, dish.DishID, dish.Name, recipe, nutrition)
+				}
+			}
+			mock.ExpectQuery(`FROM dishes WHERE meal_id IN`).WillDelayFor(roundTrip).WillReturnRows(rows)
+			b.StartTimer()
+
+			batch(store)
+		}
+	})
+}
+
+func newDelayedStore(b *testing.B) (*mysql.Storage, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return mysql.NewStorage(sqlx.NewDb(db, "sqlmock")), mock
+}
+
+func dishJSON(dish menu.Dish) ([]byte, []byte) {
+	recipe, _ := json.Marshal(dish.Recipe)
+	nutrition, _ := json.Marshal(dish.TotalNutrition)
+	return recipe, nutrition
+}
+
+// seedWeek очищает тестовую базу и заполняет ее неделей меню
+func seedWeek(b *testing.B, dsn string, mealIDs []string) *mysql.Storage {
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	for _, table := range []string{"menu", "dishes"} {
+		if _, err := db.Exec("DELETE FROM " + table); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	store := mysql.NewStorage(db)
+	for _, mealID := range mealIDs {
+		for i := range dishesPerMeal {
+			if err := store.SaveDish(context.Background(), weekDish(mealID, i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return store
+}
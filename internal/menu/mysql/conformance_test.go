@@ -0,0 +1,39 @@
+package mysql_test
+
+import (
+	"context"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/mysql"
+	"menu_manager/internal/menu/storetest"
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// mysqlDSNEnv задает DSN тестовой базы MySQL с примененными миграциями migrations/mysql
+// и параметрами parseTime=true&loc=UTC, как в configs/config.yaml.
+// Тест очищает таблицы menu, dishes и scheduler_locks, поэтому рабочую базу указывать нельзя
+const mysqlDSNEnv = "MENU_MANAGER_TEST_MYSQL_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(mysqlDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан", mysqlDSNEnv)
+	}
+
+	db, err := sqlx.ConnectContext(context.Background(), "mysql", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storetest.Run(t, func(t *testing.T) menu.Store {
+		for _, table := range []string{"menu", "dishes", "scheduler_locks"} {
+			if _, err := db.Exec("DELETE FROM " + table); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return mysql.NewStorage(db)
+	})
+}
@@ -0,0 +1,37 @@
+package postgres_test
+
+import (
+	"context"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/postgres"
+	"menu_manager/internal/menu/storetest"
+	"os"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+)
+
+// postgresDSNEnv задает DSN тестовой базы PostgreSQL с примененными миграциями migrations/postgres.
+// Тест очищает таблицы menu, dishes и scheduler_locks, поэтому рабочую базу указывать нельзя
+const postgresDSNEnv = "MENU_MANAGER_TEST_POSTGRES_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(postgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s не задан", postgresDSNEnv)
+	}
+
+	db, err := sqlx.ConnectContext(context.Background(), "pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	storetest.Run(t, func(t *testing.T) menu.Store {
+		if _, err := db.Exec("TRUNCATE menu, dishes, scheduler_locks"); err != nil {
+			t.Fatal(err)
+		}
+		return postgres.NewStorage(db)
+	})
+}
@@ -0,0 +1,612 @@
+// Package postgres реализует menu.Store поверх PostgreSQL через драйвер pgx (database/sql).
+// Рецепты и пищевая ценность хранятся в jsonb, eat_date — в timestamptz, схема лежит в migrations/postgres
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"menu_manager/internal/logging"
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+// errUniqueViolation код ошибки PostgreSQL (SQLSTATE) при нарушении уникального ключа
+const errUniqueViolation = "23505"
+
+type Storage struct {
+	db     *sqlx.DB
+	logger *slog.Logger
+}
+
+// Option настраивает необязательные параметры хранилища
+type Option func(*Storage)
+
+// WithLogger задает журнал хранилища, по умолчанию используется slog.Default
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Storage) {
+		s.logger = logger
+	}
+}
+
+func NewStorage(db *sqlx.DB, opts ...Option) *Storage {
+	s := &Storage{db: db, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// LoadMenu возвращает меню из БД со списком id приемов пиши и их запланированного времени
+func (s *Storage) LoadMenu(ctx context.Context, userID string) ([]menu.Menu, error) {
+
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = $1
+	`
+	s.logger.DebugContext(ctx, "загрузка меню", logging.UserIDKey, userID)
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMenu", userID)
+	}
+	defer rows.Close()
+
+	var menuList []menu.Menu
+	for rows.Next() {
+		var m menu.Menu
+		err := rows.Scan(
+			&m.MealID,
+			&m.Time,
+			&m.MealType,
+		)
+		if err != nil {
+			return nil, oops.NewDBError(err, "LoadMenu.Scan", userID)
+		}
+		// pgx возвращает timestamptz в локальном часовом поясе процесса
+		m.Time = m.Time.UTC()
+		menuList = append(menuList, m)
+	}
+	if len(menuList) == 0 {
+		return nil, oops.ErrNoData
+	}
+	return menuList, nil
+}
+
+// LoadMeal возвращает из базы прием пищи с описанием составляющих его блюд и продуктов
+func (s *Storage) LoadMeal(ctx context.Context, mealID string) (*menu.Meal, error) {
+
+	// текст запроса
+	query := `
+		SELECT dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE meal_id = $1
+	`
+	// выполняем запрос к БД
+	rows, err := s.db.QueryContext(ctx, query, mealID)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeal", mealID)
+	}
+
+	defer rows.Close()
+
+	// парсим данные
+	meal := menu.Meal{
+		MealID:    mealID,
+		DishIDs:   make([]string, 0, 10),
+		DishNames: make([]string, 0, 10),
+		Type:      "",
+		Recipes:   make([]menu.Recipe, 0, 10),
+		TotalNutrition: common.NutritionalValueAbsolute{
+			Proteins:      0,
+			Fats:          0,
+			Carbohydrates: 0,
+			Calories:      0,
+		},
+	}
+	for rows.Next() {
+
+		var dishID string
+		var dishName string
+		var recipeJson string
+		var nutritionJson string
+
+		err := rows.Scan(
+			&dishID,
+			&dishName,
+			&recipeJson,
+			&nutritionJson,
+		)
+		if err != nil {
+			return nil, oops.NewDBError(err, "LoadMeal.Scan", mealID)
+		}
+
+		// Парсим JSON рецепта и пищевой ценности в структуры
+		var recipe menu.Recipe
+		if err := json.Unmarshal([]byte(recipeJson), &recipe); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeal.JsonUnmarshal", dishID)
+		}
+		var nutritionalValue common.NutritionalValueAbsolute
+		err = json.Unmarshal([]byte(nutritionJson), &nutritionalValue)
+		if err != nil {
+			return nil, oops.NewDBError(err, "LoadMeal.JsonUnmarshal", mealID)
+		}
+
+		meal.DishIDs = append(meal.DishIDs, dishID)
+		meal.DishNames = append(meal.DishNames, dishName)
+		meal.Recipes = append(meal.Recipes, recipe)
+		meal.TotalNutrition = meal.TotalNutrition.AddAbsoluteValue(nutritionalValue)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadMeal.Rows", mealID)
+	}
+	return &meal, nil
+}
+
+// LoadMeals возвращает приемы пищи mealIDs одним запросом, ключ — meal_id.
+// Каждый запрошенный прием пищи есть в результате, прием пищи без блюд возвращается пустым, как в LoadMeal
+func (s *Storage) LoadMeals(ctx context.Context, mealIDs []string) (map[string]*menu.Meal, error) {
+	meals := make(map[string]*menu.Meal, len(mealIDs))
+	if len(mealIDs) == 0 {
+		return meals, nil
+	}
+	for _, mealID := range mealIDs {
+		meals[mealID] = &menu.Meal{
+			MealID:    mealID,
+			DishIDs:   make([]string, 0),
+			DishNames: make([]string, 0),
+			Recipes:   make([]menu.Recipe, 0),
+		}
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT meal_id, dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE meal_id IN (?)
+		ORDER BY name, dish_id
+	`, mealIDs)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals.In", "")
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals", "")
+	}
+	defer rows.Close()
+
+	// строки упорядочены по блюдам, группируем их по приемам пищи
+	for rows.Next() {
+		var dish menu.Dish
+		var recipeJson, nutritionJson string
+		if err := rows.Scan(&dish.MealID, &dish.DishID, &dish.Name, &recipeJson, &nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeals.Scan", "")
+		}
+		if err := json.Unmarshal([]byte(recipeJson), &dish.Recipe); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeals.JsonUnmarshal", dish.DishID)
+		}
+		if err := json.Unmarshal([]byte(nutritionJson), &dish.TotalNutrition); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeals.JsonUnmarshal", dish.DishID)
+		}
+		meal := meals[dish.MealID]
+		meal.DishIDs = append(meal.DishIDs, dish.DishID)
+		meal.DishNames = append(meal.DishNames, dish.Name)
+		meal.Recipes = append(meal.Recipes, dish.Recipe)
+		meal.TotalNutrition = meal.TotalNutrition.AddAbsoluteValue(dish.TotalNutrition)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals.Rows", "")
+	}
+	return meals, nil
+}
+
+// UpdateMenu обновляет время и даты приемов пищи
+func (s *Storage) UpdateMenu(ctx context.Context, userID string, menuList []menu.Menu) error {
+	// Начинаем транзакцию
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return oops.NewDBError(err, "failed to begin transaction", userID)
+	}
+
+	// Обновляем каждую запись
+	updateQuery := "UPDATE menu SET eat_date = $1 WHERE user_id = $2 AND meal_id = $3"
+	for _, m := range menuList {
+		_, err := tx.ExecContext(ctx, updateQuery, m.Time, userID, m.MealID)
+		if err != nil {
+			// При ошибке откатываем транзакцию
+			if rbErr := tx.Rollback(); rbErr != nil {
+				s.logger.ErrorContext(ctx, "не удалось откатить транзакцию", "op", "UpdateMenu", logging.ErrorKey, rbErr)
+			}
+			return oops.NewDBError(err, "failed to update menu", userID)
+		}
+	}
+
+	// Если все прошло успешно, фиксируем изменения
+	if err := tx.Commit(); err != nil {
+		return oops.NewDBError(err, "failed to commit transaction", userID)
+	}
+
+	return nil
+}
+
+// SaveMenuEntry сохраняет новый прием пищи в расписании пользователя
+func (s *Storage) SaveMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	query := `
+		INSERT INTO menu (meal_id, meal_type, eat_date, user_id)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err := s.db.ExecContext(ctx, query, entry.MealID, entry.MealType, entry.Time, userID)
+	if err != nil {
+		if isDuplicate(err) {
+			return oops.NewDBError(oops.ErrDuplicateKey, "SaveMenuEntry", entry.MealID)
+		}
+		return oops.NewDBError(err, "SaveMenuEntry", entry.MealID)
+	}
+	return nil
+}
+
+// LoadMenuEntry возвращает запланированный прием пищи пользователя
+func (s *Storage) LoadMenuEntry(ctx context.Context, userID string, mealID string) (*menu.Menu, error) {
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = $1 AND meal_id = $2
+	`
+	var m menu.Menu
+	err := s.db.QueryRowContext(ctx, query, userID, mealID).Scan(
+		&m.MealID,
+		&m.Time,
+		&m.MealType,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadMenuEntry", mealID)
+	}
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMenuEntry", mealID)
+	}
+	m.Time = m.Time.UTC()
+	return &m, nil
+}
+
+// UpdateMenuEntry обновляет тип и время запланированного приема пищи
+func (s *Storage) UpdateMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	query := `
+		UPDATE menu SET meal_type = $1, eat_date = $2
+		WHERE user_id = $3 AND meal_id = $4
+	`
+	if _, err := s.db.ExecContext(ctx, query, entry.MealType, entry.Time, userID, entry.MealID); err != nil {
+		return oops.NewDBError(err, "UpdateMenuEntry", entry.MealID)
+	}
+	return nil
+}
+
+// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+func (s *Storage) DeleteMenuEntry(ctx context.Context, userID string, mealID string) error {
+	query := `
+		DELETE FROM menu
+		WHERE user_id = $1 AND meal_id = $2
+	`
+	res, err := s.db.ExecContext(ctx, query, userID, mealID)
+	if err != nil {
+		return oops.NewDBError(err, "DeleteMenuEntry", mealID)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return oops.NewDBError(err, "DeleteMenuEntry.RowsAffected", mealID)
+	}
+	if affected == 0 {
+		return oops.NewDBError(oops.ErrNoData, "DeleteMenuEntry", mealID)
+	}
+	return nil
+}
+
+// SaveDish сохраняет новое блюдо
+func (s *Storage) SaveDish(ctx context.Context, dish menu.Dish) error {
+	recipeJson, nutritionJson, err := marshalDish(dish)
+	if err != nil {
+		return oops.NewDBError(err, "SaveDish.JsonMarshal", dish.DishID)
+	}
+
+	query := `
+		INSERT INTO dishes (meal_id, dish_id, name, recipie, total_nutrition)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err = s.db.ExecContext(ctx, query, dish.MealID, dish.DishID, dish.Name, recipeJson, nutritionJson)
+	if err != nil {
+		if isDuplicate(err) {
+			return oops.NewDBError(oops.ErrDuplicateKey, "SaveDish", dish.DishID)
+		}
+		return oops.NewDBError(err, "SaveDish", dish.DishID)
+	}
+	return nil
+}
+
+// LoadDish возвращает блюдо по ID
+func (s *Storage) LoadDish(ctx context.Context, dishID string) (*menu.Dish, error) {
+	query := `
+		SELECT meal_id, dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE dish_id = $1
+	`
+	var dish menu.Dish
+	var recipeJson string
+	var nutritionJson string
+
+	err := s.db.QueryRowContext(ctx, query, dishID).Scan(
+		&dish.MealID,
+		&dish.DishID,
+		&dish.Name,
+		&recipeJson,
+		&nutritionJson,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadDish", dishID)
+	}
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadDish", dishID)
+	}
+
+	if err := json.Unmarshal([]byte(recipeJson), &dish.Recipe); err != nil {
+		return nil, oops.NewDBError(err, "LoadDish.JsonUnmarshal", dishID)
+	}
+	if err := json.Unmarshal([]byte(nutritionJson), &dish.TotalNutrition); err != nil {
+		return nil, oops.NewDBError(err, "LoadDish.JsonUnmarshal", dishID)
+	}
+	return &dish, nil
+}
+
+// UpdateDish обновляет блюдо
+func (s *Storage) UpdateDish(ctx context.Context, dish menu.Dish) error {
+	recipeJson, nutritionJson, err := marshalDish(dish)
+	if err != nil {
+		return oops.NewDBError(err, "UpdateDish.JsonMarshal", dish.DishID)
+	}
+
+	query := `
+		UPDATE dishes SET meal_id = $1, name = $2, recipie = $3, total_nutrition = $4
+		WHERE dish_id = $5
+	`
+	_, err = s.db.ExecContext(ctx, query, dish.MealID, dish.Name, recipeJson, nutritionJson, dish.DishID)
+	if err != nil {
+		return oops.NewDBError(err, "UpdateDish", dish.DishID)
+	}
+	return nil
+}
+
+// DeleteDish удаляет блюдо
+func (s *Storage) DeleteDish(ctx context.Context, dishID string) error {
+	query := `
+		DELETE FROM dishes
+		WHERE dish_id = $1
+	`
+	res, err := s.db.ExecContext(ctx, query, dishID)
+	if err != nil {
+		return oops.NewDBError(err, "DeleteDish", dishID)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return oops.NewDBError(err, "DeleteDish.RowsAffected", dishID)
+	}
+	if affected == 0 {
+		return oops.NewDBError(oops.ErrNoData, "DeleteDish", dishID)
+	}
+	return nil
+}
+
+// marshalDish сериализует рецепт и пищевую ценность блюда в JSON для хранения в БД
+func marshalDish(dish menu.Dish) (string, string, error) {
+	recipeJson, err := json.Marshal(dish.Recipe)
+	if err != nil {
+		return "", "", err
+	}
+	nutritionJson, err := json.Marshal(dish.TotalNutrition)
+	if err != nil {
+		return "", "", err
+	}
+	return string(recipeJson), string(nutritionJson), nil
+}
+
+// LoadMenuRange возвращает приемы пищи пользователя в полуинтервале [from, to), упорядоченные по времени
+func (s *Storage) LoadMenuRange(ctx context.Context, userID string, from, to time.Time) ([]menu.Menu, error) {
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = $1 AND eat_date >= $2 AND eat_date < $3
+		ORDER BY eat_date
+	`
+	rows, err := s.db.QueryContext(ctx, query, userID, from, to)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMenuRange", userID)
+	}
+	defer rows.Close()
+
+	menuList := make([]menu.Menu, 0)
+	for rows.Next() {
+		var m menu.Menu
+		if err := rows.Scan(&m.MealID, &m.Time, &m.MealType); err != nil {
+			return nil, oops.NewDBError(err, "LoadMenuRange.Scan", userID)
+		}
+		m.Time = m.Time.UTC()
+		menuList = append(menuList, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadMenuRange.Rows", userID)
+	}
+	return menuList, nil
+}
+
+// LoadDishes возвращает каталог всех блюд с типом приема пищи, к которому они относятся
+func (s *Storage) LoadDishes(ctx context.Context) ([]menu.Dish, error) {
+	query := `
+		SELECT d.meal_id, d.dish_id, d.name, d.recipie, d.total_nutrition, COALESCE(m.meal_type, ''), COALESCE(d.source_dish_id, '')
+		FROM dishes d
+		LEFT JOIN menu m ON m.meal_id = d.meal_id
+		ORDER BY d.name, d.dish_id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadDishes", "")
+	}
+	defer rows.Close()
+
+	dishes := make([]menu.Dish, 0)
+	for rows.Next() {
+		var dish menu.Dish
+		var recipeJson string
+		var nutritionJson string
+
+		err := rows.Scan(
+			&dish.MealID,
+			&dish.DishID,
+			&dish.Name,
+			&recipeJson,
+			&nutritionJson,
+			&dish.MealType,
+			&dish.SourceDishID,
+		)
+		if err != nil {
+			return nil, oops.NewDBError(err, "LoadDishes.Scan", "")
+		}
+
+		if err := json.Unmarshal([]byte(recipeJson), &dish.Recipe); err != nil {
+			return nil, oops.NewDBError(err, "LoadDishes.JsonUnmarshal", dish.DishID)
+		}
+		if err := json.Unmarshal([]byte(nutritionJson), &dish.TotalNutrition); err != nil {
+			return nil, oops.NewDBError(err, "LoadDishes.JsonUnmarshal", dish.DishID)
+		}
+		dishes = append(dishes, dish)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadDishes.Rows", "")
+	}
+	return dishes, nil
+}
+
+// ReplaceMenuRange атомарно заменяет расписание пользователя в полуинтервале [from, to)
+// новыми приемами пищи и их блюдами
+func (s *Storage) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []menu.Menu, dishes []menu.Dish) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Begin", userID)
+	}
+	defer tx.Rollback()
+
+	// копии блюд, созданные генератором для заменяемых приемов пищи, удаляются вместе с расписанием.
+	// Блюда без source_dish_id заведены пользователем и остаются в каталоге
+	dishesQuery := `
+		DELETE FROM dishes
+		WHERE source_dish_id IS NOT NULL
+		  AND meal_id IN (SELECT meal_id FROM menu WHERE user_id = $1 AND eat_date >= $2 AND eat_date < $3)
+	`
+	if _, err := tx.ExecContext(ctx, dishesQuery, userID, from, to); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.DeleteDishes", userID)
+	}
+
+	deleteQuery := "DELETE FROM menu WHERE user_id = $1 AND eat_date >= $2 AND eat_date < $3"
+	if _, err := tx.ExecContext(ctx, deleteQuery, userID, from, to); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Delete", userID)
+	}
+
+	menuQuery := "INSERT INTO menu (meal_id, meal_type, eat_date, user_id) VALUES ($1, $2, $3, $4)"
+	for _, m := range entries {
+		if _, err := tx.ExecContext(ctx, menuQuery, m.MealID, m.MealType, m.Time, userID); err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.InsertMenu", m.MealID)
+		}
+	}
+
+	dishQuery := "INSERT INTO dishes (meal_id, dish_id, name, recipie, total_nutrition, source_dish_id) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))"
+	for _, dish := range dishes {
+		recipeJson, nutritionJson, err := marshalDish(dish)
+		if err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.JsonMarshal", dish.DishID)
+		}
+		if _, err := tx.ExecContext(ctx, dishQuery, dish.MealID, dish.DishID, dish.Name, recipeJson, nutritionJson, dish.SourceDishID); err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.InsertDish", dish.DishID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Commit", userID)
+	}
+	return nil
+}
+
+// LoadStaleUsers возвращает пользователей, последний прием пищи которых запланирован раньше before
+func (s *Storage) LoadStaleUsers(ctx context.Context, before time.Time) ([]string, error) {
+	query := `
+		SELECT user_id
+		FROM menu
+		GROUP BY user_id
+		HAVING MAX(eat_date) < $1
+		ORDER BY user_id
+	`
+	rows, err := s.db.QueryContext(ctx, query, before)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadStaleUsers", "")
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, oops.NewDBError(err, "LoadStaleUsers.Scan", "")
+		}
+		users = append(users, userID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadStaleUsers.Rows", "")
+	}
+	return users, nil
+}
+
+// AcquireLock захватывает блокировку name для owner на ttl. Истекшая блокировка перехватывается,
+// время берется из БД, чтобы расхождение часов экземпляров сервиса не влияло на результат
+func (s *Storage) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	// строка обновляется, только если блокировку держит тот же владелец или она истекла
+	query := `
+		INSERT INTO scheduler_locks (name, owner, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (name) DO UPDATE SET
+			owner = EXCLUDED.owner,
+			expires_at = EXCLUDED.expires_at
+		WHERE scheduler_locks.owner = EXCLUDED.owner OR scheduler_locks.expires_at < now()
+	`
+	res, err := s.db.ExecContext(ctx, query, name, owner, ttl.Seconds())
+	if err != nil {
+		return false, oops.NewDBError(err, "AcquireLock", name)
+	}
+
+	// 1 — блокировка создана, перехвачена или продлена, 0 — ее держит другой владелец
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, oops.NewDBError(err, "AcquireLock.RowsAffected", name)
+	}
+	return affected > 0, nil
+}
+
+// ReleaseLock освобождает блокировку, если ее держит owner
+func (s *Storage) ReleaseLock(ctx context.Context, name, owner string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM scheduler_locks WHERE name = $1 AND owner = $2", name, owner)
+	if err != nil {
+		return oops.NewDBError(err, "ReleaseLock", name)
+	}
+	return nil
+}
+
+// isDuplicate проверяет, что запрос нарушил первичный или уникальный ключ
+func isDuplicate(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == errUniqueViolation
+}
@@ -0,0 +1,541 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/postgres"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadMenu_Success(t *testing.T) {
+	// Создаем мок для sql.DB
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	// Оборачиваем *sql.DB в *sqlx.DB
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"meal_id", "eat_date", "meal_type"}).
+		AddRow("meal1", time.Now(), "lunch").
+		AddRow("meal2", time.Now().Add(1*time.Hour), "dinner")
+
+	mock.ExpectQuery(`SELECT meal_id, eat_date, meal_type FROM menu WHERE user_id = \$1`).
+		WithArgs("123").
+		WillReturnRows(mockRows)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	menus, err := storage.LoadMenu(context.Background(), "123")
+	assert.NoError(t, err)
+	assert.Len(t, menus, 2)
+	assert.Equal(t, "meal1", menus[0].MealID)
+	assert.Equal(t, "lunch", menus[0].MealType)
+}
+
+func TestLoadMenu_QueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery(`SELECT meal_id, eat_date, meal_type FROM menu WHERE user_id = \$1`).
+		WithArgs("123").
+		WillReturnError(sql.ErrConnDone)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	_, err = storage.LoadMenu(context.Background(), "123")
+	assert.Error(t, err)
+}
+
+func TestLoadMeal_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	nutrition := common.NutritionalValueAbsolute{
+		Proteins:      10,
+		Fats:          5,
+		Carbohydrates: 20,
+		Calories:      200,
+	}
+	nutritionJSON, _ := json.Marshal(nutrition)
+
+	recipe := menu.Recipe{
+		Ingredients: []menu.Ingredient{{ProductID: "макароны", Amount: 100, Unit: "г"}},
+		Steps:       []string{"Сварить макароны"},
+	}
+	recipeJSON, _ := json.Marshal(recipe)
+
+	mockRows := sqlmock.NewRows([]string{"dish_id", "name", "recipe", "total_nutrition"}).
+		AddRow("dish1", "Pasta", recipeJSON, nutritionJSON).
+		AddRow("dish2", "Salad", recipeJSON, nutritionJSON)
+
+	mock.ExpectQuery(`SELECT dish_id, name, recipie, total_nutrition FROM dishes WHERE meal_id = \$1`).
+		WithArgs("meal1").
+		WillReturnRows(mockRows)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	meal, err := storage.LoadMeal(context.Background(), "meal1")
+	assert.NoError(t, err)
+	assert.Equal(t, "meal1", meal.MealID)
+	assert.Len(t, meal.DishIDs, 2)
+	assert.Equal(t, []menu.Recipe{recipe, recipe}, meal.Recipes)
+	assert.Equal(t, nutrition.AddAbsoluteValue(nutrition), meal.TotalNutrition)
+}
+
+func TestLoadMeal_InvalidRecipe(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"dish_id", "name", "recipe", "total_nutrition"}).
+		AddRow("dish1", "Pasta", "not-json", `{"calories": 100}`)
+
+	mock.ExpectQuery(`SELECT dish_id, name, recipie, total_nutrition FROM dishes WHERE meal_id = \$1`).
+		WithArgs("meal1").
+		WillReturnRows(mockRows)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	_, err = storage.LoadMeal(context.Background(), "meal1")
+
+	var dbErr *oops.DBError
+	assert.ErrorAs(t, err, &dbErr)
+	assert.Equal(t, "LoadMeal.JsonUnmarshal", dbErr.Op)
+}
+
+func TestLoadMeal_QueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery(`SELECT dish_id, name, recipie, total_nutrition FROM dishes WHERE meal_id = \$1`).
+		WithArgs("meal1").
+		WillReturnError(sql.ErrConnDone)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	_, err = storage.LoadMeal(context.Background(), "meal1")
+	assert.Error(t, err)
+}
+
+func TestUpdateMenu_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE menu SET eat_date = \$1 WHERE user_id = \$2 AND meal_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), "123", "meal1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	menus := []menu.Menu{
+		{MealID: "meal1", Time: time.Now()},
+	}
+
+	err = storage.UpdateMenu(context.Background(), "123", menus)
+	assert.NoError(t, err)
+}
+
+func TestUpdateMenu_QueryError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE menu SET eat_date = \$1 WHERE user_id = \$2 AND meal_id = \$3`).
+		WithArgs(sqlmock.AnyArg(), "123", "meal1").
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	menus := []menu.Menu{
+		{MealID: "meal1", Time: time.Now()},
+	}
+
+	err = storage.UpdateMenu(context.Background(), "123", menus)
+	assert.Error(t, err)
+}
+
+func TestSaveMenuEntry_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	eatDate := time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`INSERT INTO menu \(meal_id, meal_type, eat_date, user_id\) VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs("meal1", "breakfast", eatDate, "123").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.SaveMenuEntry(context.Background(), "123", menu.Menu{MealID: "meal1", Time: eatDate, MealType: "breakfast"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSaveMenuEntry_Duplicate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`INSERT INTO menu`).
+		WillReturnError(&pgconn.PgError{Code: "23505", Message: "duplicate key value violates unique constraint"})
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.SaveMenuEntry(context.Background(), "123", menu.Menu{MealID: "meal1", Time: time.Now(), MealType: "lunch"})
+	assert.ErrorIs(t, err, oops.ErrDuplicateKey)
+}
+
+func TestLoadMenuEntry_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery(`SELECT meal_id, eat_date, meal_type FROM menu WHERE user_id = \$1 AND meal_id = \$2`).
+		WithArgs("123", "meal1").
+		WillReturnRows(sqlmock.NewRows([]string{"meal_id", "eat_date", "meal_type"}))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	_, err = storage.LoadMenuEntry(context.Background(), "123", "meal1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestUpdateMenuEntry_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`UPDATE menu SET meal_type = \$1, eat_date = \$2 WHERE user_id = \$3 AND meal_id = \$4`).
+		WithArgs("dinner", sqlmock.AnyArg(), "123", "meal1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.UpdateMenuEntry(context.Background(), "123", menu.Menu{MealID: "meal1", Time: time.Now(), MealType: "dinner"})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteMenuEntry_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`DELETE FROM menu WHERE user_id = \$1 AND meal_id = \$2`).
+		WithArgs("123", "meal1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.DeleteMenuEntry(context.Background(), "123", "meal1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestSaveDish_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	dish := menu.Dish{
+		DishID: "dish1",
+		MealID: "meal1",
+		Name:   "Овсяная каша",
+		Recipe: menu.Recipe{
+			Ingredients: []menu.Ingredient{{ProductID: "овсяные_хлопья", Amount: 100, Unit: "г"}},
+			Steps:       []string{"Добавить хлопья"},
+		},
+		TotalNutrition: common.NutritionalValueAbsolute{Calories: 350},
+	}
+	recipeJSON, _ := json.Marshal(dish.Recipe)
+	nutritionJSON, _ := json.Marshal(dish.TotalNutrition)
+
+	mock.ExpectExec(`INSERT INTO dishes \(meal_id, dish_id, name, recipie, total_nutrition\) VALUES \(\$1, \$2, \$3, \$4, \$5\)`).
+		WithArgs("meal1", "dish1", "Овсяная каша", string(recipeJSON), string(nutritionJSON)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.SaveDish(context.Background(), dish)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadDish_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"meal_id", "dish_id", "name", "recipie", "total_nutrition"}).
+		AddRow("1", "1", "Овсяная каша",
+			`{"ingredients": [{"unit": "г", "amount": 100, "product_id": "овсяные_хлопья"}], "steps": ["Вскипятить молоко"]}`,
+			`{"fats": 7, "calories": 350, "proteins": 12, "carbohydrates": 55}`)
+
+	mock.ExpectQuery(`SELECT meal_id, dish_id, name, recipie, total_nutrition FROM dishes WHERE dish_id = \$1`).
+		WithArgs("1").
+		WillReturnRows(mockRows)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	dish, err := storage.LoadDish(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Овсяная каша", dish.Name)
+	assert.Equal(t, []menu.Ingredient{{ProductID: "овсяные_хлопья", Amount: 100, Unit: "г"}}, dish.Recipe.Ingredients)
+	assert.Equal(t, uint(350), dish.TotalNutrition.Calories)
+}
+
+func TestDeleteDish_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`DELETE FROM dishes WHERE dish_id = \$1`).
+		WithArgs("dish1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.DeleteDish(context.Background(), "dish1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestLoadMenuRange_Empty(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	mock.ExpectQuery(`SELECT meal_id, eat_date, meal_type FROM menu WHERE user_id = \$1 AND eat_date >= \$2 AND eat_date < \$3 ORDER BY eat_date`).
+		WithArgs("123", from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"meal_id", "eat_date", "meal_type"}))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	menus, err := storage.LoadMenuRange(context.Background(), "123", from, to)
+	assert.NoError(t, err)
+	assert.NotNil(t, menus)
+	assert.Empty(t, menus)
+}
+
+func TestLoadDishes_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"meal_id", "dish_id", "name", "recipie", "total_nutrition", "meal_type", "source_dish_id"}).
+		AddRow("2", "2", "Куриный суп", `{"ingredients": [{"product_id": "куриное_филе", "amount": 200, "unit": "г"}], "steps": []}`, `{"calories": 450}`, "lunch", "7").
+		AddRow("1", "1", "Овсяная каша", `{"ingredients": [{"product_id": "молоко", "amount": 200, "unit": "мл"}], "steps": []}`, `{"calories": 350}`, "", "")
+
+	mock.ExpectQuery(`SELECT d\.meal_id, d\.dish_id, d\.name, d\.recipie, d\.total_nutrition, COALESCE\(m\.meal_type, ''\), COALESCE\(d\.source_dish_id, ''\) FROM dishes d LEFT JOIN menu m ON m\.meal_id = d\.meal_id ORDER BY d\.name, d\.dish_id`).
+		WillReturnRows(mockRows)
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	dishes, err := storage.LoadDishes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, dishes, 2)
+	assert.Equal(t, "куриное_филе", dishes[0].Recipe.Ingredients[0].ProductID)
+	assert.Equal(t, uint(350), dishes[1].TotalNutrition.Calories)
+	assert.Equal(t, "lunch", dishes[0].MealType)
+	assert.Empty(t, dishes[1].MealType)
+	assert.Equal(t, "7", dishes[0].SourceDishID)
+	assert.Empty(t, dishes[1].SourceDishID)
+}
+
+func TestReplaceMenuRange_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	entries := []menu.Menu{{MealID: "meal1", Time: from.Add(8 * time.Hour), MealType: "breakfast"}}
+	dishes := []menu.Dish{{DishID: "dish1", MealID: "meal1", Name: "Омлет", SourceDishID: "5"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM dishes WHERE source_dish_id IS NOT NULL AND meal_id IN \(SELECT meal_id FROM menu WHERE user_id = \$1 AND eat_date >= \$2 AND eat_date < \$3\)`).
+		WithArgs("123", from, to).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM menu WHERE user_id = \$1 AND eat_date >= \$2 AND eat_date < \$3`).
+		WithArgs("123", from, to).
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`INSERT INTO menu`).
+		WithArgs("meal1", "breakfast", entries[0].Time, "123").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO dishes`).
+		WithArgs("meal1", "dish1", "Омлет", sqlmock.AnyArg(), sqlmock.AnyArg(), "5").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.ReplaceMenuRange(context.Background(), "123", from, to, entries, dishes)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReplaceMenuRange_RollbackOnError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	entries := []menu.Menu{{MealID: "meal1", Time: from, MealType: "breakfast"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM dishes`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM menu`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO menu`).WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	err = storage.ReplaceMenuRange(context.Background(), "123", from, from.AddDate(0, 0, 1), entries, nil)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadStaleUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	before := time.Date(2024, 3, 24, 19, 30, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT user_id FROM menu GROUP BY user_id HAVING MAX\(eat_date\) < \$1`).
+		WithArgs(before).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("dan").AddRow("kolya"))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	users, err := storage.LoadStaleUsers(context.Background(), before)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dan", "kolya"}, users)
+}
+
+func TestAcquireLock(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		acquired bool
+	}{
+		{"new or expired lock", 1, true},
+		{"held by another owner", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+			mock.ExpectExec(`INSERT INTO scheduler_locks \(name, owner, expires_at\) .* ON CONFLICT \(name\) DO UPDATE`).
+				WithArgs("reschedule", "host-1", float64(600)).
+				WillReturnResult(sqlmock.NewResult(0, tt.affected))
+
+			storage := postgres.NewStorage(sqlxDB)
+
+			acquired, err := storage.AcquireLock(context.Background(), "reschedule", "host-1", 10*time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.acquired, acquired)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestReleaseLock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`DELETE FROM scheduler_locks WHERE name = \$1 AND owner = \$2`).
+		WithArgs("reschedule", "host-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	assert.NoError(t, storage.ReleaseLock(context.Background(), "reschedule", "host-1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadMenuEntry_ReturnsUTC(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	// pgx отдает timestamptz в локальном часовом поясе процесса
+	moscow := time.FixedZone("MSK", 3*60*60)
+	mock.ExpectQuery(`SELECT meal_id, eat_date, meal_type FROM menu WHERE user_id = \$1 AND meal_id = \$2`).
+		WithArgs("123", "meal1").
+		WillReturnRows(sqlmock.NewRows([]string{"meal_id", "eat_date", "meal_type"}).
+			AddRow("meal1", time.Date(2024, 3, 20, 11, 0, 0, 0, moscow), "breakfast"))
+
+	storage := postgres.NewStorage(sqlxDB)
+
+	entry, err := storage.LoadMenuEntry(context.Background(), "123", "meal1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), entry.Time)
+}
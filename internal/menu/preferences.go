@@ -0,0 +1,218 @@
+package menu
+
+import (
+	"context"
+	"menu_manager/internal/logging"
+	"sort"
+	"time"
+)
+
+// forbiddenMeals возвращает id приемов пищи, рецепты которых нарушают профиль пользователя.
+// Продукты всех приемов пищи проверяются одним запросом
+func (s *AppService) forbiddenMeals(ctx context.Context, userID string, meals map[string]*Meal) (map[string]bool, error) {
+	if s.profiles == nil || len(meals) == 0 {
+		return nil, nil
+	}
+
+	recipes := make([]Recipe, 0, len(meals))
+	for _, meal := range meals {
+		recipes = append(recipes, meal.Recipes...)
+	}
+	products := recipeProducts(recipes)
+	if len(products) == 0 {
+		return nil, nil
+	}
+	sort.Strings(products)
+
+	forbidden, err := s.profiles.Violations(ctx, userID, products)
+	if err != nil || len(forbidden) == 0 {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(forbidden))
+	for _, productID := range forbidden {
+		excluded[productID] = true
+	}
+
+	result := make(map[string]bool)
+	for mealID, meal := range meals {
+		for _, productID := range recipeProducts(meal.Recipes) {
+			if excluded[productID] {
+				result[mealID] = true
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// permittedEntries оставляет в расписании только приемы пищи, разрешенные профилем пользователя.
+// Блюда всех приемов пищи загружаются одним запросом
+func (s *AppService) permittedEntries(ctx context.Context, userID string, menu []Menu) ([]Menu, error) {
+	if s.profiles == nil || len(menu) == 0 {
+		return menu, nil
+	}
+
+	_, forbidden, err := s.entryMeals(ctx, userID, menu)
+	if err != nil {
+		return nil, err
+	}
+	return withoutForbidden(menu, forbidden), nil
+}
+
+// permittedMeals возвращает разрешенные профилем пользователя записи расписания в исходном порядке
+// вместе с их приемами пищи. Приемы пищи загружаются одним запросом
+func (s *AppService) permittedMeals(ctx context.Context, userID string, menu []Menu) ([]Menu, map[string]*Meal, error) {
+	meals, forbidden, err := s.entryMeals(ctx, userID, menu)
+	if err != nil {
+		return nil, nil, err
+	}
+	return withoutForbidden(menu, forbidden), meals, nil
+}
+
+// dropForbidden удаляет из расписания приемы пищи, нарушающие профиль пользователя, и возвращает остальные
+func (s *AppService) dropForbidden(ctx context.Context, userID string, menu []Menu) ([]Menu, error) {
+	if s.profiles == nil || len(menu) == 0 {
+		return menu, nil
+	}
+
+	_, forbidden, err := s.entryMeals(ctx, userID, menu)
+	if err != nil || len(forbidden) == 0 {
+		return menu, err
+	}
+
+	for _, entry := range menu {
+		if !forbidden[entry.MealID] {
+			continue
+		}
+		if err := s.storage.DeleteMenuEntry(ctx, userID, entry.MealID); err != nil {
+			return nil, err
+		}
+		s.logger.InfoContext(ctx, "прием пищи нарушает профиль и удален из расписания", logging.UserIDKey, userID, "meal_id", entry.MealID)
+	}
+	return withoutForbidden(menu, forbidden), nil
+}
+
+// entryMeals загружает приемы пищи расписания одним запросом и отмечает те из них,
+// рецепты которых нарушают профиль пользователя
+func (s *AppService) entryMeals(ctx context.Context, userID string, menu []Menu) (map[string]*Meal, map[string]bool, error) {
+	if len(menu) == 0 {
+		return nil, nil, nil
+	}
+
+	mealIDs := make([]string, 0, len(menu))
+	for _, entry := range menu {
+		mealIDs = append(mealIDs, entry.MealID)
+	}
+	meals, err := s.storage.LoadMeals(ctx, mealIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	forbidden, err := s.forbiddenMeals(ctx, userID, meals)
+	if err != nil {
+		return nil, nil, err
+	}
+	return meals, forbidden, nil
+}
+
+// withoutForbidden возвращает записи расписания, кроме отмеченных в forbidden
+func withoutForbidden(menu []Menu, forbidden map[string]bool) []Menu {
+	if len(forbidden) == 0 {
+		return menu
+	}
+	permitted := make([]Menu, 0, len(menu))
+	for _, entry := range menu {
+		if !forbidden[entry.MealID] {
+			permitted = append(permitted, entry)
+		}
+	}
+	return permitted
+}
+
+// permittedDishes оставляет в каталоге только блюда, разрешенные профилем пользователя
+func (s *AppService) permittedDishes(ctx context.Context, userID string, dishes []Dish) ([]Dish, error) {
+	if s.profiles == nil {
+		return dishes, nil
+	}
+
+	recipes := make([]Recipe, 0, len(dishes))
+	for _, dish := range dishes {
+		recipes = append(recipes, dish.Recipe)
+	}
+
+	// одним запросом проверяем все продукты каталога
+	forbidden, err := s.profiles.Violations(ctx, userID, recipeProducts(recipes))
+	if err != nil {
+		return nil, err
+	}
+	if len(forbidden) == 0 {
+		return dishes, nil
+	}
+
+	excluded := make(map[string]bool, len(forbidden))
+	for _, productID := range forbidden {
+		excluded[productID] = true
+	}
+
+	permitted := make([]Dish, 0, len(dishes))
+	for _, dish := range dishes {
+		allowed := true
+		for _, ingredient := range dish.Recipe.Ingredients {
+			if excluded[ingredient.ProductID] {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			permitted = append(permitted, dish)
+		}
+	}
+	return permitted, nil
+}
+
+// recipeProducts возвращает уникальные product_id всех ингредиентов рецептов
+func recipeProducts(recipes []Recipe) []string {
+	seen := make(map[string]bool)
+	products := make([]string, 0)
+	for _, recipe := range recipes {
+		for _, ingredient := range recipe.Ingredients {
+			if seen[ingredient.ProductID] {
+				continue
+			}
+			seen[ingredient.ProductID] = true
+			products = append(products, ingredient.ProductID)
+		}
+	}
+	return products
+}
+
+// location возвращает часовой пояс пользователя. Без подключенных профилей используется UTC
+func (s *AppService) location(ctx context.Context, userID string) (*time.Location, error) {
+	if s.profiles == nil {
+		return time.UTC, nil
+	}
+	return s.profiles.Location(ctx, userID)
+}
+
+// userNow возвращает текущее время в часовом поясе пользователя
+func (s *AppService) userNow(ctx context.Context, userID string) (time.Time, error) {
+	loc, err := s.location(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return s.now().In(loc), nil
+}
+
+// strategy возвращает стратегию переноса меню, выбранную пользователем.
+// Без подключенных профилей используется стратегия по умолчанию
+func (s *AppService) strategy(ctx context.Context, userID string) (RescheduleStrategy, error) {
+	if s.profiles == nil {
+		return NewRescheduleStrategy(DefaultStrategy, 0)
+	}
+
+	name, rotationWeeks, err := s.profiles.RescheduleStrategy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return NewRescheduleStrategy(name, rotationWeeks)
+}
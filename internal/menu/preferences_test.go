@@ -0,0 +1,176 @@
+package menu_test
+
+import (
+	"context"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMeal_SkipsForbiddenMeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	mockProfiles.EXPECT().Location(gomock.Any(), "dan").Return(time.UTC, nil).AnyTimes()
+	service := menu.NewService(mockStore, mockClient, menu.WithProfiles(mockProfiles))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "meal1", Time: time.Now().Add(1 * time.Hour), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Now().Add(2 * time.Hour), MealType: "dinner"},
+	}
+	chicken := &menu.Meal{MealID: "meal1", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "куриное_филе", Amount: 200, Unit: "г"}}}}}
+	salad := &menu.Meal{MealID: "meal2", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "огурец", Amount: 200, Unit: "г"}}}}}
+	shoppingList := &menu.ShoppingList{Items: []menu.ShoppingItem{}}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	// приемы пищи загружаются и проверяются по профилю одним запросом
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1", "meal2"}).Return(map[string]*menu.Meal{"meal1": chicken, "meal2": salad}, nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"куриное_филе", "огурец"}).Return([]string{"куриное_филе"}, nil)
+	mockClient.EXPECT().GetProducts(ctx, salad.Recipes).Return(shoppingList, nil)
+
+	details, err := service.GetMeal(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, salad, details.Meal)
+	assert.Equal(t, shoppingList, details.ShoppingList)
+}
+
+func TestRescheduleMenu_DropsForbiddenMeals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	mockProfiles.EXPECT().Location(gomock.Any(), "dan").Return(time.UTC, nil).AnyTimes()
+	clock := func() time.Time { return time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles), menu.WithClock(clock))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "meal1", Time: time.Date(2024, 3, 13, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Date(2024, 3, 13, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	chicken := &menu.Meal{MealID: "meal1", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "куриное_филе", Amount: 200, Unit: "г"}}}}}
+	salad := &menu.Meal{MealID: "meal2", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "огурец", Amount: 200, Unit: "г"}}}}}
+	expected := []menu.Menu{
+		{MealID: "meal2", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+
+	// запрещенный прием пищи не получает новую дату, а удаляется из расписания
+	mockProfiles.EXPECT().RescheduleStrategy(ctx, "dan").Return(menu.StrategyKeepSlot, 1, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1", "meal2"}).Return(map[string]*menu.Meal{"meal1": chicken, "meal2": salad}, nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"куриное_филе", "огурец"}).Return([]string{"куриное_филе"}, nil)
+	mockStore.EXPECT().DeleteMenuEntry(ctx, "dan", "meal1").Return(nil)
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", expected).Return(nil)
+
+	updated, err := service.RescheduleMenu(ctx, menuData, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, updated)
+}
+
+func TestGetMenu_SkipsForbiddenEntries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "meal1", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	meals := map[string]*menu.Meal{
+		"meal1": {MealID: "meal1", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "бекон"}}}}},
+		"meal2": {MealID: "meal2", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "огурец"}}}}},
+	}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1", "meal2"}).Return(meals, nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"бекон", "огурец"}).Return([]string{"бекон"}, nil)
+
+	entries, err := service.GetMenu(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, menuData[1:], entries)
+
+	// отдельный прием пищи, нарушающий профиль, тоже не отдается
+	mockStore.EXPECT().LoadMenuEntry(ctx, "dan", "meal1").Return(&menuData[0], nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1"}).Return(map[string]*menu.Meal{"meal1": meals["meal1"]}, nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"бекон"}).Return([]string{"бекон"}, nil)
+	_, err = service.GetMenuEntry(ctx, "dan", "meal1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+
+	mockStore.EXPECT().LoadMenuEntry(ctx, "dan", "meal2").Return(&menuData[1], nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal2"}).Return(map[string]*menu.Meal{"meal2": meals["meal2"]}, nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"огурец"}).Return(nil, nil)
+	entry, err := service.GetMenuEntry(ctx, "dan", "meal2")
+	assert.NoError(t, err)
+	assert.Equal(t, &menuData[1], entry)
+}
+
+func TestGetMenuView_SkipsForbiddenMeals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	mockProfiles.EXPECT().Location(gomock.Any(), "dan").Return(time.UTC, nil).AnyTimes()
+	service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles))
+
+	ctx := context.Background()
+	from := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	entries := []menu.Menu{
+		{MealID: "meal1", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	chicken := &menu.Meal{MealID: "meal1", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "куриное_филе", Amount: 200, Unit: "г"}}}}}
+	salad := &menu.Meal{MealID: "meal2", Recipes: []menu.Recipe{{Ingredients: []menu.Ingredient{{ProductID: "огурец", Amount: 200, Unit: "г"}}}}}
+
+	mockStore.EXPECT().LoadMenuRange(ctx, "dan", from, from.AddDate(0, 0, 1)).Return(entries, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1", "meal2"}).Return(map[string]*menu.Meal{"meal1": chicken, "meal2": salad}, nil)
+	// все продукты периода проверяются одним запросом
+	mockProfiles.EXPECT().Violations(ctx, "dan", []string{"куриное_филе", "огурец"}).Return([]string{"куриное_филе"}, nil)
+
+	view, err := service.GetMenuView(ctx, "dan", from, from)
+	assert.NoError(t, err)
+	assert.Len(t, view.Days, 1)
+	assert.Len(t, view.Days[0].Meals, 1)
+	assert.Equal(t, "meal2", view.Days[0].Meals[0].MealID)
+}
+
+func TestGenerateMenu_ExcludesForbiddenDishes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	mockProfiles.EXPECT().Location(gomock.Any(), "dan").Return(time.UTC, nil).AnyTimes()
+	service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles))
+
+	ctx := context.Background()
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+
+	mockStore.EXPECT().LoadDishes(ctx).Return(testCatalog(), nil)
+	mockProfiles.EXPECT().Violations(ctx, "dan", gomock.Any()).Return([]string{"Куриный суп", "Рыба с овощами"}, nil)
+	mockStore.EXPECT().ReplaceMenuRange(ctx, "dan", gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, _, _ time.Time, _ []menu.Menu, dishes []menu.Dish) error {
+			for _, dish := range dishes {
+				assert.NotEqual(t, "Куриный суп", dish.Name)
+				assert.NotEqual(t, "Рыба с овощами", dish.Name)
+			}
+			return nil
+		})
+	mockStore.EXPECT().LoadMenuRange(ctx, "dan", gomock.Any(), gomock.Any()).Return([]menu.Menu{}, nil)
+
+	_, err := service.GenerateMenu(ctx, "dan", menu.GenerateRequest{From: from, To: from, Targets: menu.NutritionTargets{Calories: 2000}})
+	assert.NoError(t, err)
+}
@@ -2,100 +2,177 @@ package menu
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
 	"math/rand"
+	"menu_manager/internal/logging"
 	"menu_manager/internal/oops"
+	"sync"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
 )
 
 // AppService реализует бизнес-логику работы с меню
 type AppService struct {
-	storage Store
-	client  Client
+	storage  Store
+	client   Client
+	profiles ProfileProvider
+	now      func() time.Time
+	grace    time.Duration
+	logger   *slog.Logger
+
+	// lookahead за сколько до окончания предстоящих приемов пищи RescheduleStale переносит меню
+	lookahead time.Duration
+
+	rngMu sync.Mutex // rand.Rand не безопасен для конкурентного использования
+	rng   *rand.Rand
+
+	reschedules singleflight.Group // переносы меню, ключ — userID
+
+	// shoppingLists последние полученные списки покупок, nil если запасной вариант отключен
+	shoppingLists *shoppingListCache
+
+	// rescheduleCount счетчик переносов меню, nil если метрики не включены
+	rescheduleCount *prometheus.CounterVec
 }
 
-// NewService создает новый экземпляр сервиса
-func NewService(storage Store, client Client) Service {
-	return &AppService{
-		storage: storage,
-		client:  client,
+// Option настраивает необязательные зависимости сервиса
+type Option func(*AppService)
+
+// WithProfiles включает проверку приемов пищи по профилю пользователя
+func WithProfiles(profiles ProfileProvider) Option {
+	return func(s *AppService) {
+		s.profiles = profiles
 	}
 }
 
-func (s *AppService) GetMeal(ctx context.Context, userID string) (*Meal, string, error) {
+// WithClock подменяет источник текущего времени, используется в тестах
+func WithClock(now func() time.Time) Option {
+	return func(s *AppService) {
+		s.now = now
+	}
+}
 
-	// получаем меню
-	menu, err := s.GetMenu(ctx, userID)
-	if err != nil {
-		return nil, "", err // can't get menu
+// WithLogger задает журнал сервиса, по умолчанию используется slog.Default
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *AppService) {
+		s.logger = logger
 	}
+}
 
-	// проверка на актуальность меню
-	if !IsActual(menu) {
-		// если устарело, то обновляем меню
-		menu, err = s.RescheduleMenu(ctx, menu, userID)
-		if err != nil {
-			return nil, "", err // can't get menu
-		}
+// WithGracePeriod задает, сколько времени после начала прием пищи еще считается текущим
+func WithGracePeriod(grace time.Duration) Option {
+	return func(s *AppService) {
+		s.grace = grace
 	}
+}
+
+// WithRescheduleLookahead задает, за сколько до окончания предстоящих приемов пищи фоновый перенос
+// RescheduleStale переносит меню вперед. По умолчанию меню переносятся, только когда уже устарели
+func WithRescheduleLookahead(lookahead time.Duration) Option {
+	return func(s *AppService) {
+		s.lookahead = lookahead
+	}
+}
+
+// WithRand задает генератор случайных чисел для стратегий переноса меню.
+// Генератор с фиксированным seed делает перенос воспроизводимым
+func WithRand(rng *rand.Rand) Option {
+	return func(s *AppService) {
+		s.rng = rng
+	}
+}
 
-	// выбираем прием пищи ближайщий по времени
-	mealID, err := FindClosestMeal(menu)
+// WithShoppingListFallback включает запасной вариант для GetMeal: если barn manager недоступен,
+// возвращается последний успешно полученный список покупок пользователя для того же приема пищи,
+// если он получен не раньше чем maxAge назад
+func WithShoppingListFallback(maxAge time.Duration) Option {
+	return func(s *AppService) {
+		s.shoppingLists = newShoppingListCache(maxAge)
+	}
+}
+
+// NewService создает новый экземпляр сервиса
+func NewService(storage Store, client Client, opts ...Option) Service {
+	s := &AppService{
+		storage: storage,
+		client:  client,
+		now:     time.Now,
+		grace:   DefaultGracePeriod,
+		logger:  slog.Default(),
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// GetMeal возвращает ближайший предстоящий прием пищи, разрешенный профилем пользователя,
+// и список продуктов, которые нужно для него докупить
+func (s *AppService) GetMeal(ctx context.Context, userID string) (*MealDetails, error) {
+
+	// получаем предстоящие приемы пищи, при необходимости перенося устаревшее меню
+	upcoming, err := s.upcoming(ctx, userID)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	// получаем прием пищи
-	meal, err := s.storage.LoadMeal(ctx, mealID)
+	// выбираем ближайший прием пищи, пропуская нарушающие профиль пользователя
+	permitted, meals, err := s.permittedMeals(ctx, userID, upcoming)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if len(permitted) == 0 {
+		return nil, oops.ErrNoUpcomingMeal
 	}
+	meal := meals[permitted[0].MealID]
 
 	// запрос продуктов в barn manager
+	details := &MealDetails{Meal: meal}
 	products, err := s.GetProducts(ctx, meal.Recipes)
-	if err != nil {
-		return nil, "", err
-	}
+	switch {
+	case err == nil:
+		details.ShoppingList = products
+		s.shoppingLists.put(userID, meal.MealID, products, s.now())
 
-	return meal, products, nil
-}
+	case ctx.Err() != nil:
+		// клиент отменил запрос, отвечать уже некому
+		return nil, err
 
-// isActual проверяет наличие блюд на сегодняшний день в меню
-func IsActual(menu []Menu) bool {
-	// Получаем текущую дату
-	now := time.Now()
-	for _, v := range menu {
-		// Сравниваем год, месяц и день
-		if v.Time.Year() == now.Year() && v.Time.Month() == now.Month() && v.Time.Day() == now.Day() {
-			return true
+	default:
+		// прием пищи важнее списка покупок, поэтому ошибка barn manager не прерывает запрос
+		s.logger.WarnContext(ctx, "не удалось получить список покупок, прием пищи возвращается без него",
+			"meal_id", meal.MealID, logging.UserIDKey, userID, logging.ErrorKey, err)
+		details.Partial = true
+		details.Warnings = append(details.Warnings, "не удалось получить список покупок: сервис barn manager недоступен")
+
+		if cached, fetchedAt, ok := s.shoppingLists.get(userID, meal.MealID, s.now()); ok {
+			details.ShoppingList = cached
+			details.Warnings = append(details.Warnings, fmt.Sprintf(
+				"показан список покупок, полученный %s, он может быть неактуален", fetchedAt.UTC().Format(time.RFC3339)))
 		}
 	}
-	return false
-}
 
-// findClosestMeal возвращает id ближайшего приема пищи
-func FindClosestMeal(menu []Menu) (string, error) {
+	return details, nil
+}
 
-	// Получаем текущую дату
-	now := time.Now()
-	log.Println(now)
-	log.Println(menu)
-	minDist := 24
-	minID := ""
+// isActual проверяет наличие блюд на сегодняшний день в меню.
+// День определяется в часовом поясе now
+func IsActual(menu []Menu, now time.Time) bool {
 	for _, v := range menu {
-		// Сравниваем с точностью до часа
-		if v.Time.Year() == now.Year() && v.Time.Month() == now.Month() && v.Time.Day() == now.Day() && (v.Time.Hour()-now.Hour()) < minDist {
-			minDist = v.Time.Hour() - now.Hour()
-			minID = v.MealID
+		// Сравниваем год, месяц и день в часовом поясе пользователя
+		t := v.Time.In(now.Location())
+		if t.Year() == now.Year() && t.Month() == now.Month() && t.Day() == now.Day() {
+			return true
 		}
 	}
-	if minID != "" {
-		return minID, nil
-	}
-	return "", oops.ErrInvalidDates
+	return false
 }
 
-// GetMenu возвращает меню по ID
+// GetMenu возвращает расписание пользователя. Приемы пищи, нарушающие профиль пользователя, пропускаются
 func (s *AppService) GetMenu(ctx context.Context, userID string) ([]Menu, error) {
 
 	// получил блюдо
@@ -104,36 +181,155 @@ func (s *AppService) GetMenu(ctx context.Context, userID string) ([]Menu, error)
 	if err != nil {
 		return nil, err
 	}
-	return menu, nil
+	return s.permittedEntries(ctx, userID, menu)
 }
 
-// rescheduleMenu обновляет время и даты приемов пищи
+// RescheduleMenu переносит устаревшее меню вперед по стратегии, выбранной пользователем,
+// так чтобы в нем снова были предстоящие приемы пищи
 func (s *AppService) RescheduleMenu(ctx context.Context, currentMenu []Menu, userID string) ([]Menu, error) {
+	return s.reschedule(ctx, currentMenu, userID, 0)
+}
 
-	// Перемешиваем время приемов пищи случайным образом
-	rand.Shuffle(len(currentMenu), func(i, j int) {
-		currentMenu[i].Time, currentMenu[j].Time = currentMenu[j].Time, currentMenu[i].Time
-	})
+// reschedule переносит прошедшие приемы пищи меню по стратегии пользователя так, чтобы последний из них
+// пришелся позже чем через lookahead от текущего момента. Предстоящие приемы пищи остаются на месте,
+// поэтому перенос заранее не отнимает у пользователя оставшиеся приемы пищи текущей недели
+func (s *AppService) reschedule(ctx context.Context, currentMenu []Menu, userID string, lookahead time.Duration) ([]Menu, error) {
 
-	// Обновляем даты на неделю вперед
-	for i := range currentMenu {
-		currentMenu[i].Time = currentMenu[i].Time.Add(7 * 24 * time.Hour)
+	strategy, err := s.strategy(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.storage.UpdateMenu(ctx, userID, currentMenu); err != nil {
+	// сдвиг ведется по календарю в поясе пользователя, это сохраняет время суток
+	// при переходе на летнее и зимнее время
+	now, err := s.userNow(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	past, upcoming := splitPast(currentMenu, now, s.grace)
+
+	// приемы пищи, нарушающие профиль пользователя, не переносятся в новые даты, а удаляются из расписания:
+	// оставленные в прошлом, они снова и снова делали бы меню устаревшим
+	past, err = s.dropForbidden(ctx, userID, past)
+	if err != nil {
+		s.countReschedule(strategy.Name(), err)
 		return nil, err
 	}
-	return currentMenu, nil
+
+	rescheduled := make([]Menu, 0, len(currentMenu))
+	rescheduled = append(rescheduled, upcoming...)
+	s.rngMu.Lock()
+	rescheduled = append(rescheduled, strategy.Reschedule(past, now.Add(lookahead), s.rng)...)
+	s.rngMu.Unlock()
+
+	err = s.storage.UpdateMenu(ctx, userID, rescheduled)
+	s.countReschedule(strategy.Name(), err)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "меню перенесено", logging.UserIDKey, userID, "strategy", strategy.Name(), "entries", len(rescheduled))
+	return rescheduled, nil
 }
 
-// GetMenu возвращает меню по ID
-func (s *AppService) GetProducts(ctx context.Context, recipes []string) (string, error) {
+// GetProducts отправляет рецепты в barn manager и возвращает список продуктов, которые нужно купить
+func (s *AppService) GetProducts(ctx context.Context, recipes []Recipe) (*ShoppingList, error) {
 
 	// отсылаем рецепты в barn manager и получаем список продуктов
 	products, err := s.client.GetProducts(ctx, recipes)
 
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	return products, nil
 }
+
+// CreateMenuEntry добавляет прием пищи в расписание пользователя
+func (s *AppService) CreateMenuEntry(ctx context.Context, userID string, entry Menu) (*Menu, error) {
+	if err := ValidateMenu(entry); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.SaveMenuEntry(ctx, userID, entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetMenuEntry возвращает запланированный прием пищи пользователя.
+// Прием пищи, нарушающий профиль пользователя, считается отсутствующим
+func (s *AppService) GetMenuEntry(ctx context.Context, userID string, mealID string) (*Menu, error) {
+	entry, err := s.storage.LoadMenuEntry(ctx, userID, mealID)
+	if err != nil {
+		return nil, err
+	}
+
+	permitted, err := s.permittedEntries(ctx, userID, []Menu{*entry})
+	if err != nil {
+		return nil, err
+	}
+	if len(permitted) == 0 {
+		return nil, oops.NewDBError(oops.ErrNoData, "GetMenuEntry", mealID)
+	}
+	return entry, nil
+}
+
+// UpdateMenuEntry изменяет тип и время запланированного приема пищи
+func (s *AppService) UpdateMenuEntry(ctx context.Context, userID string, entry Menu) (*Menu, error) {
+	if err := ValidateMenu(entry); err != nil {
+		return nil, err
+	}
+
+	// проверяем, что запись существует, иначе обновлять нечего
+	if _, err := s.storage.LoadMenuEntry(ctx, userID, entry.MealID); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.UpdateMenuEntry(ctx, userID, entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+func (s *AppService) DeleteMenuEntry(ctx context.Context, userID string, mealID string) error {
+	return s.storage.DeleteMenuEntry(ctx, userID, mealID)
+}
+
+// CreateDish добавляет новое блюдо
+func (s *AppService) CreateDish(ctx context.Context, dish Dish) (*Dish, error) {
+	if err := ValidateDish(dish); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.SaveDish(ctx, dish); err != nil {
+		return nil, err
+	}
+	return &dish, nil
+}
+
+// GetDish возвращает блюдо по ID
+func (s *AppService) GetDish(ctx context.Context, dishID string) (*Dish, error) {
+	return s.storage.LoadDish(ctx, dishID)
+}
+
+// UpdateDish изменяет название, рецепт и пищевую ценность блюда
+func (s *AppService) UpdateDish(ctx context.Context, dish Dish) (*Dish, error) {
+	if err := ValidateDish(dish); err != nil {
+		return nil, err
+	}
+
+	// проверяем, что блюдо существует, иначе обновлять нечего
+	if _, err := s.storage.LoadDish(ctx, dish.DishID); err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.UpdateDish(ctx, dish); err != nil {
+		return nil, err
+	}
+	return &dish, nil
+}
+
+// DeleteDish удаляет блюдо
+func (s *AppService) DeleteDish(ctx context.Context, dishID string) error {
+	return s.storage.DeleteDish(ctx, dishID)
+}
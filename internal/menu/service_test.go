@@ -2,8 +2,10 @@ package menu_test
 
 import (
 	"context"
+	"fmt"
 	menu "menu_manager/internal/menu"
 	mocks "menu_manager/internal/menu/mock"
+	common "menu_manager/internal/models"
 	"menu_manager/internal/oops"
 	"testing"
 	"time"
@@ -26,18 +28,114 @@ func TestGetMeal(t *testing.T) {
 		{MealID: "meal1", Time: time.Now().Add(1 * time.Hour), MealType: "lunch"},
 		{MealID: "meal2", Time: time.Now().Add(2 * time.Hour), MealType: "dinner"},
 	}
-	expectedMeal := &menu.Meal{MealID: "meal1", Recipes: []string{"recipe1", "recipe2"}}
-	expectedProducts := "product1, product2"
+	expectedMeal := &menu.Meal{MealID: "meal1", Recipes: []menu.Recipe{{Steps: []string{"recipe1"}}, {Steps: []string{"recipe2"}}}}
+	expectedProducts := &menu.ShoppingList{Items: []menu.ShoppingItem{{Product: common.Product{ID: "product1"}, ToBuy: 1}}}
 
 	mockStore.EXPECT().LoadMenu(ctx, userID).Return(menuData, nil)
-	mockStore.EXPECT().LoadMeal(ctx, "meal1").Return(expectedMeal, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1", "meal2"}).
+		Return(map[string]*menu.Meal{"meal1": expectedMeal, "meal2": {MealID: "meal2"}}, nil)
 	mockClient.EXPECT().GetProducts(ctx, expectedMeal.Recipes).Return(expectedProducts, nil)
 
-	meal, products, err := service.GetMeal(ctx, userID)
+	details, err := service.GetMeal(ctx, userID)
 
 	assert.NoError(t, err)
-	assert.Equal(t, expectedMeal, meal)
-	assert.Equal(t, expectedProducts, products)
+	assert.Equal(t, expectedMeal, details.Meal)
+	assert.Equal(t, expectedProducts, details.ShoppingList)
+}
+
+func TestGetMeal_BarnUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	service := menu.NewService(mockStore, mockClient)
+
+	ctx := context.Background()
+	menuData := []menu.Menu{{MealID: "meal1", Time: time.Now().Add(time.Hour), MealType: "lunch"}}
+	expectedMeal := &menu.Meal{MealID: "meal1"}
+
+	mockStore.EXPECT().LoadMenu(ctx, "123").Return(menuData, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1"}).Return(map[string]*menu.Meal{"meal1": expectedMeal}, nil)
+	mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(nil, fmt.Errorf("%w: connection refused", oops.ErrBarnUnavailable))
+
+	// прием пищи возвращается и без списка покупок
+	details, err := service.GetMeal(ctx, "123")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedMeal, details.Meal)
+	assert.Nil(t, details.ShoppingList)
+	assert.True(t, details.Partial)
+	assert.Len(t, details.Warnings, 1)
+}
+
+func TestGetMeal_ShoppingListFallback(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	service := menu.NewService(mockStore, mockClient, menu.WithClock(clock), menu.WithShoppingListFallback(time.Hour))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{{MealID: "meal1", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"}}
+	products := &menu.ShoppingList{Items: []menu.ShoppingItem{{Product: common.Product{ID: "product1"}, ToBuy: 1}}}
+	barnDown := fmt.Errorf("%w: connection refused", oops.ErrBarnUnavailable)
+
+	mockStore.EXPECT().LoadMenu(ctx, gomock.Any()).Return(menuData, nil).AnyTimes()
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1"}).Return(map[string]*menu.Meal{"meal1": &menu.Meal{MealID: "meal1"}}, nil).AnyTimes()
+	gomock.InOrder(
+		mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(products, nil),
+		mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(nil, barnDown).Times(3),
+	)
+
+	details, err := service.GetMeal(ctx, "123")
+	assert.NoError(t, err)
+	assert.False(t, details.Partial)
+
+	// barn manager недоступен: возвращается последний полученный список
+	now = now.Add(30 * time.Minute)
+	details, err = service.GetMeal(ctx, "123")
+	assert.NoError(t, err)
+	assert.True(t, details.Partial)
+	assert.Equal(t, products, details.ShoppingList)
+	assert.Len(t, details.Warnings, 2)
+	assert.Contains(t, details.Warnings[1], "2024-03-20T12:00:00Z")
+
+	// список покупок кэшируется для каждого пользователя отдельно
+	details, err = service.GetMeal(ctx, "456")
+	assert.NoError(t, err)
+	assert.Nil(t, details.ShoppingList)
+
+	// устаревший список не возвращается
+	now = now.Add(time.Hour)
+	details, err = service.GetMeal(ctx, "123")
+	assert.NoError(t, err)
+	assert.True(t, details.Partial)
+	assert.Nil(t, details.ShoppingList)
+}
+
+func TestGetMeal_Canceled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	service := menu.NewService(mockStore, mockClient)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	menuData := []menu.Menu{{MealID: "meal1", Time: time.Now().Add(time.Hour), MealType: "lunch"}}
+
+	mockStore.EXPECT().LoadMenu(ctx, "123").Return(menuData, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"meal1"}).Return(map[string]*menu.Meal{"meal1": &menu.Meal{MealID: "meal1"}}, nil)
+	mockClient.EXPECT().GetProducts(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, recipes []menu.Recipe) (*menu.ShoppingList, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+
+	_, err := service.GetMeal(ctx, "123")
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 func TestIsActual(t *testing.T) {
@@ -47,25 +145,82 @@ func TestIsActual(t *testing.T) {
 		{MealID: "meal2", Time: now.Add(-24 * time.Hour), MealType: "dinner"},
 	}
 
-	assert.True(t, menu.IsActual(menuData))
+	assert.True(t, menu.IsActual(menuData, now))
 	assert.False(t, menu.IsActual([]menu.Menu{
 		{MealID: "meal3", Time: now.Add(-24 * time.Hour), MealType: "lunch"},
-	}))
+	}, now))
 }
 
-func TestFindClosestMeal(t *testing.T) {
-	now := time.Now()
-	menuData := []menu.Menu{
-		{MealID: "meal1", Time: now.Add(1 * time.Hour), MealType: "lunch"},
-		{MealID: "meal2", Time: now.Add(2 * time.Hour), MealType: "dinner"},
+func TestFindNextMeal(t *testing.T) {
+	now := time.Date(2024, 3, 20, 13, 20, 0, 0, time.UTC)
+	grace := 30 * time.Minute
+
+	tests := []struct {
+		name     string
+		menu     []menu.Menu
+		expected string
+	}{
+		{
+			name: "minute precision",
+			menu: []menu.Menu{
+				{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+				{MealID: "snack", Time: time.Date(2024, 3, 20, 13, 45, 0, 0, time.UTC), MealType: "snack"},
+			},
+			expected: "snack",
+		},
+		{
+			name: "past meal is skipped",
+			menu: []menu.Menu{
+				{MealID: "breakfast", Time: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+				{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+			},
+			expected: "dinner",
+		},
+		{
+			name: "currently eating within grace",
+			menu: []menu.Menu{
+				{MealID: "lunch", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+				{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+			},
+			expected: "lunch",
+		},
+		{
+			name: "next day",
+			menu: []menu.Menu{
+				{MealID: "breakfast", Time: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+				{MealID: "tomorrow", Time: time.Date(2024, 3, 21, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+			},
+			expected: "tomorrow",
+		},
 	}
 
-	mealID, err := menu.FindClosestMeal(menuData)
-	assert.NoError(t, err)
-	assert.Equal(t, "meal1", mealID)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mealID, err := menu.FindNextMeal(tt.menu, now, grace)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, mealID)
+		})
+	}
+
+	_, err := menu.FindNextMeal([]menu.Menu{
+		{MealID: "breakfast", Time: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+	}, now, grace)
+	assert.ErrorIs(t, err, oops.ErrNoUpcomingMeal)
+}
+
+func TestNextMeals(t *testing.T) {
+	now := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	menuData := []menu.Menu{
+		{MealID: "c", Time: now.Add(48 * time.Hour), MealType: "lunch"},
+		{MealID: "a", Time: now.Add(time.Hour), MealType: "lunch"},
+		{MealID: "past", Time: now.Add(-time.Hour), MealType: "breakfast"},
+		{MealID: "b", Time: now.Add(24 * time.Hour), MealType: "lunch"},
+	}
 
-	_, err = menu.FindClosestMeal([]menu.Menu{})
-	assert.ErrorIs(t, err, oops.ErrInvalidDates)
+	next := menu.NextMeals(menuData, now, 0, 2)
+	assert.Equal(t, []string{"a", "b"}, []string{next[0].MealID, next[1].MealID})
+	assert.Len(t, menu.NextMeals(menuData, now, 0, 0), 3)
+	assert.Len(t, menu.NextMeals(menuData, now, 2*time.Hour, 0), 4)
 }
 
 func TestRescheduleMenu(t *testing.T) {
@@ -73,24 +228,26 @@ func TestRescheduleMenu(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockStore := mocks.NewMockStore(ctrl)
-	service := menu.NewService(mockStore, nil)
+	clock := func() time.Time { return time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, nil, menu.WithClock(clock))
 
 	ctx := context.Background()
 	userID := "123"
+	// меню устарело на две недели
 	menuData := []menu.Menu{
-		{MealID: "meal1", Time: time.Now(), MealType: "lunch"},
-		{MealID: "meal2", Time: time.Now().Add(1 * time.Hour), MealType: "dinner"},
+		{MealID: "meal1", Time: time.Date(2024, 3, 6, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Date(2024, 3, 6, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	expected := []menu.Menu{
+		{MealID: "meal1", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "meal2", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
 	}
 
-	mockStore.EXPECT().UpdateMenu(ctx, userID, gomock.Any()).Return(nil)
+	mockStore.EXPECT().UpdateMenu(ctx, userID, expected).Return(nil)
 
 	updatedMenu, err := service.RescheduleMenu(ctx, menuData, userID)
 	assert.NoError(t, err)
-	assert.Len(t, updatedMenu, len(menuData))
-
-	for _, v := range updatedMenu {
-		assert.True(t, v.Time.After(time.Now()))
-	}
+	assert.Equal(t, expected, updatedMenu)
 }
 
 func TestGetMenu(t *testing.T) {
@@ -112,3 +269,108 @@ func TestGetMenu(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, expectedMenu, menu)
 }
+
+func TestCreateMenuEntry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	entry := menu.Menu{MealID: "meal1", Time: time.Now(), MealType: "breakfast"}
+
+	mockStore.EXPECT().SaveMenuEntry(ctx, "123", entry).Return(nil)
+
+	created, err := service.CreateMenuEntry(ctx, "123", entry)
+	assert.NoError(t, err)
+	assert.Equal(t, entry, *created)
+}
+
+func TestCreateMenuEntry_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	tests := []struct {
+		name  string
+		entry menu.Menu
+		field string
+	}{
+		{"empty meal_id", menu.Menu{Time: time.Now(), MealType: "lunch"}, "meal_id"},
+		{"unknown meal_type", menu.Menu{MealID: "meal1", Time: time.Now(), MealType: "brunch"}, "meal_type"},
+		{"empty eat_date", menu.Menu{MealID: "meal1", MealType: "lunch"}, "eat_date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.CreateMenuEntry(context.Background(), "123", tt.entry)
+
+			var validationErr *oops.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestUpdateMenuEntry_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	entry := menu.Menu{MealID: "meal1", Time: time.Now(), MealType: "dinner"}
+
+	mockStore.EXPECT().LoadMenuEntry(ctx, "123", "meal1").Return(nil, oops.ErrNoData)
+
+	_, err := service.UpdateMenuEntry(ctx, "123", entry)
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestCreateDish_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	dish := menu.Dish{
+		DishID: "dish1",
+		MealID: "meal1",
+		Name:   "Омлет",
+		Recipe: menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: "яйцо", Unit: "шт"}}},
+	}
+
+	_, err := service.CreateDish(context.Background(), dish)
+
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "recipe.ingredients[0].amount", validationErr.Field)
+}
+
+func TestUpdateDish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	dish := menu.Dish{
+		DishID: "dish1",
+		MealID: "meal1",
+		Name:   "Омлет",
+		Recipe: menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: "яйцо", Amount: 2, Unit: "шт"}}},
+	}
+
+	mockStore.EXPECT().LoadDish(ctx, "dish1").Return(&dish, nil)
+	mockStore.EXPECT().UpdateDish(ctx, dish).Return(nil)
+
+	updated, err := service.UpdateDish(ctx, dish)
+	assert.NoError(t, err)
+	assert.Equal(t, dish, *updated)
+}
@@ -0,0 +1,57 @@
+package menu
+
+import (
+	common "menu_manager/internal/models"
+)
+
+// NewShoppingList рассчитывает список покупок: суммирует ингредиенты рецептов по продуктам
+// и вычитает то, что уже есть в наличии по данным barn manager.
+// Запасы barn manager хранит без единиц, поэтому продукт, указанный в рецептах в разных единицах, возвращается ошибкой
+func NewShoppingList(recipes []Recipe, products []common.Product) (*ShoppingList, error) {
+	// потребность суммируется так же, как в запросе к barn manager, в порядке первого появления продукта
+	request, err := newCheckAvailabilityRequest(recipes)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]common.Product, len(products))
+	for _, p := range products {
+		known[p.ID] = p
+	}
+
+	list := &ShoppingList{Items: make([]ShoppingItem, 0, len(request.Ingredients))}
+	for _, required := range request.Ingredients {
+		product, ok := known[required.ProductID]
+		if !ok {
+			// barn manager ничего не знает о продукте, значит его придется купить целиком
+			product = common.Product{ID: required.ProductID, Name: required.ProductID}
+		}
+
+		var inStock uint
+		if product.Amount > 0 {
+			inStock = uint(product.Amount)
+		}
+		if inStock >= required.Amount {
+			continue
+		}
+		toBuy := required.Amount - inStock
+
+		list.Items = append(list.Items, ShoppingItem{
+			Product:  product,
+			Required: required.Amount,
+			ToBuy:    toBuy,
+			Unit:     required.Unit,
+			Packages: packagesFor(toBuy, product.WeightPerPkg),
+		})
+	}
+	return list, nil
+}
+
+// packagesFor возвращает число упаковок, покрывающих недостающее количество продукта
+func packagesFor(toBuy uint, weightPerPkg int) uint {
+	if weightPerPkg <= 0 {
+		return 0
+	}
+	perPkg := uint(weightPerPkg)
+	return (toBuy + perPkg - 1) / perPkg
+}
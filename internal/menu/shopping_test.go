@@ -0,0 +1,64 @@
+package menu_test
+
+import (
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewShoppingList(t *testing.T) {
+	recipes := []menu.Recipe{
+		{Ingredients: []menu.Ingredient{
+			{ProductID: "молоко", Amount: 200, Unit: "мл"},
+			{ProductID: "овсяные_хлопья", Amount: 100, Unit: "г"},
+		}},
+		{Ingredients: []menu.Ingredient{
+			{ProductID: "молоко", Amount: 900, Unit: "мл"},
+			{ProductID: "морковь", Amount: 100, Unit: "г"},
+		}},
+	}
+	products := []common.Product{
+		{ID: "молоко", Name: "Молоко", WeightPerPkg: 1000, Amount: 100},
+		{ID: "овсяные_хлопья", Name: "Овсяные хлопья", WeightPerPkg: 500, Amount: 400},
+	}
+
+	list, err := menu.NewShoppingList(recipes, products)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []menu.ShoppingItem{
+		{Product: products[0], Required: 1100, ToBuy: 1000, Unit: "мл", Packages: 1},
+		{Product: common.Product{ID: "морковь", Name: "морковь"}, Required: 100, ToBuy: 100, Unit: "г", Packages: 0},
+	}, list.Items)
+}
+
+func TestNewShoppingList_NothingToBuy(t *testing.T) {
+	recipes := []menu.Recipe{
+		{Ingredients: []menu.Ingredient{{ProductID: "яйцо", Amount: 2, Unit: "шт"}}},
+	}
+	products := []common.Product{{ID: "яйцо", WeightPerPkg: 10, Amount: 6}}
+
+	list, err := menu.NewShoppingList(recipes, products)
+	assert.NoError(t, err)
+
+	assert.NotNil(t, list.Items)
+	assert.Empty(t, list.Items)
+}
+
+func TestNewShoppingList_MixedUnits(t *testing.T) {
+	recipes := []menu.Recipe{
+		{Ingredients: []menu.Ingredient{{ProductID: "молоко", Amount: 200, Unit: "мл"}}},
+		{Ingredients: []menu.Ingredient{{ProductID: "молоко", Amount: 1, Unit: "шт"}}},
+	}
+	products := []common.Product{{ID: "молоко", WeightPerPkg: 1000, Amount: 100}}
+
+	// миллилитры и штуки не складываются, иначе к покупке вышло бы 101 «чего-то»
+	list, err := menu.NewShoppingList(recipes, products)
+	assert.Nil(t, list)
+	assert.ErrorIs(t, err, oops.ErrInvalidValue)
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "unit", validationErr.Field)
+}
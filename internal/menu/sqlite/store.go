@@ -0,0 +1,553 @@
+// Package sqlite реализует menu.Store поверх SQLite на чистом Go (modernc.org/sqlite).
+// Схема и поведение совпадают с internal/menu/mysql, схема лежит в migrations/sqlite
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"menu_manager/internal/logging"
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+
+	"github.com/jmoiron/sqlx"
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timeLayout формат хранения времени. Время всегда записывается в UTC и с точностью до секунды,
+// как TIMESTAMP в MySQL, поэтому строки сравниваются в том же порядке, что и моменты времени
+const timeLayout = "2006-01-02 15:04:05"
+
+// lockTimeLayout формат срока действия блокировки, фиксированная ширина сохраняет порядок сравнения строк
+const lockTimeLayout = "2006-01-02 15:04:05.000000"
+
+// Open открывает базу SQLite по пути к файлу (":memory:" — база в памяти).
+// SQLite допускает только одного писателя, поэтому все запросы идут через одно соединение:
+// так не бывает ошибок SQLITE_BUSY, а база в памяти видна всем запросам.
+// Транзакции начинаются с BEGIN IMMEDIATE и сразу захватывают блокировку записи
+func Open(ctx context.Context, path string) (*sqlx.DB, error) {
+	db, err := sqlx.ConnectContext(ctx, "sqlite", path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть базу SQLite %s: %w", path, err)
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+type Storage struct {
+	db     *sqlx.DB
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+// Option настраивает необязательные параметры хранилища
+type Option func(*Storage)
+
+// WithLogger задает журнал хранилища, по умолчанию используется slog.Default
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Storage) {
+		s.logger = logger
+	}
+}
+
+func NewStorage(db *sqlx.DB, opts ...Option) *Storage {
+	s := &Storage{db: db, now: time.Now, logger: slog.Default()}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// LoadMenu возвращает меню из БД со списком id приемов пиши и их запланированного времени
+func (s *Storage) LoadMenu(ctx context.Context, userID string) ([]menu.Menu, error) {
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = ?
+	`
+	s.logger.DebugContext(ctx, "загрузка меню", logging.UserIDKey, userID)
+	menuList, err := s.queryMenu(ctx, "LoadMenu", userID, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(menuList) == 0 {
+		return nil, oops.ErrNoData
+	}
+	return menuList, nil
+}
+
+// LoadMenuRange возвращает приемы пищи пользователя в полуинтервале [from, to), упорядоченные по времени
+func (s *Storage) LoadMenuRange(ctx context.Context, userID string, from, to time.Time) ([]menu.Menu, error) {
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = ? AND eat_date >= ? AND eat_date < ?
+		ORDER BY eat_date
+	`
+	return s.queryMenu(ctx, "LoadMenuRange", userID, query, userID, formatTime(from), formatTime(to))
+}
+
+// LoadMeal возвращает из базы прием пищи с описанием составляющих его блюд и продуктов
+func (s *Storage) LoadMeal(ctx context.Context, mealID string) (*menu.Meal, error) {
+	query := `
+		SELECT dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE meal_id = ?
+	`
+	rows, err := s.db.QueryContext(ctx, query, mealID)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeal", mealID)
+	}
+	defer rows.Close()
+
+	meal := menu.Meal{
+		MealID:         mealID,
+		DishIDs:        make([]string, 0, 10),
+		DishNames:      make([]string, 0, 10),
+		Recipes:        make([]menu.Recipe, 0, 10),
+		TotalNutrition: common.NutritionalValueAbsolute{},
+	}
+	for rows.Next() {
+		var dish menu.Dish
+		var recipeJson, nutritionJson string
+		if err := rows.Scan(&dish.DishID, &dish.Name, &recipeJson, &nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeal.Scan", mealID)
+		}
+		if err := unmarshalDish(&dish, recipeJson, nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeal.JsonUnmarshal", dish.DishID)
+		}
+
+		meal.DishIDs = append(meal.DishIDs, dish.DishID)
+		meal.DishNames = append(meal.DishNames, dish.Name)
+		meal.Recipes = append(meal.Recipes, dish.Recipe)
+		meal.TotalNutrition = meal.TotalNutrition.AddAbsoluteValue(dish.TotalNutrition)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadMeal.Rows", mealID)
+	}
+	return &meal, nil
+}
+
+// LoadMeals возвращает приемы пищи mealIDs одним запросом, ключ — meal_id.
+// Каждый запрошенный прием пищи есть в результате, прием пищи без блюд возвращается пустым, как в LoadMeal
+func (s *Storage) LoadMeals(ctx context.Context, mealIDs []string) (map[string]*menu.Meal, error) {
+	meals := make(map[string]*menu.Meal, len(mealIDs))
+	if len(mealIDs) == 0 {
+		return meals, nil
+	}
+	for _, mealID := range mealIDs {
+		meals[mealID] = &menu.Meal{
+			MealID:    mealID,
+			DishIDs:   make([]string, 0),
+			DishNames: make([]string, 0),
+			Recipes:   make([]menu.Recipe, 0),
+		}
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT meal_id, dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE meal_id IN (?)
+		ORDER BY name, dish_id
+	`, mealIDs)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals.In", "")
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals", "")
+	}
+	defer rows.Close()
+
+	// строки упорядочены по блюдам, группируем их по приемам пищи
+	for rows.Next() {
+		var dish menu.Dish
+		var recipeJson, nutritionJson string
+		if err := rows.Scan(&dish.MealID, &dish.DishID, &dish.Name, &recipeJson, &nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeals.Scan", "")
+		}
+		if err := unmarshalDish(&dish, recipeJson, nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadMeals.JsonUnmarshal", dish.DishID)
+		}
+		meal := meals[dish.MealID]
+		meal.DishIDs = append(meal.DishIDs, dish.DishID)
+		meal.DishNames = append(meal.DishNames, dish.Name)
+		meal.Recipes = append(meal.Recipes, dish.Recipe)
+		meal.TotalNutrition = meal.TotalNutrition.AddAbsoluteValue(dish.TotalNutrition)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadMeals.Rows", "")
+	}
+	return meals, nil
+}
+
+// UpdateMenu обновляет время и даты приемов пищи
+func (s *Storage) UpdateMenu(ctx context.Context, userID string, menuList []menu.Menu) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return oops.NewDBError(err, "UpdateMenu.Begin", userID)
+	}
+	defer tx.Rollback()
+
+	updateQuery := "UPDATE menu SET eat_date = ? WHERE user_id = ? AND meal_id = ?"
+	for _, m := range menuList {
+		if _, err := tx.ExecContext(ctx, updateQuery, formatTime(m.Time), userID, m.MealID); err != nil {
+			return oops.NewDBError(err, "UpdateMenu", userID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.NewDBError(err, "UpdateMenu.Commit", userID)
+	}
+	return nil
+}
+
+// SaveMenuEntry сохраняет новый прием пищи в расписании пользователя
+func (s *Storage) SaveMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	query := `
+		INSERT INTO menu (meal_id, meal_type, eat_date, user_id)
+		VALUES (?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(ctx, query, entry.MealID, entry.MealType, formatTime(entry.Time), userID)
+	if err != nil {
+		if isDuplicate(err) {
+			return oops.NewDBError(oops.ErrDuplicateKey, "SaveMenuEntry", entry.MealID)
+		}
+		return oops.NewDBError(err, "SaveMenuEntry", entry.MealID)
+	}
+	return nil
+}
+
+// LoadMenuEntry возвращает запланированный прием пищи пользователя
+func (s *Storage) LoadMenuEntry(ctx context.Context, userID string, mealID string) (*menu.Menu, error) {
+	query := `
+		SELECT meal_id, eat_date, meal_type
+		FROM menu
+		WHERE user_id = ? AND meal_id = ?
+	`
+	var m menu.Menu
+	err := s.db.QueryRowContext(ctx, query, userID, mealID).Scan(&m.MealID, &m.Time, &m.MealType)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadMenuEntry", mealID)
+	}
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadMenuEntry", mealID)
+	}
+	return &m, nil
+}
+
+// UpdateMenuEntry обновляет тип и время запланированного приема пищи
+func (s *Storage) UpdateMenuEntry(ctx context.Context, userID string, entry menu.Menu) error {
+	query := `
+		UPDATE menu SET meal_type = ?, eat_date = ?
+		WHERE user_id = ? AND meal_id = ?
+	`
+	if _, err := s.db.ExecContext(ctx, query, entry.MealType, formatTime(entry.Time), userID, entry.MealID); err != nil {
+		return oops.NewDBError(err, "UpdateMenuEntry", entry.MealID)
+	}
+	return nil
+}
+
+// DeleteMenuEntry удаляет прием пищи из расписания пользователя
+func (s *Storage) DeleteMenuEntry(ctx context.Context, userID string, mealID string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM menu WHERE user_id = ? AND meal_id = ?", userID, mealID)
+	if err != nil {
+		return oops.NewDBError(err, "DeleteMenuEntry", mealID)
+	}
+	return checkAffected(res, "DeleteMenuEntry", mealID)
+}
+
+// SaveDish сохраняет новое блюдо
+func (s *Storage) SaveDish(ctx context.Context, dish menu.Dish) error {
+	recipeJson, nutritionJson, err := marshalDish(dish)
+	if err != nil {
+		return oops.NewDBError(err, "SaveDish.JsonMarshal", dish.DishID)
+	}
+
+	query := `
+		INSERT INTO dishes (meal_id, dish_id, name, recipie, total_nutrition)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = s.db.ExecContext(ctx, query, dish.MealID, dish.DishID, dish.Name, recipeJson, nutritionJson)
+	if err != nil {
+		if isDuplicate(err) {
+			return oops.NewDBError(oops.ErrDuplicateKey, "SaveDish", dish.DishID)
+		}
+		return oops.NewDBError(err, "SaveDish", dish.DishID)
+	}
+	return nil
+}
+
+// LoadDish возвращает блюдо по ID
+func (s *Storage) LoadDish(ctx context.Context, dishID string) (*menu.Dish, error) {
+	query := `
+		SELECT meal_id, dish_id, name, recipie, total_nutrition
+		FROM dishes
+		WHERE dish_id = ?
+	`
+	var dish menu.Dish
+	var recipeJson, nutritionJson string
+	err := s.db.QueryRowContext(ctx, query, dishID).Scan(&dish.MealID, &dish.DishID, &dish.Name, &recipeJson, &nutritionJson)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadDish", dishID)
+	}
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadDish", dishID)
+	}
+	if err := unmarshalDish(&dish, recipeJson, nutritionJson); err != nil {
+		return nil, oops.NewDBError(err, "LoadDish.JsonUnmarshal", dishID)
+	}
+	return &dish, nil
+}
+
+// UpdateDish обновляет блюдо
+func (s *Storage) UpdateDish(ctx context.Context, dish menu.Dish) error {
+	recipeJson, nutritionJson, err := marshalDish(dish)
+	if err != nil {
+		return oops.NewDBError(err, "UpdateDish.JsonMarshal", dish.DishID)
+	}
+
+	query := `
+		UPDATE dishes SET meal_id = ?, name = ?, recipie = ?, total_nutrition = ?
+		WHERE dish_id = ?
+	`
+	_, err = s.db.ExecContext(ctx, query, dish.MealID, dish.Name, recipeJson, nutritionJson, dish.DishID)
+	if err != nil {
+		return oops.NewDBError(err, "UpdateDish", dish.DishID)
+	}
+	return nil
+}
+
+// DeleteDish удаляет блюдо
+func (s *Storage) DeleteDish(ctx context.Context, dishID string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM dishes WHERE dish_id = ?", dishID)
+	if err != nil {
+		return oops.NewDBError(err, "DeleteDish", dishID)
+	}
+	return checkAffected(res, "DeleteDish", dishID)
+}
+
+// LoadDishes возвращает каталог всех блюд с типом приема пищи, к которому они относятся
+func (s *Storage) LoadDishes(ctx context.Context) ([]menu.Dish, error) {
+	query := `
+		SELECT d.meal_id, d.dish_id, d.name, d.recipie, d.total_nutrition, COALESCE(m.meal_type, ''), COALESCE(d.source_dish_id, '')
+		FROM dishes d
+		LEFT JOIN menu m ON m.meal_id = d.meal_id
+		ORDER BY d.name, d.dish_id
+	`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadDishes", "")
+	}
+	defer rows.Close()
+
+	dishes := make([]menu.Dish, 0)
+	for rows.Next() {
+		var dish menu.Dish
+		var recipeJson, nutritionJson string
+		if err := rows.Scan(&dish.MealID, &dish.DishID, &dish.Name, &recipeJson, &nutritionJson, &dish.MealType, &dish.SourceDishID); err != nil {
+			return nil, oops.NewDBError(err, "LoadDishes.Scan", "")
+		}
+		if err := unmarshalDish(&dish, recipeJson, nutritionJson); err != nil {
+			return nil, oops.NewDBError(err, "LoadDishes.JsonUnmarshal", dish.DishID)
+		}
+		dishes = append(dishes, dish)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadDishes.Rows", "")
+	}
+	return dishes, nil
+}
+
+// ReplaceMenuRange атомарно заменяет расписание пользователя в полуинтервале [from, to)
+// новыми приемами пищи и их блюдами
+func (s *Storage) ReplaceMenuRange(ctx context.Context, userID string, from, to time.Time, entries []menu.Menu, dishes []menu.Dish) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Begin", userID)
+	}
+	defer tx.Rollback()
+
+	// копии блюд, созданные генератором для заменяемых приемов пищи, удаляются вместе с расписанием.
+	// Блюда без source_dish_id заведены пользователем и остаются в каталоге
+	dishesQuery := `
+		DELETE FROM dishes
+		WHERE source_dish_id IS NOT NULL
+		  AND meal_id IN (SELECT meal_id FROM menu WHERE user_id = ? AND eat_date >= ? AND eat_date < ?)
+	`
+	if _, err := tx.ExecContext(ctx, dishesQuery, userID, formatTime(from), formatTime(to)); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.DeleteDishes", userID)
+	}
+
+	deleteQuery := "DELETE FROM menu WHERE user_id = ? AND eat_date >= ? AND eat_date < ?"
+	if _, err := tx.ExecContext(ctx, deleteQuery, userID, formatTime(from), formatTime(to)); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Delete", userID)
+	}
+
+	menuQuery := "INSERT INTO menu (meal_id, meal_type, eat_date, user_id) VALUES (?, ?, ?, ?)"
+	for _, m := range entries {
+		if _, err := tx.ExecContext(ctx, menuQuery, m.MealID, m.MealType, formatTime(m.Time), userID); err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.InsertMenu", m.MealID)
+		}
+	}
+
+	dishQuery := "INSERT INTO dishes (meal_id, dish_id, name, recipie, total_nutrition, source_dish_id) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))"
+	for _, dish := range dishes {
+		recipeJson, nutritionJson, err := marshalDish(dish)
+		if err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.JsonMarshal", dish.DishID)
+		}
+		if _, err := tx.ExecContext(ctx, dishQuery, dish.MealID, dish.DishID, dish.Name, recipeJson, nutritionJson, dish.SourceDishID); err != nil {
+			return oops.NewDBError(err, "ReplaceMenuRange.InsertDish", dish.DishID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return oops.NewDBError(err, "ReplaceMenuRange.Commit", userID)
+	}
+	return nil
+}
+
+// LoadStaleUsers возвращает пользователей, последний прием пищи которых запланирован раньше before
+func (s *Storage) LoadStaleUsers(ctx context.Context, before time.Time) ([]string, error) {
+	query := `
+		SELECT user_id
+		FROM menu
+		GROUP BY user_id
+		HAVING MAX(eat_date) < ?
+		ORDER BY user_id
+	`
+	rows, err := s.db.QueryContext(ctx, query, formatTime(before))
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadStaleUsers", "")
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, oops.NewDBError(err, "LoadStaleUsers.Scan", "")
+		}
+		users = append(users, userID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadStaleUsers.Rows", "")
+	}
+	return users, nil
+}
+
+// AcquireLock захватывает блокировку name для owner на ttl. Истекшая блокировка перехватывается.
+// База SQLite локальна, поэтому время берется из часов процесса
+func (s *Storage) AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error) {
+	// строка обновляется, только если блокировку держит тот же владелец или она истекла
+	query := `
+		INSERT INTO scheduler_locks (name, owner, expires_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			owner = excluded.owner,
+			expires_at = excluded.expires_at
+		WHERE scheduler_locks.owner = excluded.owner OR scheduler_locks.expires_at < ?
+	`
+	now := s.now().UTC()
+	res, err := s.db.ExecContext(ctx, query, name, owner,
+		now.Add(ttl).Format(lockTimeLayout), now.Format(lockTimeLayout))
+	if err != nil {
+		return false, oops.NewDBError(err, "AcquireLock", name)
+	}
+
+	// 1 — блокировка создана, перехвачена или продлена, 0 — ее держит другой владелец
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, oops.NewDBError(err, "AcquireLock.RowsAffected", name)
+	}
+	return affected > 0, nil
+}
+
+// ReleaseLock освобождает блокировку, если ее держит owner
+func (s *Storage) ReleaseLock(ctx context.Context, name, owner string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM scheduler_locks WHERE name = ? AND owner = ?", name, owner)
+	if err != nil {
+		return oops.NewDBError(err, "ReleaseLock", name)
+	}
+	return nil
+}
+
+// queryMenu выполняет запрос, возвращающий meal_id, eat_date и meal_type
+func (s *Storage) queryMenu(ctx context.Context, op, userID, query string, args ...any) ([]menu.Menu, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, oops.NewDBError(err, op, userID)
+	}
+	defer rows.Close()
+
+	menuList := make([]menu.Menu, 0)
+	for rows.Next() {
+		var m menu.Menu
+		if err := rows.Scan(&m.MealID, &m.Time, &m.MealType); err != nil {
+			return nil, oops.NewDBError(err, op+".Scan", userID)
+		}
+		menuList = append(menuList, m)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, op+".Rows", userID)
+	}
+	return menuList, nil
+}
+
+// formatTime приводит время к формату хранения. Драйвер сам разбирает такие строки
+// в time.Time при чтении столбцов типа TIMESTAMP, но записывает time.Time в несравнимом виде
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// isDuplicate проверяет, что запрос нарушил первичный или уникальный ключ
+func isDuplicate(err error) bool {
+	var sqliteErr *driver.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	code := sqliteErr.Code()
+	return code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY || code == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// checkAffected возвращает ErrNoData, если запрос не затронул ни одной строки
+func checkAffected(res sql.Result, op, key string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return oops.NewDBError(err, op+".RowsAffected", key)
+	}
+	if affected == 0 {
+		return oops.NewDBError(oops.ErrNoData, op, key)
+	}
+	return nil
+}
+
+// marshalDish сериализует рецепт и пищевую ценность блюда в JSON для хранения в БД
+func marshalDish(dish menu.Dish) (string, string, error) {
+	recipeJson, err := json.Marshal(dish.Recipe)
+	if err != nil {
+		return "", "", err
+	}
+	nutritionJson, err := json.Marshal(dish.TotalNutrition)
+	if err != nil {
+		return "", "", err
+	}
+	return string(recipeJson), string(nutritionJson), nil
+}
+
+// unmarshalDish разбирает рецепт и пищевую ценность блюда из JSON
+func unmarshalDish(dish *menu.Dish, recipeJson, nutritionJson string) error {
+	if err := json.Unmarshal([]byte(recipeJson), &dish.Recipe); err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(nutritionJson), &dish.TotalNutrition)
+}
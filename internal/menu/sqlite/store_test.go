@@ -0,0 +1,53 @@
+package sqlite_test
+
+import (
+	"context"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/menu/sqlite"
+	"menu_manager/internal/menu/storetest"
+	"menu_manager/internal/migrate"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// newDB открывает пустую базу в памяти и применяет к ней встроенные миграции SQLite
+func newDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	db, err := sqlite.Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) menu.Store {
+		return sqlite.NewStorage(newDB(t))
+	})
+}
+
+func TestStoresTimeInUTC(t *testing.T) {
+	db := newDB(t)
+	store := sqlite.NewStorage(db)
+	ctx := context.Background()
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	err := store.SaveMenuEntry(ctx, "kolya", menu.Menu{MealID: "1", Time: time.Date(2024, 3, 20, 11, 0, 0, 0, moscow), MealType: "breakfast"})
+	assert.NoError(t, err)
+
+	var raw string
+	assert.NoError(t, db.QueryRow("SELECT CAST(eat_date AS TEXT) FROM menu WHERE meal_id = '1'").Scan(&raw))
+	assert.Equal(t, "2024-03-20 08:00:00", raw)
+}
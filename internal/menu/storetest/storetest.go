@@ -0,0 +1,444 @@
+// Package storetest содержит общий набор тестов, который должна проходить каждая реализация menu.Store.
+// Набор фиксирует поведение MySQL-хранилища: какие ошибки возвращаются, в каком порядке и часовом поясе
+// отдаются данные, что происходит с чужими и несуществующими записями
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"menu_manager/internal/menu"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/scheduler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// NewStore возвращает пустое хранилище. Вызывается в начале каждого теста набора
+type NewStore func(t *testing.T) menu.Store
+
+// Run прогоняет набор тестов на хранилище. Тесты блокировок выполняются,
+// только если хранилище реализует scheduler.Locker
+func Run(t *testing.T, newStore NewStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store menu.Store)
+	}{
+		{"LoadMenu", testLoadMenu},
+		{"LoadMenuRange", testLoadMenuRange},
+		{"MenuEntry", testMenuEntry},
+		{"UpdateMenu", testUpdateMenu},
+		{"Dish", testDish},
+		{"LoadMeal", testLoadMeal},
+		{"LoadMeals", testLoadMeals},
+		{"LoadDishes", testLoadDishes},
+		{"ReplaceMenuRange", testReplaceMenuRange},
+		{"ReplaceMenuRange_Rollback", testReplaceMenuRangeRollback},
+		{"LoadStaleUsers", testLoadStaleUsers},
+		{"Locks", testLocks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// at возвращает время 20 марта 2024 года в UTC
+func at(hour int) time.Time {
+	return time.Date(2024, 3, 20, hour, 0, 0, 0, time.UTC)
+}
+
+func seedMenu(t *testing.T, store menu.Store) {
+	t.Helper()
+	ctx := context.Background()
+	for _, row := range []struct {
+		userID string
+		entry  menu.Menu
+	}{
+		{"kolya", menu.Menu{MealID: "2", Time: at(13), MealType: "lunch"}},
+		{"kolya", menu.Menu{MealID: "1", Time: at(8), MealType: "breakfast"}},
+		{"dan", menu.Menu{MealID: "3", Time: at(19), MealType: "dinner"}},
+	} {
+		if err := store.SaveMenuEntry(ctx, row.userID, row.entry); err != nil {
+			t.Fatalf("SaveMenuEntry(%s): %v", row.entry.MealID, err)
+		}
+	}
+}
+
+func seedDishes(t *testing.T, store menu.Store) {
+	t.Helper()
+	ctx := context.Background()
+	for _, dish := range []menu.Dish{
+		{
+			DishID: "2",
+			MealID: "2",
+			Name:   "Куриный суп",
+			Recipe: menu.Recipe{
+				Ingredients: []menu.Ingredient{{ProductID: "куриное_филе", Amount: 200, Unit: "г"}},
+				Steps:       []string{"Сварить бульон"},
+			},
+			TotalNutrition: common.NutritionalValueAbsolute{Calories: 450, Proteins: 35, Fats: 12, Carbohydrates: 25},
+		},
+		{
+			DishID:         "4",
+			MealID:         "2",
+			Name:           "Рататуй",
+			Recipe:         menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: "помидор", Amount: 100, Unit: "г"}}},
+			TotalNutrition: common.NutritionalValueAbsolute{Calories: 40, Proteins: 335, Fats: 121, Carbohydrates: 259},
+		},
+		{
+			DishID:         "1",
+			MealID:         "1",
+			Name:           "Овсяная каша",
+			Recipe:         menu.Recipe{Ingredients: []menu.Ingredient{{ProductID: "молоко", Amount: 200, Unit: "мл"}}},
+			TotalNutrition: common.NutritionalValueAbsolute{Calories: 350, Proteins: 12, Fats: 7, Carbohydrates: 55},
+		},
+	} {
+		if err := store.SaveDish(ctx, dish); err != nil {
+			t.Fatalf("SaveDish(%s): %v", dish.DishID, err)
+		}
+	}
+}
+
+func testLoadMenu(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+
+	_, err := store.LoadMenu(ctx, "kolya")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+
+	seedMenu(t, store)
+
+	// порядок записей LoadMenu не гарантирует
+	menus, err := store.LoadMenu(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []menu.Menu{
+		{MealID: "1", Time: at(8), MealType: "breakfast"},
+		{MealID: "2", Time: at(13), MealType: "lunch"},
+	}, menus)
+}
+
+func testLoadMenuRange(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+
+	// полуинтервал [from, to): прием пищи ровно в to не попадает
+	menus, err := store.LoadMenuRange(ctx, "kolya", at(8), at(13))
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{{MealID: "1", Time: at(8), MealType: "breakfast"}}, menus)
+
+	menus, err = store.LoadMenuRange(ctx, "kolya", at(0), at(23))
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{
+		{MealID: "1", Time: at(8), MealType: "breakfast"},
+		{MealID: "2", Time: at(13), MealType: "lunch"},
+	}, menus)
+
+	// пустой период — не ошибка
+	menus, err = store.LoadMenuRange(ctx, "kolya", at(20), at(23))
+	assert.NoError(t, err)
+	assert.Empty(t, menus)
+}
+
+func testMenuEntry(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+
+	// время сохраняется в UTC независимо от часового пояса на входе
+	moscow := time.FixedZone("MSK", 3*60*60)
+	entry := menu.Menu{MealID: "5", Time: time.Date(2024, 3, 21, 0, 30, 0, 0, moscow), MealType: "snack"}
+	assert.NoError(t, store.SaveMenuEntry(ctx, "kolya", entry))
+	assert.ErrorIs(t, store.SaveMenuEntry(ctx, "dan", entry), oops.ErrDuplicateKey)
+
+	loaded, err := store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, &menu.Menu{MealID: "5", Time: at(21).Add(30 * time.Minute), MealType: "snack"}, loaded)
+
+	entry.MealType = "dinner"
+	entry.Time = at(20)
+	assert.NoError(t, store.UpdateMenuEntry(ctx, "kolya", entry))
+	loaded, err = store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, &menu.Menu{MealID: "5", Time: at(20), MealType: "dinner"}, loaded)
+
+	// чужие записи не видны, не изменяются и не удаляются
+	_, err = store.LoadMenuEntry(ctx, "dan", "5")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	assert.NoError(t, store.UpdateMenuEntry(ctx, "dan", menu.Menu{MealID: "5", Time: at(1), MealType: "snack"}))
+	assert.ErrorIs(t, store.DeleteMenuEntry(ctx, "dan", "5"), oops.ErrNoData)
+
+	loaded, err = store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.NoError(t, err)
+	assert.Equal(t, "dinner", loaded.MealType)
+
+	assert.NoError(t, store.DeleteMenuEntry(ctx, "kolya", "5"))
+	_, err = store.LoadMenuEntry(ctx, "kolya", "5")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	assert.ErrorIs(t, store.DeleteMenuEntry(ctx, "kolya", "5"), oops.ErrNoData)
+}
+
+func testUpdateMenu(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+	err := store.UpdateMenu(ctx, "kolya", []menu.Menu{
+		{MealID: "1", Time: time.Date(2024, 3, 27, 11, 0, 0, 0, moscow), MealType: "breakfast"},
+		{MealID: "3", Time: at(1), MealType: "dinner"},       // прием пищи другого пользователя
+		{MealID: "unknown", Time: at(1), MealType: "dinner"}, // несуществующая запись
+	})
+	assert.NoError(t, err)
+
+	entry, err := store.LoadMenuEntry(ctx, "kolya", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 27, 8, 0, 0, 0, time.UTC), entry.Time)
+
+	entry, err = store.LoadMenuEntry(ctx, "dan", "3")
+	assert.NoError(t, err)
+	assert.Equal(t, at(19), entry.Time)
+}
+
+func testDish(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedDishes(t, store)
+
+	dish, err := store.LoadDish(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Куриный суп", dish.Name)
+	assert.Equal(t, "2", dish.MealID)
+	assert.Equal(t, []menu.Ingredient{{ProductID: "куриное_филе", Amount: 200, Unit: "г"}}, dish.Recipe.Ingredients)
+	assert.Equal(t, []string{"Сварить бульон"}, dish.Recipe.Steps)
+	assert.Equal(t, uint(450), dish.TotalNutrition.Calories)
+
+	assert.ErrorIs(t, store.SaveDish(ctx, *dish), oops.ErrDuplicateKey)
+
+	dish.Name = "Борщ"
+	dish.TotalNutrition.Calories = 300
+	assert.NoError(t, store.UpdateDish(ctx, *dish))
+	dish, err = store.LoadDish(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "Борщ", dish.Name)
+	assert.Equal(t, uint(300), dish.TotalNutrition.Calories)
+
+	// обновление несуществующего блюда ничего не делает
+	assert.NoError(t, store.UpdateDish(ctx, menu.Dish{DishID: "unknown", Name: "Пустота"}))
+	_, err = store.LoadDish(ctx, "unknown")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+
+	assert.NoError(t, store.DeleteDish(ctx, "2"))
+	_, err = store.LoadDish(ctx, "2")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	assert.ErrorIs(t, store.DeleteDish(ctx, "2"), oops.ErrNoData)
+}
+
+func testLoadMeal(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+	seedDishes(t, store)
+
+	meal, err := store.LoadMeal(ctx, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", meal.MealID)
+	assert.ElementsMatch(t, []string{"2", "4"}, meal.DishIDs)
+	assert.ElementsMatch(t, []string{"Куриный суп", "Рататуй"}, meal.DishNames)
+	assert.Len(t, meal.Recipes, 2)
+	assert.Equal(t, common.NutritionalValueAbsolute{Calories: 490, Proteins: 370, Fats: 133, Carbohydrates: 284}, meal.TotalNutrition)
+
+	// прием пищи без блюд возвращается пустым, а не ошибкой
+	meal, err = store.LoadMeal(ctx, "3")
+	assert.NoError(t, err)
+	assert.Empty(t, meal.DishIDs)
+	assert.Empty(t, meal.Recipes)
+}
+
+func testLoadMeals(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+	seedDishes(t, store)
+
+	meals, err := store.LoadMeals(ctx, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, meals)
+
+	// каждый прием пищи совпадает с результатом LoadMeal, прием пищи без блюд и неизвестный — пустые
+	meals, err = store.LoadMeals(ctx, []string{"1", "2", "3", "unknown", "2"})
+	assert.NoError(t, err)
+	assert.Len(t, meals, 4)
+	for _, mealID := range []string{"1", "2", "3", "unknown"} {
+		single, err := store.LoadMeal(ctx, mealID)
+		assert.NoError(t, err)
+		if assert.Contains(t, meals, mealID) {
+			assert.Equal(t, single.MealID, meals[mealID].MealID)
+			assert.ElementsMatch(t, single.DishIDs, meals[mealID].DishIDs)
+			assert.ElementsMatch(t, single.DishNames, meals[mealID].DishNames)
+			assert.ElementsMatch(t, single.Recipes, meals[mealID].Recipes)
+			assert.Equal(t, single.TotalNutrition, meals[mealID].TotalNutrition)
+		}
+	}
+	assert.Equal(t, common.NutritionalValueAbsolute{Calories: 490, Proteins: 370, Fats: 133, Carbohydrates: 284}, meals["2"].TotalNutrition)
+	assert.Empty(t, meals["3"].DishIDs)
+	assert.Empty(t, meals["unknown"].DishIDs)
+}
+
+func testLoadDishes(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+
+	dishes, err := store.LoadDishes(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, dishes)
+
+	seedMenu(t, store)
+	seedDishes(t, store)
+	assert.NoError(t, store.SaveDish(ctx, menu.Dish{DishID: "0", MealID: "9", Name: "Рататуй"}))
+
+	dishes, err = store.LoadDishes(ctx)
+	assert.NoError(t, err)
+	ids := make([]string, 0, len(dishes))
+	types := make([]string, 0, len(dishes))
+	for _, dish := range dishes {
+		ids = append(ids, dish.DishID)
+		types = append(types, dish.MealType)
+	}
+	// по названию, при равных названиях — по dish_id
+	assert.Equal(t, []string{"2", "1", "0", "4"}, ids)
+	// тип берется из расписания, у блюда без приема пищи он пустой
+	assert.Equal(t, []string{"lunch", "breakfast", "", "lunch"}, types)
+
+	// тип приема пищи не хранится в самом блюде
+	dish, err := store.LoadDish(ctx, "2")
+	assert.NoError(t, err)
+	assert.Empty(t, dish.MealType)
+}
+
+func testReplaceMenuRange(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+	seedDishes(t, store)
+
+	entries := []menu.Menu{{MealID: "g1", Time: at(9), MealType: "breakfast"}}
+	dishes := []menu.Dish{{DishID: "g1-1", MealID: "g1", Name: "Сырники", SourceDishID: "1"}}
+	assert.NoError(t, store.ReplaceMenuRange(ctx, "kolya", at(0), at(12), entries, dishes))
+
+	menus, err := store.LoadMenuRange(ctx, "kolya", at(0), at(23))
+	assert.NoError(t, err)
+	assert.Equal(t, []menu.Menu{
+		{MealID: "g1", Time: at(9), MealType: "breakfast"},
+		{MealID: "2", Time: at(13), MealType: "lunch"},
+	}, menus)
+
+	meal, err := store.LoadMeal(ctx, "g1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Сырники"}, meal.DishNames)
+
+	catalog, err := store.LoadDishes(ctx)
+	assert.NoError(t, err)
+	sources := make(map[string]string, len(catalog))
+	for _, dish := range catalog {
+		sources[dish.DishID] = dish.SourceDishID
+	}
+	assert.Equal(t, "1", sources["g1-1"])
+	assert.Empty(t, sources["1"])
+
+	// повторная замена удаляет только помеченные копии, блюда пользователя остаются
+	entries = []menu.Menu{{MealID: "g2", Time: at(8), MealType: "breakfast"}}
+	dishes = []menu.Dish{{DishID: "g2-1", MealID: "g2", Name: "Сырники", SourceDishID: "1"}}
+	assert.NoError(t, store.ReplaceMenuRange(ctx, "kolya", at(0), at(12), entries, dishes))
+
+	_, err = store.LoadDish(ctx, "g1-1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	_, err = store.LoadDish(ctx, "g2-1")
+	assert.NoError(t, err)
+	_, err = store.LoadDish(ctx, "1")
+	assert.NoError(t, err)
+	_, err = store.LoadDish(ctx, "4")
+	assert.NoError(t, err)
+
+	// расписание другого пользователя не затрагивается
+	_, err = store.LoadMenuEntry(ctx, "dan", "3")
+	assert.NoError(t, err)
+}
+
+func testReplaceMenuRangeRollback(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+	seedMenu(t, store)
+
+	// meal_id 3 занят другим пользователем, замена должна откатиться целиком
+	entries := []menu.Menu{
+		{MealID: "g1", Time: at(9), MealType: "breakfast"},
+		{MealID: "3", Time: at(10), MealType: "snack"},
+	}
+	dishes := []menu.Dish{{DishID: "g1-1", MealID: "g1", Name: "Сырники"}}
+	assert.Error(t, store.ReplaceMenuRange(ctx, "kolya", at(0), at(23), entries, dishes))
+
+	menus, err := store.LoadMenuRange(ctx, "kolya", at(0), at(23))
+	assert.NoError(t, err)
+	assert.Len(t, menus, 2)
+	_, err = store.LoadMenuEntry(ctx, "kolya", "g1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	_, err = store.LoadDish(ctx, "g1-1")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func testLoadStaleUsers(t *testing.T, store menu.Store) {
+	ctx := context.Background()
+
+	users, err := store.LoadStaleUsers(ctx, at(23))
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	seedMenu(t, store)
+
+	users, err = store.LoadStaleUsers(ctx, at(13))
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+
+	users, err = store.LoadStaleUsers(ctx, at(14))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kolya"}, users)
+
+	users, err = store.LoadStaleUsers(ctx, at(20))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"dan", "kolya"}, users)
+}
+
+func testLocks(t *testing.T, store menu.Store) {
+	locker, ok := store.(scheduler.Locker)
+	if !ok {
+		t.Skip("хранилище не реализует scheduler.Locker")
+	}
+	ctx := context.Background()
+
+	acquired, err := locker.AcquireLock(ctx, "job", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = locker.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// владелец может продлить свою блокировку
+	acquired, err = locker.AcquireLock(ctx, "job", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// чужой владелец не освобождает блокировку
+	assert.NoError(t, locker.ReleaseLock(ctx, "job", "b"))
+	acquired, err = locker.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	assert.NoError(t, locker.ReleaseLock(ctx, "job", "a"))
+	acquired, err = locker.AcquireLock(ctx, "job", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// истекшая блокировка перехватывается
+	acquired, err = locker.AcquireLock(ctx, "expired", "a", -time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	acquired, err = locker.AcquireLock(ctx, "expired", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
@@ -0,0 +1,180 @@
+package menu
+
+import (
+	"fmt"
+	"math/rand"
+	"menu_manager/internal/oops"
+	"sort"
+	"time"
+)
+
+// Имена встроенных стратегий переноса меню, по ним стратегия выбирается в профиле пользователя
+const (
+	StrategyKeepSlot   = "keep-slot"
+	StrategyRotate     = "rotate"
+	StrategyRandomized = "randomized-within-type"
+
+	// DefaultStrategy используется, если пользователь не выбрал стратегию
+	DefaultStrategy = StrategyKeepSlot
+)
+
+// RescheduleStrategy определяет, как устаревшее меню переносится вперед
+type RescheduleStrategy interface {
+	// Name возвращает имя стратегии
+	Name() string
+	// Reschedule возвращает новое расписание, в котором последний прием пищи приходится
+	// позже момента reference. Сдвиг выполняется целыми неделями по календарю часового пояса reference,
+	// поэтому время суток сохраняется и при переходе на летнее и зимнее время.
+	// Исходный срез не изменяется
+	Reschedule(menu []Menu, reference time.Time, rng *rand.Rand) []Menu
+}
+
+// IsKnownStrategy проверяет, что стратегия с таким именем существует
+func IsKnownStrategy(name string) bool {
+	switch name {
+	case StrategyKeepSlot, StrategyRotate, StrategyRandomized:
+		return true
+	}
+	return false
+}
+
+// NewRescheduleStrategy создает стратегию по имени. Пустое имя означает стратегию по умолчанию,
+// rotationWeeks используется только стратегией rotate
+func NewRescheduleStrategy(name string, rotationWeeks int) (RescheduleStrategy, error) {
+	switch name {
+	case "", StrategyKeepSlot:
+		return KeepSlotStrategy{}, nil
+	case StrategyRotate:
+		return RotateStrategy{Weeks: rotationWeeks}, nil
+	case StrategyRandomized:
+		return RandomizedStrategy{}, nil
+	}
+	return nil, oops.NewValidationError("reschedule_strategy", fmt.Errorf("%w: %s", oops.ErrInvalidValue, name))
+}
+
+// KeepSlotStrategy переносит меню на минимальное число недель вперед,
+// сохраняя у каждого приема пищи тип, день недели и время суток
+type KeepSlotStrategy struct{}
+
+func (KeepSlotStrategy) Name() string { return StrategyKeepSlot }
+
+func (KeepSlotStrategy) Reschedule(menu []Menu, reference time.Time, _ *rand.Rand) []Menu {
+	return shiftWeeks(menu, weeksBehind(menu, reference, 1), reference.Location())
+}
+
+// RotateStrategy считает меню циклом из Weeks недель и переносит его вперед только на целое число циклов,
+// поэтому порядок недель в ротации сохраняется. При Weeks = 0 длина цикла определяется по самому меню
+type RotateStrategy struct {
+	Weeks int
+}
+
+func (RotateStrategy) Name() string { return StrategyRotate }
+
+func (s RotateStrategy) Reschedule(menu []Menu, reference time.Time, _ *rand.Rand) []Menu {
+	cycle := s.Weeks
+	if cycle <= 0 {
+		cycle = spanWeeks(menu, reference.Location())
+	}
+	return shiftWeeks(menu, weeksBehind(menu, reference, cycle), reference.Location())
+}
+
+// RandomizedStrategy переносит меню как KeepSlotStrategy, а затем случайно перемешивает
+// приемы пищи между слотами одного типа: завтрак остается завтраком и в то же время суток
+type RandomizedStrategy struct{}
+
+func (RandomizedStrategy) Name() string { return StrategyRandomized }
+
+func (RandomizedStrategy) Reschedule(menu []Menu, reference time.Time, rng *rand.Rand) []Menu {
+	shifted := shiftWeeks(menu, weeksBehind(menu, reference, 1), reference.Location())
+
+	// упорядочиваем слоты, чтобы результат зависел только от состояния rng
+	sort.SliceStable(shifted, func(i, j int) bool {
+		if !shifted[i].Time.Equal(shifted[j].Time) {
+			return shifted[i].Time.Before(shifted[j].Time)
+		}
+		return shifted[i].MealID < shifted[j].MealID
+	})
+
+	slotsByType := make(map[string][]int)
+	types := make([]string, 0)
+	for i, m := range shifted {
+		if _, ok := slotsByType[m.MealType]; !ok {
+			types = append(types, m.MealType)
+		}
+		slotsByType[m.MealType] = append(slotsByType[m.MealType], i)
+	}
+
+	for _, mealType := range types {
+		slots := slotsByType[mealType]
+		rng.Shuffle(len(slots), func(i, j int) {
+			a, b := slots[i], slots[j]
+			shifted[a].MealID, shifted[b].MealID = shifted[b].MealID, shifted[a].MealID
+		})
+	}
+	return shifted
+}
+
+// weeksBehind возвращает минимальное кратное step число недель, после сдвига на которое
+// последний прием пищи окажется позже момента reference
+func weeksBehind(menu []Menu, reference time.Time, step int) int {
+	if len(menu) == 0 {
+		return 0
+	}
+	if step < 1 {
+		step = 1
+	}
+
+	loc := reference.Location()
+	latest := menu[0].Time
+	for _, m := range menu[1:] {
+		if m.Time.After(latest) {
+			latest = m.Time
+		}
+	}
+	if latest.After(reference) {
+		return 0
+	}
+
+	latest = latest.In(loc)
+	weeks := daysBetween(latest, reference.In(loc)) / 7
+	if !latest.AddDate(0, 0, 7*weeks).After(reference) {
+		weeks++
+	}
+	return (weeks + step - 1) / step * step
+}
+
+// spanWeeks возвращает, сколько календарных недель занимает меню
+func spanWeeks(menu []Menu, loc *time.Location) int {
+	if len(menu) == 0 {
+		return 1
+	}
+	earliest, latest := menu[0].Time, menu[0].Time
+	for _, m := range menu[1:] {
+		if m.Time.Before(earliest) {
+			earliest = m.Time
+		}
+		if m.Time.After(latest) {
+			latest = m.Time
+		}
+	}
+	return daysBetween(earliest.In(loc), latest.In(loc))/7 + 1
+}
+
+// daysBetween возвращает число календарных дней от from до to без учета времени суток
+func daysBetween(from, to time.Time) int {
+	a := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	b := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// shiftWeeks возвращает копию меню, сдвинутую на weeks недель по календарю часового пояса loc
+func shiftWeeks(menu []Menu, weeks int, loc *time.Location) []Menu {
+	shifted := make([]Menu, len(menu))
+	for i, m := range menu {
+		shifted[i] = m
+		if weeks > 0 {
+			shifted[i].Time = m.Time.In(loc).AddDate(0, 0, 7*weeks).UTC()
+		}
+	}
+	return shifted
+}
@@ -0,0 +1,160 @@
+package menu_test
+
+import (
+	"context"
+	"math/rand"
+	"menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	"menu_manager/internal/oops"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// staleWeek возвращает неделю меню со 2 по 8 марта 2024: завтрак и ужин каждый день
+func staleWeek() []menu.Menu {
+	entries := make([]menu.Menu, 0)
+	for day := 2; day <= 8; day++ {
+		entries = append(entries,
+			menu.Menu{MealID: "breakfast-" + strconv.Itoa(day), Time: time.Date(2024, 3, day, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+			menu.Menu{MealID: "dinner-" + strconv.Itoa(day), Time: time.Date(2024, 3, day, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+		)
+	}
+	return entries
+}
+
+func TestKeepSlotStrategy_CatchesUpSeveralWeeks(t *testing.T) {
+	reference := time.Date(2024, 3, 27, 12, 0, 0, 0, time.UTC)
+	current := staleWeek()
+
+	rescheduled := menu.KeepSlotStrategy{}.Reschedule(current, reference, nil)
+
+	assert.Len(t, rescheduled, len(current))
+	for i, entry := range rescheduled {
+		// три недели отставания: последний прием пищи 8 марта, сегодня 27 марта
+		assert.Equal(t, current[i].Time.AddDate(0, 0, 21), entry.Time)
+		assert.Equal(t, current[i].MealID, entry.MealID)
+		assert.Equal(t, current[i].MealType, entry.MealType)
+	}
+	assert.Equal(t, time.Date(2024, 3, 2, 8, 0, 0, 0, time.UTC), current[0].Time, "исходное меню не должно меняться")
+}
+
+func TestKeepSlotStrategy_ActualMenuUnchanged(t *testing.T) {
+	reference := time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC)
+	current := staleWeek()
+
+	assert.Equal(t, current, menu.KeepSlotStrategy{}.Reschedule(current, reference, nil))
+}
+
+func TestRotateStrategy_ShiftsByWholeCycles(t *testing.T) {
+	reference := time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)
+	current := staleWeek()
+
+	tests := []struct {
+		name  string
+		weeks int
+		shift int
+	}{
+		// отставание две недели, цикл из трех недель переносится на целый цикл
+		{"three week cycle", 3, 21},
+		{"two week cycle", 2, 14},
+		// длина цикла определяется по меню, оно занимает одну неделю
+		{"cycle from menu", 0, 14},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rescheduled := menu.RotateStrategy{Weeks: tt.weeks}.Reschedule(current, reference, nil)
+			for i, entry := range rescheduled {
+				assert.Equal(t, current[i].Time.AddDate(0, 0, tt.shift), entry.Time)
+			}
+		})
+	}
+}
+
+func TestRandomizedStrategy_KeepsMealTypeSlots(t *testing.T) {
+	reference := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	current := staleWeek()
+
+	rescheduled := menu.RandomizedStrategy{}.Reschedule(current, reference, rand.New(rand.NewSource(1)))
+
+	assert.Len(t, rescheduled, len(current))
+	seen := make(map[string]bool)
+	for _, entry := range rescheduled {
+		seen[entry.MealID] = true
+		// завтраки остаются завтраками в 08:00, ужины — ужинами в 19:00
+		assert.True(t, strings.HasPrefix(entry.MealID, entry.MealType+"-"))
+		if entry.MealType == "breakfast" {
+			assert.Equal(t, 8, entry.Time.Hour())
+		} else {
+			assert.Equal(t, 19, entry.Time.Hour())
+		}
+		assert.True(t, !entry.Time.Before(time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)))
+	}
+	assert.Len(t, seen, len(current))
+}
+
+func TestRandomizedStrategy_ReproducibleWithSeed(t *testing.T) {
+	reference := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+
+	first := menu.RandomizedStrategy{}.Reschedule(staleWeek(), reference, rand.New(rand.NewSource(42)))
+	second := menu.RandomizedStrategy{}.Reschedule(staleWeek(), reference, rand.New(rand.NewSource(42)))
+
+	assert.Equal(t, first, second)
+}
+
+func TestNewRescheduleStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"", menu.StrategyKeepSlot},
+		{menu.StrategyKeepSlot, menu.StrategyKeepSlot},
+		{menu.StrategyRotate, menu.StrategyRotate},
+		{menu.StrategyRandomized, menu.StrategyRandomized},
+	}
+	for _, tt := range tests {
+		strategy, err := menu.NewRescheduleStrategy(tt.name, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, strategy.Name())
+	}
+
+	_, err := menu.NewRescheduleStrategy("shuffle", 0)
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "reschedule_strategy", validationErr.Field)
+}
+
+func TestRescheduleMenu_UsesProfileStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	clock := func() time.Time { return time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC) }
+	newService := func() menu.Service {
+		return menu.NewService(mockStore, nil,
+			menu.WithProfiles(mockProfiles),
+			menu.WithClock(clock),
+			menu.WithRand(rand.New(rand.NewSource(7))))
+	}
+
+	ctx := context.Background()
+	mockProfiles.EXPECT().Location(ctx, "dan").Return(time.UTC, nil).AnyTimes()
+	mockProfiles.EXPECT().RescheduleStrategy(ctx, "dan").Return(menu.StrategyRandomized, 1, nil).Times(2)
+	mockProfiles.EXPECT().Violations(ctx, "dan", gomock.Any()).Return(nil, nil).AnyTimes()
+	mockStore.EXPECT().LoadMeals(ctx, gomock.Any()).Return(map[string]*menu.Meal{}, nil).Times(2)
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", gomock.Any()).Return(nil).Times(2)
+
+	// одинаковый seed дает одинаковое расписание
+	first, err := newService().RescheduleMenu(ctx, staleWeek(), "dan")
+	assert.NoError(t, err)
+	second, err := newService().RescheduleMenu(ctx, staleWeek(), "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, menu.RandomizedStrategy{}.Reschedule(staleWeek(), clock(), rand.New(rand.NewSource(7))), first)
+}
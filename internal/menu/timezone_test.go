@@ -0,0 +1,161 @@
+package menu_test
+
+import (
+	"context"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("load location %s: %v", name, err)
+	}
+	return loc
+}
+
+func TestIsActual_AcrossMidnight(t *testing.T) {
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+
+	// 20 марта 16:00 UTC — в Токио уже 21 марта 01:00
+	now := time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC)
+	dinner := []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC), MealType: "dinner"}, // 20 марта 19:00 JST
+	}
+	breakfast := []menu.Menu{
+		{MealID: "breakfast", Time: time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC), MealType: "breakfast"}, // 21 марта 08:00 JST
+	}
+
+	assert.True(t, menu.IsActual(dinner, now))
+	assert.False(t, menu.IsActual(dinner, now.In(tokyo)))
+	assert.True(t, menu.IsActual(breakfast, now.In(tokyo)))
+}
+
+func TestFindNextMeal_AcrossUserMidnight(t *testing.T) {
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+
+	now := time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC).In(tokyo) // 21 марта 01:00 JST
+	menuData := []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC), MealType: "dinner"},
+		{MealID: "breakfast", Time: time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC), MealType: "breakfast"},
+	}
+
+	mealID, err := menu.FindNextMeal(menuData, now, menu.DefaultGracePeriod)
+	assert.NoError(t, err)
+	assert.Equal(t, "breakfast", mealID)
+}
+
+func TestRescheduleMenu_KeepsWallClockAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name     string
+		current  time.Time
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			// переход на летнее время 10 марта 2024: 08:00 EST -> 08:00 EDT
+			name:     "spring forward",
+			current:  time.Date(2024, 3, 4, 13, 0, 0, 0, time.UTC),
+			now:      time.Date(2024, 3, 11, 10, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			// переход на зимнее время 3 ноября 2024: 19:00 EDT -> 19:00 EST, в UTC уже следующие сутки
+			name:     "fall back",
+			current:  time.Date(2024, 10, 28, 23, 0, 0, 0, time.UTC),
+			now:      time.Date(2024, 11, 4, 15, 0, 0, 0, time.UTC),
+			expected: time.Date(2024, 11, 5, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockStore := mocks.NewMockStore(ctrl)
+			mockProfiles := mocks.NewMockProfileProvider(ctrl)
+			clock := func() time.Time { return tt.now }
+			service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles), menu.WithClock(clock))
+
+			ctx := context.Background()
+			menuData := []menu.Menu{{MealID: "meal1", Time: tt.current, MealType: "breakfast"}}
+
+			mockProfiles.EXPECT().Location(ctx, "kolya").Return(newYork, nil)
+			mockProfiles.EXPECT().RescheduleStrategy(ctx, "kolya").Return(menu.StrategyKeepSlot, 1, nil)
+			mockStore.EXPECT().LoadMeals(ctx, []string{"meal1"}).Return(map[string]*menu.Meal{}, nil)
+			mockStore.EXPECT().UpdateMenu(ctx, "kolya", gomock.Any()).Return(nil)
+
+			updated, err := service.RescheduleMenu(ctx, menuData, "kolya")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, updated[0].Time)
+			assert.Equal(t, tt.current.In(newYork).Hour(), updated[0].Time.In(newYork).Hour())
+		})
+	}
+}
+
+func TestGetMenuView_GroupsByUserDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	clock := func() time.Time { return time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, nil, menu.WithProfiles(mockProfiles), menu.WithClock(clock))
+
+	ctx := context.Background()
+
+	// без дат период начинается с сегодняшнего дня пользователя — 21 марта по Токио
+	start := time.Date(2024, 3, 21, 0, 0, 0, 0, tokyo)
+	end := start.AddDate(0, 0, 7)
+	breakfast := menu.Menu{MealID: "breakfast", Time: time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC), MealType: "breakfast"}
+
+	mockProfiles.EXPECT().Location(ctx, "dan").Return(tokyo, nil)
+	mockStore.EXPECT().LoadMenuRange(ctx, "dan", start, end).Return([]menu.Menu{breakfast}, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"breakfast"}).Return(map[string]*menu.Meal{"breakfast": {MealID: "breakfast", DishNames: []string{"Омлет"}}}, nil)
+
+	view, err := service.GetMenuView(ctx, "dan", time.Time{}, time.Time{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-21", view.From)
+	assert.Equal(t, "2024-03-21", view.Days[0].Date)
+	assert.Len(t, view.Days[0].Meals, 1)
+}
+
+func TestGetMeal_UsesUserClock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tokyo := mustLoadLocation(t, "Asia/Tokyo")
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	mockProfiles := mocks.NewMockProfileProvider(ctrl)
+	clock := func() time.Time { return time.Date(2024, 3, 20, 16, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, mockClient, menu.WithProfiles(mockProfiles), menu.WithClock(clock))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 10, 0, 0, 0, time.UTC), MealType: "dinner"},
+		{MealID: "breakfast", Time: time.Date(2024, 3, 20, 23, 0, 0, 0, time.UTC), MealType: "breakfast"},
+	}
+	breakfast := &menu.Meal{MealID: "breakfast"}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	mockProfiles.EXPECT().Location(ctx, "dan").Return(tokyo, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"breakfast"}).Return(map[string]*menu.Meal{"breakfast": breakfast}, nil)
+	mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(&menu.ShoppingList{}, nil)
+
+	details, err := service.GetMeal(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, breakfast, details.Meal)
+}
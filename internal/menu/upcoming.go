@@ -0,0 +1,176 @@
+package menu
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/oops"
+	"sort"
+	"time"
+)
+
+const (
+	// DefaultGracePeriod сколько времени после начала прием пищи еще считается текущим
+	DefaultGracePeriod = 30 * time.Minute
+
+	// defaultUpcomingLimit и maxUpcomingLimit ограничивают число приемов пищи в ответе GetUpcomingMeals
+	defaultUpcomingLimit = 5
+	maxUpcomingLimit     = 50
+)
+
+// NextMeals возвращает предстоящие приемы пищи в порядке времени, не больше limit (при limit <= 0 — все).
+// Прием пищи, начавшийся не раньше чем grace назад, еще считается предстоящим: пользователь как раз ест
+func NextMeals(menu []Menu, now time.Time, grace time.Duration, limit int) []Menu {
+	since := now.Add(-grace)
+
+	upcoming := make([]Menu, 0)
+	for _, entry := range menu {
+		if !entry.Time.Before(since) {
+			upcoming = append(upcoming, entry)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		if !upcoming[i].Time.Equal(upcoming[j].Time) {
+			return upcoming[i].Time.Before(upcoming[j].Time)
+		}
+		return upcoming[i].MealID < upcoming[j].MealID
+	})
+
+	if limit > 0 && len(upcoming) > limit {
+		upcoming = upcoming[:limit]
+	}
+	return upcoming
+}
+
+// FindNextMeal возвращает id ближайшего предстоящего приема пищи, в том числе в следующие дни
+func FindNextMeal(menu []Menu, now time.Time, grace time.Duration) (string, error) {
+	next := NextMeals(menu, now, grace, 1)
+	if len(next) == 0 {
+		return "", oops.ErrNoUpcomingMeal
+	}
+	return next[0].MealID, nil
+}
+
+// GetUpcomingMeals возвращает до limit ближайших приемов пищи пользователя с блюдами и пищевой ценностью.
+// Приемы пищи, нарушающие профиль пользователя, пропускаются
+func (s *AppService) GetUpcomingMeals(ctx context.Context, userID string, limit int) ([]ScheduledMeal, error) {
+	if limit == 0 {
+		limit = defaultUpcomingLimit
+	}
+	if limit < 0 || limit > maxUpcomingLimit {
+		return nil, oops.NewValidationError("limit", oops.ErrInvalidValue)
+	}
+
+	upcoming, err := s.upcoming(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// приемы пищи всех предстоящих записей загружаются и проверяются по профилю одним запросом
+	permitted, loaded, err := s.permittedMeals(ctx, userID, upcoming)
+	if err != nil {
+		return nil, err
+	}
+	if len(permitted) > limit {
+		permitted = permitted[:limit]
+	}
+
+	meals := make([]ScheduledMeal, 0, len(permitted))
+	for _, entry := range permitted {
+		meals = append(meals, newScheduledMeal(entry, loaded[entry.MealID]))
+	}
+	return meals, nil
+}
+
+// upcoming возвращает все предстоящие приемы пищи пользователя в порядке времени.
+// Если предстоящих приемов пищи нет, меню сначала переносится вперед
+func (s *AppService) upcoming(ctx context.Context, userID string) ([]Menu, error) {
+	menu, err := s.storage.LoadMenu(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// все сравнения ведутся в часовом поясе пользователя
+	now, err := s.userNow(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	upcoming := NextMeals(menu, now, s.grace, 0)
+	if len(upcoming) > 0 {
+		return upcoming, nil
+	}
+
+	// меню устарело, переносим его по стратегии пользователя
+	menu, err = s.rollForward(ctx, userID, menu, 0)
+	if err != nil {
+		return nil, err
+	}
+	return NextMeals(menu, now, s.grace, 0), nil
+}
+
+// rollForward переносит устаревшее меню пользователя. Параллельные запросы одного пользователя
+// и фоновый планировщик дожидаются одного переноса, а не выполняют его каждый сам
+func (s *AppService) rollForward(ctx context.Context, userID string, menu []Menu, lookahead time.Duration) ([]Menu, error) {
+	rescheduled, err, _ := s.reschedules.Do(userID, func() (any, error) {
+		return s.reschedule(ctx, menu, userID, lookahead)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rescheduled.([]Menu), nil
+}
+
+// splitPast делит меню на прошедшие приемы пищи и те, что еще предстоят с учетом grace
+func splitPast(menu []Menu, now time.Time, grace time.Duration) (past, upcoming []Menu) {
+	since := now.Add(-grace)
+	for _, entry := range menu {
+		if entry.Time.Before(since) {
+			past = append(past, entry)
+		} else {
+			upcoming = append(upcoming, entry)
+		}
+	}
+	return past, upcoming
+}
+
+// RescheduleStale заранее переносит меню всех пользователей, у которых предстоящие приемы пищи
+// закончатся в ближайшие lookahead (WithRescheduleLookahead), чтобы первый запрос новой недели не ждал записи в БД.
+// Ошибка переноса меню одного пользователя не останавливает перенос остальных. Возвращает число перенесенных меню
+func (s *AppService) RescheduleStale(ctx context.Context) (int, error) {
+	users, err := s.storage.LoadStaleUsers(ctx, s.now().Add(s.lookahead-s.grace))
+	if err != nil {
+		return 0, err
+	}
+
+	var rescheduled int
+	var errs []error
+	for _, userID := range users {
+		if err := ctx.Err(); err != nil {
+			return rescheduled, err
+		}
+
+		menu, err := s.storage.LoadMenu(ctx, userID)
+		if err != nil {
+			errs = append(errs, oops.NewUserError(userID, err))
+			continue
+		}
+
+		now, err := s.userNow(ctx, userID)
+		if err != nil {
+			errs = append(errs, oops.NewUserError(userID, err))
+			continue
+		}
+		// все приемы пищи еще впереди, переносить пока нечего
+		if past, _ := splitPast(menu, now, s.grace); len(past) == 0 {
+			continue
+		}
+
+		if _, err := s.rollForward(ctx, userID, menu, s.lookahead); err != nil {
+			errs = append(errs, oops.NewUserError(userID, err))
+			continue
+		}
+		rescheduled++
+	}
+	return rescheduled, errors.Join(errs...)
+}
@@ -0,0 +1,190 @@
+package menu_test
+
+import (
+	"context"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMeal_AfterLastMealOfDay(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	clock := func() time.Time { return time.Date(2024, 3, 20, 21, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, mockClient, menu.WithClock(clock))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+		{MealID: "breakfast", Time: time.Date(2024, 3, 21, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+	}
+	breakfast := &menu.Meal{MealID: "breakfast"}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"breakfast"}).Return(map[string]*menu.Meal{"breakfast": breakfast}, nil)
+	mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(&menu.ShoppingList{}, nil)
+
+	details, err := service.GetMeal(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, breakfast, details.Meal)
+}
+
+func TestGetMeal_ReschedulesWhenNothingUpcoming(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	mockClient := mocks.NewMockClient(ctrl)
+	// среда 20 марта 21:00, ужин уже прошел
+	clock := func() time.Time { return time.Date(2024, 3, 20, 21, 0, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, mockClient, menu.WithClock(clock))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "breakfast", Time: time.Date(2024, 3, 14, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	rescheduled := []menu.Menu{
+		{MealID: "breakfast", Time: time.Date(2024, 3, 21, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+		{MealID: "dinner", Time: time.Date(2024, 3, 27, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	breakfast := &menu.Meal{MealID: "breakfast"}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", rescheduled).Return(nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"breakfast", "dinner"}).
+		Return(map[string]*menu.Meal{"breakfast": breakfast, "dinner": {MealID: "dinner"}}, nil)
+	mockClient.EXPECT().GetProducts(ctx, gomock.Any()).Return(&menu.ShoppingList{}, nil)
+
+	details, err := service.GetMeal(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, breakfast, details.Meal)
+}
+
+func TestGetMeal_NoUpcomingMeal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return([]menu.Menu{}, nil)
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", []menu.Menu{}).Return(nil)
+
+	_, err := service.GetMeal(ctx, "dan")
+	assert.ErrorIs(t, err, oops.ErrNoUpcomingMeal)
+}
+
+func TestGetUpcomingMeals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	clock := func() time.Time { return time.Date(2024, 3, 20, 13, 10, 0, 0, time.UTC) }
+	service := menu.NewService(mockStore, nil, menu.WithClock(clock), menu.WithGracePeriod(5*time.Minute))
+
+	ctx := context.Background()
+	menuData := []menu.Menu{
+		{MealID: "lunch", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "breakfast", Time: time.Date(2024, 3, 21, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+		{MealID: "dinner", Time: time.Date(2024, 3, 20, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+		{MealID: "lunch2", Time: time.Date(2024, 3, 21, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+	}
+
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(menuData, nil)
+	mockStore.EXPECT().LoadMeals(ctx, []string{"dinner", "breakfast", "lunch2"}).Return(map[string]*menu.Meal{
+		"dinner":    {MealID: "dinner", DishNames: []string{"Плов"}},
+		"breakfast": {MealID: "breakfast", DishNames: []string{"Омлет"}},
+		"lunch2":    {MealID: "lunch2", DishNames: []string{"Суп"}},
+	}, nil)
+
+	// обед начался 10 минут назад, это дольше периода grace
+	meals, err := service.GetUpcomingMeals(ctx, "dan", 2)
+	assert.NoError(t, err)
+	assert.Len(t, meals, 2)
+	assert.Equal(t, "dinner", meals[0].MealID)
+	assert.Equal(t, []string{"Плов"}, meals[0].DishNames)
+	assert.Equal(t, "breakfast", meals[1].MealID)
+	assert.Equal(t, menu.MealTypeBreakfast, meals[1].MealType)
+}
+
+func TestGetUpcomingMeals_LimitTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := menu.NewService(mocks.NewMockStore(ctrl), nil)
+
+	_, err := service.GetUpcomingMeals(context.Background(), "dan", 500)
+
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "limit", validationErr.Field)
+}
+
+func TestRescheduleStale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	now := time.Date(2024, 3, 24, 20, 0, 0, 0, time.UTC)
+	service := menu.NewService(mockStore, nil, menu.WithClock(func() time.Time { return now }))
+
+	ctx := context.Background()
+	danMenu := []menu.Menu{{MealID: "dinner", Time: time.Date(2024, 3, 24, 19, 0, 0, 0, time.UTC), MealType: "dinner"}}
+	mockStore.EXPECT().LoadStaleUsers(ctx, now.Add(-menu.DefaultGracePeriod)).Return([]string{"kolya", "dan"}, nil)
+	mockStore.EXPECT().LoadMenu(ctx, "kolya").Return(nil, oops.ErrNoData)
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(danMenu, nil)
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 31, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}).Return(nil)
+
+	rescheduled, err := service.RescheduleStale(ctx)
+	assert.Equal(t, 1, rescheduled)
+	assert.ErrorIs(t, err, oops.ErrNoData)
+	// id пользователя передается полем ошибки, журнал заменит его псевдонимом
+	var userErr *oops.UserError
+	assert.ErrorAs(t, err, &userErr)
+	assert.Equal(t, "kolya", userErr.UserID)
+}
+
+func TestRescheduleStale_Lookahead(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	// воскресенье 24 марта 08:00, ужин сегодня еще впереди, но неделя заканчивается в пределах lookahead
+	now := time.Date(2024, 3, 24, 8, 0, 0, 0, time.UTC)
+	lookahead := 24 * time.Hour
+	service := menu.NewService(mockStore, nil,
+		menu.WithClock(func() time.Time { return now }), menu.WithRescheduleLookahead(lookahead))
+
+	ctx := context.Background()
+	danMenu := []menu.Menu{
+		{MealID: "lunch", Time: time.Date(2024, 3, 18, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+		{MealID: "dinner", Time: time.Date(2024, 3, 24, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+	}
+	// все приемы пищи еще впереди, переносить нечего
+	kolyaMenu := []menu.Menu{{MealID: "brunch", Time: time.Date(2024, 3, 24, 12, 0, 0, 0, time.UTC), MealType: "lunch"}}
+
+	mockStore.EXPECT().LoadStaleUsers(ctx, now.Add(lookahead-menu.DefaultGracePeriod)).Return([]string{"dan", "kolya"}, nil)
+	mockStore.EXPECT().LoadMenu(ctx, "dan").Return(danMenu, nil)
+	mockStore.EXPECT().LoadMenu(ctx, "kolya").Return(kolyaMenu, nil)
+	// сегодняшний ужин остается на месте, прошедший обед переносится на следующую неделю
+	mockStore.EXPECT().UpdateMenu(ctx, "dan", []menu.Menu{
+		{MealID: "dinner", Time: time.Date(2024, 3, 24, 19, 0, 0, 0, time.UTC), MealType: "dinner"},
+		{MealID: "lunch", Time: time.Date(2024, 3, 25, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+	}).Return(nil)
+
+	rescheduled, err := service.RescheduleStale(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, rescheduled)
+}
@@ -0,0 +1,52 @@
+package menu
+
+import (
+	"fmt"
+	"menu_manager/internal/oops"
+)
+
+// ValidateMenu проверяет корректность записи расписания перед сохранением
+func ValidateMenu(entry Menu) error {
+	if entry.MealID == "" {
+		return oops.NewValidationError("meal_id", oops.ErrEmptyValue)
+	}
+	if entry.MealType == "" {
+		return oops.NewValidationError("meal_type", oops.ErrEmptyValue)
+	}
+	if !MealType(entry.MealType).IsValid() {
+		return oops.NewValidationError("meal_type", oops.ErrInvalidValue)
+	}
+	if entry.Time.IsZero() {
+		return oops.NewValidationError("eat_date", oops.ErrEmptyValue)
+	}
+	return nil
+}
+
+// ValidateDish проверяет корректность блюда и его рецепта перед сохранением
+func ValidateDish(dish Dish) error {
+	if dish.DishID == "" {
+		return oops.NewValidationError("id", oops.ErrEmptyValue)
+	}
+	if dish.MealID == "" {
+		return oops.NewValidationError("meal_id", oops.ErrEmptyValue)
+	}
+	if dish.Name == "" {
+		return oops.NewValidationError("name", oops.ErrEmptyValue)
+	}
+	if len(dish.Recipe.Ingredients) == 0 {
+		return oops.NewValidationError("recipe.ingredients", oops.ErrEmptyValue)
+	}
+	for i, ingredient := range dish.Recipe.Ingredients {
+		field := fmt.Sprintf("recipe.ingredients[%d]", i)
+		if ingredient.ProductID == "" {
+			return oops.NewValidationError(field+".product_id", oops.ErrEmptyValue)
+		}
+		if ingredient.Amount == 0 {
+			return oops.NewValidationError(field+".amount", oops.ErrInvalidValue)
+		}
+		if ingredient.Unit == "" {
+			return oops.NewValidationError(field+".unit", oops.ErrEmptyValue)
+		}
+	}
+	return nil
+}
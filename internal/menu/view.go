@@ -0,0 +1,131 @@
+package menu
+
+import (
+	"context"
+	"menu_manager/internal/oops"
+	"time"
+)
+
+// DateLayout формат календарной даты в запросах и ответах API
+const DateLayout = "2006-01-02"
+
+const (
+	// defaultViewDays длина периода по умолчанию — неделя
+	defaultViewDays = 7
+	// maxViewDays ограничивает период, чтобы не загружать слишком много приемов пищи за раз
+	maxViewDays = 31
+)
+
+// GetMenuView возвращает приемы пищи за период с блюдами и пищевой ценностью, сгруппированные по дням.
+// Приемы пищи, нарушающие профиль пользователя, пропускаются.
+// from и to задают календарные даты (время суток игнорируется) в часовом поясе пользователя, to включается в период
+func (s *AppService) GetMenuView(ctx context.Context, userID string, from, to time.Time) (*MenuView, error) {
+	now, err := s.userNow(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	loc := now.Location()
+
+	start, end, err := viewPeriod(from, to, now, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.storage.LoadMenuRange(ctx, userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// заготавливаем все дни периода, чтобы пустые дни тоже попали в ответ
+	view := &MenuView{
+		From: start.Format(DateLayout),
+		To:   end.AddDate(0, 0, -1).Format(DateLayout),
+		Days: make([]DayMenu, 0, maxViewDays),
+	}
+	dayIndex := make(map[string]int)
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(DateLayout)
+		dayIndex[date] = len(view.Days)
+		view.Days = append(view.Days, DayMenu{Date: date, Meals: make([]ScheduledMeal, 0)})
+	}
+
+	// блюда всех приемов пищи периода загружаются одним запросом
+	meals, err := s.loadMeals(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	// приемы пищи, нарушающие профиль пользователя, в меню не показываются
+	forbidden, err := s.forbiddenMeals(ctx, userID, meals)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		meal := meals[entry.MealID]
+		i, ok := dayIndex[entry.Time.In(loc).Format(DateLayout)]
+		if !ok || forbidden[entry.MealID] {
+			continue
+		}
+		day := &view.Days[i]
+		day.Meals = append(day.Meals, newScheduledMeal(entry, meal))
+		day.TotalNutrition = day.TotalNutrition.AddAbsoluteValue(meal.TotalNutrition)
+	}
+	return view, nil
+}
+
+// loadMeals загружает приемы пищи расписания одним запросом к хранилищу.
+// Прием пищи, которого хранилище не вернуло, считается пустым
+func (s *AppService) loadMeals(ctx context.Context, entries []Menu) (map[string]*Meal, error) {
+	if len(entries) == 0 {
+		return map[string]*Meal{}, nil
+	}
+
+	mealIDs := make([]string, len(entries))
+	for i, entry := range entries {
+		mealIDs[i] = entry.MealID
+	}
+	meals, err := s.storage.LoadMeals(ctx, mealIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mealID := range mealIDs {
+		if meals[mealID] == nil {
+			meals[mealID] = &Meal{MealID: mealID}
+		}
+	}
+	return meals, nil
+}
+
+// viewPeriod переводит календарные даты в полуинтервал [start, end) в часовом поясе loc
+func viewPeriod(from, to, now time.Time, loc *time.Location) (time.Time, time.Time, error) {
+	if from.IsZero() {
+		from = now
+	}
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+
+	if to.IsZero() {
+		return start, start.AddDate(0, 0, defaultViewDays), nil
+	}
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, loc).AddDate(0, 0, 1)
+
+	if !end.After(start) {
+		return time.Time{}, time.Time{}, oops.NewValidationError("to", oops.ErrInvalidDates)
+	}
+	if end.After(start.AddDate(0, 0, maxViewDays)) {
+		return time.Time{}, time.Time{}, oops.NewValidationError("to", oops.ErrInvalidValue)
+	}
+	return start, end, nil
+}
+
+// newScheduledMeal объединяет запись расписания с составом приема пищи
+func newScheduledMeal(entry Menu, meal *Meal) ScheduledMeal {
+	return ScheduledMeal{
+		MealID:         entry.MealID,
+		MealType:       MealType(entry.MealType),
+		Time:           entry.Time,
+		DishNames:      meal.DishNames,
+		TotalNutrition: meal.TotalNutrition,
+	}
+}
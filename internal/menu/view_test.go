@@ -0,0 +1,72 @@
+package menu_test
+
+import (
+	"context"
+	menu "menu_manager/internal/menu"
+	mocks "menu_manager/internal/menu/mock"
+	common "menu_manager/internal/models"
+	"menu_manager/internal/oops"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMenuView(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := menu.NewService(mockStore, nil)
+
+	ctx := context.Background()
+	from := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)
+
+	entries := []menu.Menu{
+		{MealID: "1", Time: time.Date(2024, 3, 20, 8, 0, 0, 0, time.UTC), MealType: "breakfast"},
+		{MealID: "2", Time: time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC), MealType: "lunch"},
+	}
+	breakfast := &menu.Meal{MealID: "1", DishNames: []string{"Овсяная каша"}, TotalNutrition: common.NutritionalValueAbsolute{Proteins: 12, Calories: 350}}
+	lunch := &menu.Meal{MealID: "2", DishNames: []string{"Куриный суп", "Рататуй"}, TotalNutrition: common.NutritionalValueAbsolute{Proteins: 35, Calories: 450}}
+
+	mockStore.EXPECT().LoadMenuRange(ctx, "123", start, end).Return(entries, nil)
+	// все приемы пищи периода загружаются одним запросом
+	mockStore.EXPECT().LoadMeals(ctx, []string{"1", "2"}).Return(map[string]*menu.Meal{"1": breakfast, "2": lunch}, nil)
+
+	view, err := service.GetMenuView(ctx, "123", from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, "2024-03-18", view.From)
+	assert.Equal(t, "2024-03-20", view.To)
+	assert.Len(t, view.Days, 3)
+
+	assert.Empty(t, view.Days[0].Meals)
+	assert.Empty(t, view.Days[1].Meals)
+
+	day := view.Days[2]
+	assert.Equal(t, "2024-03-20", day.Date)
+	assert.Len(t, day.Meals, 2)
+	assert.Equal(t, menu.MealTypeLunch, day.Meals[1].MealType)
+	assert.Equal(t, []string{"Куриный суп", "Рататуй"}, day.Meals[1].DishNames)
+	assert.Equal(t, common.NutritionalValueAbsolute{Proteins: 47, Calories: 800}, day.TotalNutrition)
+}
+
+func TestGetMenuView_InvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := menu.NewService(mocks.NewMockStore(ctrl), nil)
+
+	from := time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.GetMenuView(context.Background(), "123", from, from.AddDate(0, 0, -1))
+	assert.ErrorIs(t, err, oops.ErrInvalidDates)
+
+	_, err = service.GetMenuView(context.Background(), "123", from, from.AddDate(0, 1, 1))
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "to", validationErr.Field)
+}
@@ -0,0 +1,307 @@
+// Package migrate применяет встроенные в бинарник миграции схемы БД.
+// Версия схемы хранится в таблице schema_migrations в формате golang-migrate,
+// поэтому базы, размеченные утилитой migrate, продолжают мигрировать с той же версии
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"menu_manager/migrations"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	// lockName имя блокировки MySQL, под которой выполняются миграции
+	lockName = "menu_manager_migrate"
+	// lockKey ключ advisory-блокировки PostgreSQL
+	lockKey = 7_316_014_232_015
+	// lockTimeout сколько секунд MySQL ждет блокировку, занятую другим экземпляром
+	lockTimeout = 60
+)
+
+// ErrDirty схема осталась в промежуточном состоянии после неудачной миграции,
+// ее нужно исправить вручную и сбросить флаг dirty в schema_migrations
+var ErrDirty = errors.New("схема БД помечена как dirty")
+
+// Status состояние одной миграции
+type Status struct {
+	Version uint64
+	Name    string
+	Applied bool
+}
+
+// Migrator применяет и откатывает миграции одной БД.
+// Параллельные запуски из нескольких экземпляров сервиса сериализуются блокировкой в самой БД
+type Migrator struct {
+	db         *sqlx.DB
+	dialect    string
+	migrations []Migration
+}
+
+// New создает Migrator для БД dialect (mysql, postgres или sqlite) с миграциями из migrations.FS
+func New(db *sqlx.DB, dialect string) (*Migrator, error) {
+	fsys, err := fs.Sub(migrations.FS, dialect)
+	if err != nil {
+		return nil, fmt.Errorf("нет миграций для %q: %w", dialect, err)
+	}
+	return NewFromFS(db, dialect, fsys)
+}
+
+// NewFromFS создает Migrator с миграциями из fsys
+func NewFromFS(db *sqlx.DB, dialect string, fsys fs.FS) (*Migrator, error) {
+	switch dialect {
+	case "mysql", "postgres", "sqlite":
+	default:
+		return nil, fmt.Errorf("миграции не поддерживаются для %q", dialect)
+	}
+
+	list, err := loadMigrations(fsys)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("нет миграций для %q", dialect)
+	}
+	return &Migrator{db: db, dialect: dialect, migrations: list}, nil
+}
+
+// Up применяет все еще не примененные миграции и возвращает их количество
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(q querier) error {
+		version, err := m.currentVersion(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if err = m.apply(ctx, q, migration.Version, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("миграция %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down откатывает steps последних примененных миграций и возвращает их количество
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("количество откатываемых миграций должно быть положительным, получено %d", steps)
+	}
+
+	reverted := 0
+	err := m.locked(ctx, func(q querier) error {
+		version, err := m.currentVersion(ctx, q)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("у миграции %06d_%s нет файла down", migration.Version, migration.Name)
+			}
+
+			previous := uint64(0)
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err = m.apply(ctx, q, migration.Version, migration.Down, previous); err != nil {
+				return fmt.Errorf("откат миграции %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status возвращает текущую версию схемы, флаг dirty и состояние каждой миграции
+func (m *Migrator) Status(ctx context.Context) (uint64, bool, []Status, error) {
+	var (
+		version uint64
+		dirty   bool
+	)
+	err := m.withConn(ctx, func(conn *sql.Conn) error {
+		if err := m.ensureTable(ctx, conn); err != nil {
+			return err
+		}
+		var err error
+		version, dirty, err = m.readVersion(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return 0, false, nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= version,
+		})
+	}
+	return version, dirty, statuses, nil
+}
+
+// Version возвращает примененную версию схемы и флаг dirty. В отличие от Status, таблица schema_migrations
+// не создается, поэтому запрос подходит для проверки готовности: у непромигрированной базы он завершается ошибкой
+func (m *Migrator) Version(ctx context.Context) (uint64, bool, error) {
+	return m.readVersion(ctx, m.db)
+}
+
+// Latest возвращает версию последней встроенной миграции
+func (m *Migrator) Latest() uint64 {
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// querier общая часть *sql.Conn, *sql.Tx и *sqlx.DB
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// locked выполняет fn под блокировкой миграций.
+// В PostgreSQL и SQLite DDL транзакционен, поэтому все миграции запуска выполняются в одной транзакции
+// и при ошибке откатываются целиком. В MySQL DDL не транзакционен: миграции выполняются по одной
+// на том же соединении, что держит GET_LOCK, а прерванная миграция оставляет флаг dirty
+func (m *Migrator) locked(ctx context.Context, fn func(q querier) error) error {
+	return m.withConn(ctx, func(conn *sql.Conn) error {
+		switch m.dialect {
+		case "mysql":
+			return m.lockedMySQL(ctx, conn, fn)
+		default:
+			return m.lockedTx(ctx, conn, fn)
+		}
+	})
+}
+
+// lockedMySQL выполняет fn на соединении conn под GET_LOCK. Блокировка MySQL принадлежит соединению,
+// поэтому все миграции выполняются на conn, а при обрыве связи блокировка снимается вместе с ним
+func (m *Migrator) lockedMySQL(ctx context.Context, conn *sql.Conn, fn func(q querier) error) error {
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeout).Scan(&acquired); err != nil {
+		return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+	}
+	if acquired.Int64 != 1 {
+		return fmt.Errorf("блокировка миграций занята другим экземпляром дольше %d секунд", lockTimeout)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT RELEASE_LOCK(?)", lockName)
+
+	if err := m.ensureTable(ctx, conn); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+func (m *Migrator) lockedTx(ctx context.Context, conn *sql.Conn, fn func(q querier) error) error {
+	// в SQLite транзакция открывается как BEGIN IMMEDIATE (_txlock=immediate в DSN)
+	// и сразу захватывает блокировку записи в файл базы
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию миграций: %w", err)
+	}
+	defer tx.Rollback()
+
+	if m.dialect == "postgres" {
+		if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", lockKey); err != nil {
+			return fmt.Errorf("не удалось получить блокировку миграций: %w", err)
+		}
+	}
+	if err = m.ensureTable(ctx, tx); err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("не удалось получить соединение с БД: %w", err)
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// apply выполняет SQL миграции version и записывает в schema_migrations версию target
+func (m *Migrator) apply(ctx context.Context, q querier, version uint64, script string, target uint64) error {
+	if err := m.setVersion(ctx, q, version, true); err != nil {
+		return err
+	}
+	for _, statement := range splitStatements(script) {
+		if _, err := q.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return m.setVersion(ctx, q, target, false)
+}
+
+// currentVersion возвращает примененную версию схемы или ErrDirty
+func (m *Migrator) currentVersion(ctx context.Context, q querier) (uint64, error) {
+	version, dirty, err := m.readVersion(ctx, q)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w на версии %d", ErrDirty, version)
+	}
+	return version, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context, q querier) error {
+	_, err := q.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT NOT NULL PRIMARY KEY,
+		dirty BOOLEAN NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("не удалось создать таблицу schema_migrations: %w", err)
+	}
+	return nil
+}
+
+func (m *Migrator) readVersion(ctx context.Context, q querier) (uint64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := q.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("не удалось прочитать версию схемы: %w", err)
+	}
+	return uint64(version), dirty, nil
+}
+
+// setVersion заменяет единственную строку schema_migrations. Версия 0 означает пустую схему
+func (m *Migrator) setVersion(ctx context.Context, q querier, version uint64, dirty bool) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return fmt.Errorf("не удалось обновить версию схемы: %w", err)
+	}
+	if version == 0 && !dirty {
+		return nil
+	}
+
+	query := m.db.Rebind("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)")
+	if _, err := q.ExecContext(ctx, query, int64(version), dirty); err != nil {
+		return fmt.Errorf("не удалось обновить версию схемы: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package migrate_test
+
+import (
+	"context"
+	"menu_manager/internal/menu/sqlite"
+	"menu_manager/internal/migrate"
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func openSQLite(t *testing.T, path string) *sqlx.DB {
+	t.Helper()
+	db, err := sqlite.Open(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestUpDownStatus(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	ctx := context.Background()
+
+	migrator, err := migrate.New(db, "sqlite")
+	assert.NoError(t, err)
+
+	version, dirty, statuses, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), version)
+	assert.False(t, dirty)
+	assert.Len(t, statuses, 6)
+	assert.False(t, statuses[0].Applied)
+
+	applied, err := migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, applied)
+
+	// повторный запуск ничего не делает
+	applied, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, applied)
+
+	version, _, statuses, err = migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), version)
+	assert.Equal(t, migrate.Status{Version: 5, Name: "create_scheduler_locks_table", Applied: true}, statuses[4])
+	assert.Equal(t, migrate.Status{Version: 6, Name: "add_dish_source", Applied: true}, statuses[5])
+
+	reverted, err := migrator.Down(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reverted)
+
+	version, _, statuses, err = migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), version)
+	assert.True(t, statuses[3].Applied)
+	assert.False(t, statuses[4].Applied)
+
+	var count int
+	assert.Error(t, db.Get(&count, "SELECT COUNT(*) FROM scheduler_locks"))
+	assert.NoError(t, db.Get(&count, "SELECT COUNT(*) FROM menu"))
+
+	reverted, err = migrator.Down(ctx, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, reverted)
+
+	version, _, _, err = migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), version)
+	assert.Error(t, db.Get(&count, "SELECT COUNT(*) FROM menu"))
+}
+
+func TestVersion(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	ctx := context.Background()
+
+	migrator, err := migrate.New(db, "sqlite")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), migrator.Latest())
+
+	// Version не создает schema_migrations, поэтому у пустой базы возвращает ошибку
+	_, _, err = migrator.Version(ctx)
+	assert.Error(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	version, dirty, err := migrator.Version(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(6), version)
+	assert.False(t, dirty)
+}
+
+func TestUp_FailedMigrationRollsBack(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	ctx := context.Background()
+
+	migrator, err := migrate.NewFromFS(db, "sqlite", fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER);")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a;")},
+		"000002_broken.up.sql":     {Data: []byte("CREATE TABLE b (id INTEGER); INSERT INTO missing VALUES (1);")},
+	})
+	assert.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.ErrorContains(t, err, "000002_broken")
+	assert.NotContains(t, err.Error(), "000001")
+
+	// SQLite выполняет запуск в одной транзакции, поэтому схема остается нетронутой
+	version, dirty, _, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), version)
+	assert.False(t, dirty)
+
+	var count int
+	assert.Error(t, db.Get(&count, "SELECT COUNT(*) FROM a"))
+}
+
+func TestUp_Dirty(t *testing.T) {
+	db := openSQLite(t, ":memory:")
+	ctx := context.Background()
+
+	migrator, err := migrate.New(db, "sqlite")
+	assert.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+
+	_, err = db.Exec("UPDATE schema_migrations SET dirty = 1")
+	assert.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, migrate.ErrDirty)
+	_, err = migrator.Down(ctx, 1)
+	assert.ErrorIs(t, err, migrate.ErrDirty)
+
+	_, dirty, _, err := migrator.Status(ctx)
+	assert.NoError(t, err)
+	assert.True(t, dirty)
+}
+
+func TestUp_Concurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "menu.db")
+	ctx := context.Background()
+
+	const instances = 4
+	applied := make([]int, instances)
+	errs := make([]error, instances)
+
+	var wg sync.WaitGroup
+	for i := range instances {
+		// у каждого экземпляра свое подключение к общему файлу базы
+		migrator, err := migrate.New(openSQLite(t, path), "sqlite")
+		assert.NoError(t, err)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			applied[i], errs[i] = migrator.Up(ctx)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range instances {
+		assert.NoError(t, errs[i])
+		total += applied[i]
+	}
+	assert.Equal(t, 6, total)
+}
+
+func TestUp_MySQLLockBusy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT GET_LOCK\(\?, \?\)`).
+		WithArgs("menu_manager_migrate", 60).
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(0))
+
+	migrator, err := migrate.New(sqlx.NewDb(db, "mysql"), "mysql")
+	assert.NoError(t, err)
+
+	_, err = migrator.Up(context.Background())
+	assert.ErrorContains(t, err, "занята другим экземпляром")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUp_MySQL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT GET_LOCK`).WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT version, dirty FROM schema_migrations`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "dirty"}).AddRow(1, false))
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(2, true).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`CREATE TABLE b \(id INT\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO b VALUES \(';'\)`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(2, false).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`SELECT RELEASE_LOCK`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	migrator, err := migrate.NewFromFS(sqlx.NewDb(db, "mysql"), "mysql", fstest.MapFS{
+		"000001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INT);")},
+		"000002_create_b.up.sql": {Data: []byte("-- комментарий; не разделяет запросы\nCREATE TABLE b (id INT);\nINSERT INTO b VALUES (';');")},
+	})
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNew_UnknownDialect(t *testing.T) {
+	_, err := migrate.New(nil, "redis")
+	assert.Error(t, err)
+}
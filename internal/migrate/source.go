@@ -0,0 +1,133 @@
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration одна версия схемы: SQL для применения и отката
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// loadMigrations читает из fsys файлы вида 000001_name.up.sql и 000001_name.down.sql
+// и возвращает миграции в порядке версий
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("не удалось прочитать каталог миграций: %w", err)
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("не удалось прочитать миграцию %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("у версии %d разные имена миграций: %s и %s", version, m.Name, name)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("у миграции %06d_%s нет файла up", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// parseFileName разбирает имя файла 000001_name.up.sql
+func parseFileName(file string) (uint64, string, string, error) {
+	base := strings.TrimSuffix(file, ".sql")
+	base, direction, ok := cutLast(base, ".")
+	if !ok || (direction != "up" && direction != "down") {
+		return 0, "", "", fmt.Errorf("миграция %s: ожидается суффикс .up.sql или .down.sql", file)
+	}
+	rawVersion, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("миграция %s: ожидается имя вида 000001_name", file)
+	}
+	version, err := strconv.ParseUint(rawVersion, 10, 64)
+	if err != nil || version == 0 {
+		return 0, "", "", fmt.Errorf("миграция %s: некорректная версия %q", file, rawVersion)
+	}
+	return version, name, direction, nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// splitStatements делит SQL миграции на отдельные запросы по точке с запятой вне строк и комментариев.
+// Драйвер MySQL без multiStatements выполняет только один запрос за вызов
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	var quote byte // открытая кавычка строки или идентификатора, 0 — вне строки
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			current.WriteByte(c)
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			current.WriteByte(c)
+		case c == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			// комментарий до конца строки пропускается
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+		case c == ';':
+			statements = appendStatement(statements, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(c)
+		}
+	}
+	return appendStatement(statements, current.String())
+}
+
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"fmt"
+	"menu_manager/internal/oops"
+)
+
+// CheckAvailabilityPath путь запроса наличия продуктов в barn manager
+const CheckAvailabilityPath = "/api/v1/products/check-availability"
+
+// CheckAvailabilityVersion версия контракта запроса POST /api/v1/products/check-availability.
+// Несовместимые изменения полей запроса требуют новой версии
+const CheckAvailabilityVersion = 1
+
+// CheckAvailabilityRequest тело запроса наличия продуктов в barn manager
+type CheckAvailabilityRequest struct {
+	Version     int                  `json:"version"`
+	Ingredients []RequiredIngredient `json:"ingredients"` // по одной записи на продукт
+}
+
+// RequiredIngredient сколько продукта нужно для всех рецептов приема пищи
+type RequiredIngredient struct {
+	ProductID string `json:"product_id"`
+	Amount    uint   `json:"amount"`
+	Unit      string `json:"unit"` // г, мл, шт
+}
+
+// CheckAvailabilityResponse тело ответа barn manager на запрос наличия продуктов.
+// Продукты, о которых barn manager ничего не знает, в ответ не попадают
+type CheckAvailabilityResponse struct {
+	Products []Product `json:"products"`
+}
+
+// Validate проверяет запрос перед отправкой: версия, непустые продукты без повторов,
+// положительное количество и единица измерения
+func (r CheckAvailabilityRequest) Validate() error {
+	if r.Version != CheckAvailabilityVersion {
+		return oops.NewValidationError("version", fmt.Errorf("%w: ожидается %d, получено %d", oops.ErrInvalidValue, CheckAvailabilityVersion, r.Version))
+	}
+	if len(r.Ingredients) == 0 {
+		return oops.NewValidationError("ingredients", oops.ErrEmptyValue)
+	}
+
+	seen := make(map[string]bool, len(r.Ingredients))
+	for i, ingredient := range r.Ingredients {
+		field := fmt.Sprintf("ingredients[%d]", i)
+		switch {
+		case ingredient.ProductID == "":
+			return oops.NewValidationError(field+".product_id", oops.ErrEmptyValue)
+		case seen[ingredient.ProductID]:
+			return oops.NewValidationError(field+".product_id", fmt.Errorf("%w: продукт %s указан дважды", oops.ErrInvalidValue, ingredient.ProductID))
+		case ingredient.Amount == 0:
+			return oops.NewValidationError(field+".amount", oops.ErrEmptyValue)
+		case ingredient.Unit == "":
+			return oops.NewValidationError(field+".unit", oops.ErrEmptyValue)
+		}
+		seen[ingredient.ProductID] = true
+	}
+	return nil
+}
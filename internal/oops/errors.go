@@ -16,6 +16,16 @@ var (
 	ErrRecipeNotFound = errors.New("рецепт не найден")
 	ErrInvalidDates   = errors.New("некорректные даты")
 	ErrNotImplemented = errors.New("функционал не реализован")
+	ErrNoUpcomingMeal = errors.New("нет предстоящих приемов пищи")
+
+	// Ошибки валидации
+	ErrEmptyValue    = errors.New("значение не может быть пустым")
+	ErrInvalidValue  = errors.New("недопустимое значение")
+	ErrMalformedBody = errors.New("некорректное тело запроса")
+
+	// Ошибки внешних сервисов
+	ErrBarnUnavailable = errors.New("сервис barn manager недоступен")
+	ErrCircuitOpen     = errors.New("запросы к сервису приостановлены после серии ошибок")
 )
 
 // ValidationError представляет ошибку валидации
@@ -31,15 +41,48 @@ type DBError struct {
 	Op  string
 }
 
+// UserError представляет ошибку операции с данными пользователя. id хранится отдельным полем,
+// чтобы журнал мог вывести вместо него псевдоним
+type UserError struct {
+	UserID string
+	Err    error
+}
+
 func (e *ValidationError) Error() string {
 	return fmt.Sprintf("ошибка валидации поля '%s': %v", e.Field, e.Err)
 }
 
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
 func (e *DBError) Error() string {
-	if e.ID != "" {
-		return fmt.Sprintf("операция БД '%s' для ID '%s': %v", e.Op, e.ID, e.Err)
+	return e.Text(e.ID, fmt.Sprint(e.Err))
+}
+
+// Text возвращает текст ошибки с переданными id и текстом вложенной ошибки
+func (e *DBError) Text(id, cause string) string {
+	if id != "" {
+		return fmt.Sprintf("операция БД '%s' для ID '%s': %s", e.Op, id, cause)
 	}
-	return fmt.Sprintf("операция БД '%s': %v", e.Op, e.Err)
+	return fmt.Sprintf("операция БД '%s': %s", e.Op, cause)
+}
+
+func (e *DBError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UserError) Error() string {
+	return e.Text(e.UserID, fmt.Sprint(e.Err))
+}
+
+// Text возвращает текст ошибки с переданными id пользователя и текстом вложенной ошибки
+func (e *UserError) Text(userID, cause string) string {
+	return fmt.Sprintf("пользователь %s: %s", userID, cause)
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
 }
 
 func NewValidationError(field string, err error) *ValidationError {
@@ -56,3 +99,10 @@ func NewDBError(err error, op string, id string) *DBError {
 		Op:  op,
 	}
 }
+
+func NewUserError(userID string, err error) *UserError {
+	return &UserError{
+		UserID: userID,
+		Err:    err,
+	}
+}
@@ -0,0 +1,111 @@
+package oops
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// ProblemContentType тип содержимого ответа об ошибке по RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix префикс URI типа проблемы, к нему добавляется машиночитаемый код
+const problemTypePrefix = "urn:menu-manager:problem:"
+
+// Машиночитаемые коды ошибок, стабильные для клиентов API
+const (
+	CodeValidationFailed = "validation_failed"
+	CodeMalformedBody    = "malformed_body"
+	CodeNotFound         = "not_found"
+	CodeNoUpcomingMeal   = "no_upcoming_meal"
+	CodeDuplicateKey     = "duplicate_key"
+	CodeBarnUnavailable  = "barn_unavailable"
+	CodeDBUnavailable    = "db_unavailable"
+	CodeDBError          = "db_error"
+	CodeNotImplemented   = "not_implemented"
+	CodeInternal         = "internal_error"
+)
+
+// serverErrorDetails текст detail для ответов 5xx. Текст самой ошибки — сообщения драйвера БД, запросы SQL,
+// тело ответа barn manager — клиенту не отдается, WriteProblem пишет его в журнал
+var serverErrorDetails = map[string]string{
+	CodeBarnUnavailable: "сервис barn manager недоступен",
+	CodeDBUnavailable:   "база данных недоступна",
+	CodeDBError:         "ошибка при обращении к базе данных",
+	CodeNotImplemented:  "операция не поддерживается",
+	CodeInternal:        "внутренняя ошибка сервиса",
+}
+
+// Problem представляет тело ответа об ошибке в формате RFC 7807
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Field    string `json:"field,omitempty"` // поле запроса, не прошедшее валидацию
+}
+
+// NewProblem сопоставляет ошибку со статусом HTTP и машиночитаемым кодом
+func NewProblem(err error) Problem {
+	var validationErr *ValidationError
+	var dbErr *DBError
+
+	switch {
+	case errors.As(err, &validationErr):
+		p := newProblem(http.StatusBadRequest, CodeValidationFailed, err)
+		p.Field = validationErr.Field
+		return p
+	case errors.Is(err, ErrMalformedBody):
+		return newProblem(http.StatusBadRequest, CodeMalformedBody, err)
+	case errors.Is(err, ErrInvalidDates):
+		return newProblem(http.StatusBadRequest, CodeValidationFailed, err)
+	case errors.Is(err, ErrNoData), errors.Is(err, ErrMenuNotFound), errors.Is(err, ErrRecipeNotFound):
+		return newProblem(http.StatusNotFound, CodeNotFound, err)
+	case errors.Is(err, ErrNoUpcomingMeal):
+		return newProblem(http.StatusNotFound, CodeNoUpcomingMeal, err)
+	case errors.Is(err, ErrDuplicateKey):
+		return newProblem(http.StatusConflict, CodeDuplicateKey, err)
+	case errors.Is(err, ErrBarnUnavailable):
+		return newProblem(http.StatusBadGateway, CodeBarnUnavailable, err)
+	case errors.Is(err, ErrDBConnection):
+		return newProblem(http.StatusServiceUnavailable, CodeDBUnavailable, err)
+	case errors.Is(err, ErrNotImplemented):
+		return newProblem(http.StatusNotImplemented, CodeNotImplemented, err)
+	case errors.As(err, &dbErr):
+		return newProblem(http.StatusInternalServerError, CodeDBError, err)
+	default:
+		return newProblem(http.StatusInternalServerError, CodeInternal, err)
+	}
+}
+
+// WriteProblem отправляет клиенту ответ об ошибке в формате application/problem+json
+func WriteProblem(w http.ResponseWriter, r *http.Request, err error) {
+	p := NewProblem(err)
+	p.Instance = r.URL.Path
+
+	if p.Status >= http.StatusInternalServerError {
+		// путь не логируется, в нем бывает id пользователя; запрос находится по request_id из контекста
+		slog.ErrorContext(r.Context(), "ошибка обработки запроса", "method", r.Method, "code", p.Code, "error", err)
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+func newProblem(status int, code string, err error) Problem {
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		detail = serverErrorDetails[code]
+	}
+	return Problem{
+		Type:   problemTypePrefix + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
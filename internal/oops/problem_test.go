@@ -0,0 +1,78 @@
+package oops_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"menu_manager/internal/oops"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"validation", oops.NewValidationError("meal_type", oops.ErrInvalidValue), http.StatusBadRequest, oops.CodeValidationFailed},
+		{"malformed body", fmt.Errorf("%w: unexpected EOF", oops.ErrMalformedBody), http.StatusBadRequest, oops.CodeMalformedBody},
+		{"wrapped no data", oops.NewDBError(oops.ErrNoData, "LoadDish", "1"), http.StatusNotFound, oops.CodeNotFound},
+		{"invalid dates", oops.ErrInvalidDates, http.StatusBadRequest, oops.CodeValidationFailed},
+		{"no upcoming meal", oops.ErrNoUpcomingMeal, http.StatusNotFound, oops.CodeNoUpcomingMeal},
+		{"duplicate", oops.NewDBError(oops.ErrDuplicateKey, "SaveDish", "1"), http.StatusConflict, oops.CodeDuplicateKey},
+		{"barn", fmt.Errorf("%w: timeout", oops.ErrBarnUnavailable), http.StatusBadGateway, oops.CodeBarnUnavailable},
+		{"db connection", oops.ErrDBConnection, http.StatusServiceUnavailable, oops.CodeDBUnavailable},
+		{"db error", oops.NewDBError(errors.New("deadlock"), "UpdateMenu", "1"), http.StatusInternalServerError, oops.CodeDBError},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError, oops.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := oops.NewProblem(tt.err)
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, tt.code, p.Code)
+			assert.Equal(t, "urn:menu-manager:problem:"+tt.code, p.Type)
+			assert.Equal(t, http.StatusText(tt.status), p.Title)
+		})
+	}
+}
+
+func TestNewProblem_ServerErrorDetail(t *testing.T) {
+	// текст ошибок 4xx помогает клиенту исправить запрос
+	p := oops.NewProblem(oops.NewValidationError("meal_type", oops.ErrInvalidValue))
+	assert.Contains(t, p.Detail, "meal_type")
+
+	// текст ошибок 5xx остается в журнале, клиент получает только общее описание и код
+	for _, err := range []error{
+		oops.NewDBError(errors.New("Error 1213: Deadlock found when trying to get lock; SELECT * FROM menu"), "UpdateMenu", "kolya"),
+		fmt.Errorf("%w: unexpected status code: 500, body: stack trace", oops.ErrBarnUnavailable),
+		errors.New("panic: runtime error"),
+	} {
+		p := oops.NewProblem(err)
+		assert.NotEmpty(t, p.Detail)
+		assert.NotContains(t, p.Detail, "Deadlock")
+		assert.NotContains(t, p.Detail, "kolya")
+		assert.NotContains(t, p.Detail, "stack trace")
+		assert.NotContains(t, p.Detail, "panic")
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/dishes", nil)
+	rec := httptest.NewRecorder()
+
+	oops.WriteProblem(rec, req, oops.NewValidationError("name", oops.ErrEmptyValue))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, oops.ProblemContentType, rec.Header().Get("Content-Type"))
+
+	var p oops.Problem
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, "name", p.Field)
+	assert.Equal(t, "/api/v1/dishes", p.Instance)
+}
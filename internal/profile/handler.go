@@ -0,0 +1,80 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"menu_manager/internal/oops"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Handler обрабатывает HTTP-запросы для работы с профилями
+type Handler struct {
+	router  *chi.Mux
+	service Service
+}
+
+// NewHandler создает новый обработчик HTTP-запросов
+func NewHandler(router *chi.Mux, service Service) *Handler {
+	return &Handler{
+		router:  router,
+		service: service,
+	}
+}
+
+// Register регистрирует все обработчики маршрутов
+func (h *Handler) Register() {
+	h.router.Route("/api/v1/profiles", func(r chi.Router) {
+		r.Get("/{userID}", h.getProfile)
+		r.Put("/{userID}", h.saveProfile)
+		r.Delete("/{userID}", h.deleteProfile)
+	})
+}
+
+// getProfile возвращает профиль пользователя
+func (h *Handler) getProfile(w http.ResponseWriter, r *http.Request) {
+	p, err := h.service.GetProfile(r.Context(), chi.URLParam(r, "userID"))
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// saveProfile создает или заменяет профиль пользователя
+func (h *Handler) saveProfile(w http.ResponseWriter, r *http.Request) {
+	var p Profile
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		oops.WriteProblem(w, r, fmt.Errorf("%w: %v", oops.ErrMalformedBody, err))
+		return
+	}
+	// идентификатор пользователя берется из пути, а не из тела запроса
+	p.UserID = chi.URLParam(r, "userID")
+
+	saved, err := h.service.SaveProfile(r.Context(), p)
+	if err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// deleteProfile удаляет профиль пользователя
+func (h *Handler) deleteProfile(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteProfile(r.Context(), chi.URLParam(r, "userID")); err != nil {
+		oops.WriteProblem(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeJSON сериализует ответ в JSON с указанным статусом
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
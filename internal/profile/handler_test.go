@@ -0,0 +1,89 @@
+package profile_test
+
+import (
+	"encoding/json"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+	mocks "menu_manager/internal/profile/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveProfile_Handler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+
+	p := profile.Profile{
+		UserID:           "dan",
+		Diet:             profile.DietVegan,
+		Allergens:        []string{"nuts"},
+		DislikedProducts: []string{"огурец"},
+		Cuisines:         []string{"italian"},
+	}
+	mockService.EXPECT().SaveProfile(gomock.Any(), p).Return(&p, nil)
+
+	router := chi.NewRouter()
+	handler := profile.NewHandler(router, mockService)
+	handler.Register()
+
+	// user_id в теле игнорируется, используется идентификатор из пути
+	body := `{"user_id":"other","diet":"vegan","allergens":["nuts"],"disliked_products":["огурец"],"cuisines":["italian"]}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/profiles/dan", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var saved profile.Profile
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &saved))
+	assert.Equal(t, p, saved)
+}
+
+func TestGetProfile_NotFound(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().GetProfile(gomock.Any(), "kolya").
+		Return(nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", "kolya"))
+
+	router := chi.NewRouter()
+	handler := profile.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/profiles/kolya", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, oops.ProblemContentType, rec.Header().Get("Content-Type"))
+}
+
+func TestDeleteProfile_Handler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := mocks.NewMockService(ctrl)
+	mockService.EXPECT().DeleteProfile(gomock.Any(), "dan").Return(nil)
+
+	router := chi.NewRouter()
+	handler := profile.NewHandler(router, mockService)
+	handler.Register()
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/profiles/dan", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
@@ -0,0 +1,121 @@
+// Package memory хранит профили пользователей и категории продуктов в памяти процесса.
+// Используется для локальной разработки и тестов без MySQL, данные не переживают перезапуск
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+)
+
+// Storage реализует profile.Store в памяти, безопасен для конкурентного использования
+type Storage struct {
+	mu         sync.RWMutex
+	profiles   map[string]profile.Profile // ключ — user_id
+	categories map[string][]string        // ключ — product_id
+}
+
+func NewStorage() *Storage {
+	return &Storage{
+		profiles:   make(map[string]profile.Profile),
+		categories: make(map[string][]string),
+	}
+}
+
+// Fixture описывает начальные данные хранилища в JSON
+type Fixture struct {
+	Profiles          []profile.Profile   `json:"profiles"`
+	ProductCategories map[string][]string `json:"product_categories"` // категории по product_id
+}
+
+// LoadFixtureFile добавляет в хранилище данные из JSON-файла фикстуры.
+// Ключи, относящиеся к другим хранилищам (например, menus), игнорируются
+func (s *Storage) LoadFixtureFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать фикстуру: %w", err)
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return fmt.Errorf("не удалось разобрать фикстуру %s: %w", path, err)
+	}
+	return s.Seed(fixture)
+}
+
+// Seed добавляет в хранилище данные фикстуры
+func (s *Storage) Seed(fixture Fixture) error {
+	for _, p := range fixture.Profiles {
+		if err := s.SaveProfile(context.Background(), p); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for productID, categories := range fixture.ProductCategories {
+		s.categories[productID] = append(s.categories[productID], categories...)
+	}
+	return nil
+}
+
+// LoadProfile возвращает профиль пользователя
+func (s *Storage) LoadProfile(ctx context.Context, userID string) (*profile.Profile, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.profiles[userID]
+	if !ok {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", userID)
+	}
+	p = cloneProfile(p)
+	return &p, nil
+}
+
+// SaveProfile создает или заменяет профиль пользователя
+func (s *Storage) SaveProfile(ctx context.Context, p profile.Profile) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.profiles[p.UserID] = cloneProfile(p)
+	return nil
+}
+
+// DeleteProfile удаляет профиль пользователя
+func (s *Storage) DeleteProfile(ctx context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.profiles[userID]; !ok {
+		return oops.NewDBError(oops.ErrNoData, "DeleteProfile", userID)
+	}
+	delete(s.profiles, userID)
+	return nil
+}
+
+// LoadProductCategories возвращает категории продуктов по их product_id
+func (s *Storage) LoadProductCategories(ctx context.Context, productIDs []string) (map[string][]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := make(map[string][]string, len(productIDs))
+	for _, productID := range productIDs {
+		if c, ok := s.categories[productID]; ok {
+			categories[productID] = append([]string(nil), c...)
+		}
+	}
+	return categories, nil
+}
+
+// cloneProfile копирует списки профиля, чтобы вызывающий код не мог изменить данные хранилища
+func cloneProfile(p profile.Profile) profile.Profile {
+	p.Allergens = append([]string{}, p.Allergens...)
+	p.DislikedProducts = append([]string{}, p.DislikedProducts...)
+	p.Cuisines = append([]string{}, p.Cuisines...)
+	return p
+}
@@ -0,0 +1,68 @@
+package memory_test
+
+import (
+	"context"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+	"menu_manager/internal/profile/memory"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadFixtureFile(t *testing.T) {
+	store := memory.NewStorage()
+	assert.NoError(t, store.LoadFixtureFile("../../../configs/fixture.json"))
+
+	p, err := store.LoadProfile(context.Background(), "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.DietVegetarian, p.Diet)
+	assert.Equal(t, []string{"lactose"}, p.Allergens)
+
+	categories, err := store.LoadProductCategories(context.Background(), []string{"молоко", "огурец"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"молоко": {"dairy", "lactose"}}, categories)
+}
+
+func TestProfileLifecycle(t *testing.T) {
+	store := memory.NewStorage()
+	ctx := context.Background()
+
+	_, err := store.LoadProfile(ctx, "kolya")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+
+	p := profile.Profile{UserID: "kolya", Diet: profile.DietVegan, Allergens: []string{"nuts"}}
+	assert.NoError(t, store.SaveProfile(ctx, p))
+
+	// изменение исходного профиля не меняет хранилище
+	p.Allergens[0] = "fish"
+	loaded, err := store.LoadProfile(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nuts"}, loaded.Allergens)
+	assert.Equal(t, []string{}, loaded.Cuisines)
+
+	p.Diet = profile.DietOmnivore
+	assert.NoError(t, store.SaveProfile(ctx, p))
+	loaded, err = store.LoadProfile(ctx, "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.DietOmnivore, loaded.Diet)
+
+	assert.NoError(t, store.DeleteProfile(ctx, "kolya"))
+	assert.ErrorIs(t, store.DeleteProfile(ctx, "kolya"), oops.ErrNoData)
+}
+
+func TestProfileService_WithMemoryStore(t *testing.T) {
+	store := memory.NewStorage()
+	assert.NoError(t, store.Seed(memory.Fixture{
+		ProductCategories: map[string][]string{"куриное_филе": {"meat"}},
+	}))
+	service := profile.NewService(store)
+	ctx := context.Background()
+
+	_, err := service.SaveProfile(ctx, profile.Profile{UserID: "dan", Diet: profile.DietVegetarian})
+	assert.NoError(t, err)
+
+	violations, err := service.Violations(ctx, "dan", []string{"куриное_филе", "морковь"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"куриное_филе"}, violations)
+}
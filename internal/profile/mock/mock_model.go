@@ -0,0 +1,208 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/profile/model.go
+
+// Package profile_test is a generated GoMock package.
+package profile_test
+
+import (
+	context "context"
+	profile "menu_manager/internal/profile"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// DeleteProfile mocks base method.
+func (m *MockService) DeleteProfile(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProfile", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProfile indicates an expected call of DeleteProfile.
+func (mr *MockServiceMockRecorder) DeleteProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProfile", reflect.TypeOf((*MockService)(nil).DeleteProfile), ctx, userID)
+}
+
+// GetProfile mocks base method.
+func (m *MockService) GetProfile(ctx context.Context, userID string) (*profile.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfile", ctx, userID)
+	ret0, _ := ret[0].(*profile.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfile indicates an expected call of GetProfile.
+func (mr *MockServiceMockRecorder) GetProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfile", reflect.TypeOf((*MockService)(nil).GetProfile), ctx, userID)
+}
+
+// Location mocks base method.
+func (m *MockService) Location(ctx context.Context, userID string) (*time.Location, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Location", ctx, userID)
+	ret0, _ := ret[0].(*time.Location)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Location indicates an expected call of Location.
+func (mr *MockServiceMockRecorder) Location(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Location", reflect.TypeOf((*MockService)(nil).Location), ctx, userID)
+}
+
+// RescheduleStrategy mocks base method.
+func (m *MockService) RescheduleStrategy(ctx context.Context, userID string) (string, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RescheduleStrategy", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RescheduleStrategy indicates an expected call of RescheduleStrategy.
+func (mr *MockServiceMockRecorder) RescheduleStrategy(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RescheduleStrategy", reflect.TypeOf((*MockService)(nil).RescheduleStrategy), ctx, userID)
+}
+
+// SaveProfile mocks base method.
+func (m *MockService) SaveProfile(ctx context.Context, p profile.Profile) (*profile.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProfile", ctx, p)
+	ret0, _ := ret[0].(*profile.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveProfile indicates an expected call of SaveProfile.
+func (mr *MockServiceMockRecorder) SaveProfile(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProfile", reflect.TypeOf((*MockService)(nil).SaveProfile), ctx, p)
+}
+
+// Violations mocks base method.
+func (m *MockService) Violations(ctx context.Context, userID string, productIDs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Violations", ctx, userID, productIDs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Violations indicates an expected call of Violations.
+func (mr *MockServiceMockRecorder) Violations(ctx, userID, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Violations", reflect.TypeOf((*MockService)(nil).Violations), ctx, userID, productIDs)
+}
+
+// MockStore is a mock of Store interface.
+type MockStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreMockRecorder
+}
+
+// MockStoreMockRecorder is the mock recorder for MockStore.
+type MockStoreMockRecorder struct {
+	mock *MockStore
+}
+
+// NewMockStore creates a new mock instance.
+func NewMockStore(ctrl *gomock.Controller) *MockStore {
+	mock := &MockStore{ctrl: ctrl}
+	mock.recorder = &MockStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStore) EXPECT() *MockStoreMockRecorder {
+	return m.recorder
+}
+
+// DeleteProfile mocks base method.
+func (m *MockStore) DeleteProfile(ctx context.Context, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProfile", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProfile indicates an expected call of DeleteProfile.
+func (mr *MockStoreMockRecorder) DeleteProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProfile", reflect.TypeOf((*MockStore)(nil).DeleteProfile), ctx, userID)
+}
+
+// LoadProductCategories mocks base method.
+func (m *MockStore) LoadProductCategories(ctx context.Context, productIDs []string) (map[string][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadProductCategories", ctx, productIDs)
+	ret0, _ := ret[0].(map[string][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadProductCategories indicates an expected call of LoadProductCategories.
+func (mr *MockStoreMockRecorder) LoadProductCategories(ctx, productIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadProductCategories", reflect.TypeOf((*MockStore)(nil).LoadProductCategories), ctx, productIDs)
+}
+
+// LoadProfile mocks base method.
+func (m *MockStore) LoadProfile(ctx context.Context, userID string) (*profile.Profile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoadProfile", ctx, userID)
+	ret0, _ := ret[0].(*profile.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoadProfile indicates an expected call of LoadProfile.
+func (mr *MockStoreMockRecorder) LoadProfile(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadProfile", reflect.TypeOf((*MockStore)(nil).LoadProfile), ctx, userID)
+}
+
+// SaveProfile mocks base method.
+func (m *MockStore) SaveProfile(ctx context.Context, p profile.Profile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveProfile", ctx, p)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveProfile indicates an expected call of SaveProfile.
+func (mr *MockStoreMockRecorder) SaveProfile(ctx, p interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveProfile", reflect.TypeOf((*MockStore)(nil).SaveProfile), ctx, p)
+}
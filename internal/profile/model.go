@@ -0,0 +1,134 @@
+package profile
+
+import (
+	"context"
+	"time"
+)
+
+// Diet определяет тип питания пользователя
+type Diet string
+
+const (
+	DietOmnivore    Diet = "omnivore"
+	DietVegetarian  Diet = "vegetarian"
+	DietVegan       Diet = "vegan"
+	DietPescatarian Diet = "pescatarian"
+)
+
+// Категории продуктов, на которые опираются ограничения диет
+const (
+	CategoryMeat    = "meat"
+	CategoryFish    = "fish"
+	CategorySeafood = "seafood"
+	CategoryDairy   = "dairy"
+	CategoryEgg     = "egg"
+	CategoryHoney   = "honey"
+)
+
+// dietRestrictions перечисляет категории продуктов, запрещенные каждой диетой
+var dietRestrictions = map[Diet][]string{
+	DietOmnivore:    {},
+	DietPescatarian: {CategoryMeat},
+	DietVegetarian:  {CategoryMeat, CategoryFish, CategorySeafood},
+	DietVegan:       {CategoryMeat, CategoryFish, CategorySeafood, CategoryDairy, CategoryEgg, CategoryHoney},
+}
+
+// IsValid проверяет, что диета входит в список поддерживаемых
+func (d Diet) IsValid() bool {
+	_, ok := dietRestrictions[d]
+	return ok
+}
+
+// Profile представляет пищевые предпочтения пользователя
+type Profile struct {
+	UserID           string   `json:"user_id"`
+	Diet             Diet     `json:"diet"`
+	Allergens        []string `json:"allergens"`         // product_id или категории продуктов
+	DislikedProducts []string `json:"disliked_products"` // product_id
+	Cuisines         []string `json:"cuisines"`          // предпочитаемые кухни
+	TimeZone         string   `json:"time_zone"`         // часовой пояс IANA, например Europe/Moscow
+	// RescheduleStrategy стратегия переноса устаревшего меню: keep-slot, rotate или randomized-within-type
+	RescheduleStrategy string `json:"reschedule_strategy"`
+	RotationWeeks      int    `json:"rotation_weeks"` // длина цикла стратегии rotate в неделях
+}
+
+// DefaultTimeZone часовой пояс пользователей, не указавших свой
+const DefaultTimeZone = "UTC"
+
+const (
+	// DefaultRotationWeeks длина цикла ротации по умолчанию
+	DefaultRotationWeeks = 1
+	// MaxRotationWeeks ограничивает длину цикла ротации
+	MaxRotationWeeks = 12
+)
+
+// Location возвращает часовой пояс пользователя, при ошибке или отсутствии — UTC
+func (p *Profile) Location() *time.Location {
+	if p.TimeZone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Forbids проверяет, нарушает ли продукт с указанными категориями профиль пользователя
+func (p *Profile) Forbids(productID string, categories []string) bool {
+	for _, disliked := range p.DislikedProducts {
+		if disliked == productID {
+			return true
+		}
+	}
+	for _, allergen := range p.Allergens {
+		if allergen == productID || contains(categories, allergen) {
+			return true
+		}
+	}
+	for _, restricted := range dietRestrictions[p.Diet] {
+		if contains(categories, restricted) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Service определяет интерфейс для работы с профилями пользователей
+type Service interface {
+	// GetProfile возвращает профиль пользователя
+	GetProfile(ctx context.Context, userID string) (*Profile, error)
+	// SaveProfile создает или полностью заменяет профиль пользователя
+	SaveProfile(ctx context.Context, p Profile) (*Profile, error)
+	// DeleteProfile удаляет профиль пользователя
+	DeleteProfile(ctx context.Context, userID string) error
+	// Violations возвращает продукты из списка, которые нарушают профиль пользователя.
+	// Если профиля нет, ограничений тоже нет
+	Violations(ctx context.Context, userID string, productIDs []string) ([]string, error)
+	// Location возвращает часовой пояс пользователя. Если профиля нет, используется UTC
+	Location(ctx context.Context, userID string) (*time.Location, error)
+	// RescheduleStrategy возвращает стратегию переноса меню и длину цикла ротации.
+	// Если профиля нет, возвращается стратегия по умолчанию
+	RescheduleStrategy(ctx context.Context, userID string) (string, int, error)
+}
+
+// Store определяет интерфейс для хранения профилей
+type Store interface {
+	// LoadProfile возвращает профиль пользователя из БД
+	LoadProfile(ctx context.Context, userID string) (*Profile, error)
+	// SaveProfile создает или заменяет профиль пользователя
+	SaveProfile(ctx context.Context, p Profile) error
+	// DeleteProfile удаляет профиль пользователя
+	DeleteProfile(ctx context.Context, userID string) error
+	// LoadProductCategories возвращает категории продуктов по их product_id
+	LoadProductCategories(ctx context.Context, productIDs []string) (map[string][]string, error)
+}
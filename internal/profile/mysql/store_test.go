@@ -0,0 +1,113 @@
+package mysql_test
+
+import (
+	"context"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+	"menu_manager/internal/profile/mysql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadProfile_Success(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"user_id", "diet", "allergens", "disliked_products", "cuisines", "time_zone", "reschedule_strategy", "rotation_weeks"}).
+		AddRow("dan", "vegetarian", `["lactose"]`, `[]`, `["french"]`, "Europe/Paris", "rotate", 2)
+
+	mock.ExpectQuery(`SELECT user_id, diet, allergens, disliked_products, cuisines, time_zone, reschedule_strategy, rotation_weeks FROM user_profiles WHERE user_id = \?`).
+		WithArgs("dan").
+		WillReturnRows(mockRows)
+
+	storage := mysql.NewStorage(sqlxDB)
+
+	p, err := storage.LoadProfile(context.Background(), "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.Profile{
+		UserID:             "dan",
+		Diet:               profile.DietVegetarian,
+		Allergens:          []string{"lactose"},
+		DislikedProducts:   []string{},
+		Cuisines:           []string{"french"},
+		TimeZone:           "Europe/Paris",
+		RescheduleStrategy: "rotate",
+		RotationWeeks:      2,
+	}, *p)
+}
+
+func TestLoadProfile_NotFound(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectQuery(`SELECT user_id, diet, allergens, disliked_products, cuisines, time_zone, reschedule_strategy, rotation_weeks FROM user_profiles`).
+		WithArgs("kolya").
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "diet", "allergens", "disliked_products", "cuisines", "time_zone", "reschedule_strategy", "rotation_weeks"}))
+
+	storage := mysql.NewStorage(sqlxDB)
+
+	_, err = storage.LoadProfile(context.Background(), "kolya")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+}
+
+func TestSaveProfile_Upsert(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectExec(`INSERT INTO user_profiles \(user_id, diet, allergens, disliked_products, cuisines, time_zone, reschedule_strategy, rotation_weeks\) VALUES \(\?, \?, \?, \?, \?, \?, \?, \?\) ON DUPLICATE KEY UPDATE`).
+		WithArgs("dan", profile.DietVegan, `["nuts"]`, `[]`, `[]`, "Asia/Tokyo", "keep-slot", 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	storage := mysql.NewStorage(sqlxDB)
+
+	err = storage.SaveProfile(context.Background(), profile.Profile{
+		UserID:             "dan",
+		Diet:               profile.DietVegan,
+		Allergens:          []string{"nuts"},
+		DislikedProducts:   []string{},
+		Cuisines:           []string{},
+		TimeZone:           "Asia/Tokyo",
+		RescheduleStrategy: "keep-slot",
+		RotationWeeks:      1,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoadProductCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	sqlxDB := sqlx.NewDb(db, "sqlmock")
+
+	mockRows := sqlmock.NewRows([]string{"product_id", "category"}).
+		AddRow("молоко", "dairy").
+		AddRow("молоко", "lactose").
+		AddRow("куриное_филе", "meat")
+
+	mock.ExpectQuery(`SELECT product_id, category FROM product_categories WHERE product_id IN \(\?, \?, \?\)`).
+		WithArgs("молоко", "куриное_филе", "морковь").
+		WillReturnRows(mockRows)
+
+	storage := mysql.NewStorage(sqlxDB)
+
+	categories, err := storage.LoadProductCategories(context.Background(), []string{"молоко", "куриное_филе", "морковь"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"молоко":       {"dairy", "lactose"},
+		"куриное_филе": {"meat"},
+	}, categories)
+}
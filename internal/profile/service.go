@@ -0,0 +1,150 @@
+package profile
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/oops"
+	"time"
+)
+
+// AppService реализует бизнес-логику работы с профилями
+type AppService struct {
+	storage Store
+}
+
+// NewService создает новый экземпляр сервиса
+func NewService(storage Store) Service {
+	return &AppService{
+		storage: storage,
+	}
+}
+
+// GetProfile возвращает профиль пользователя
+func (s *AppService) GetProfile(ctx context.Context, userID string) (*Profile, error) {
+	return s.storage.LoadProfile(ctx, userID)
+}
+
+// SaveProfile создает или полностью заменяет профиль пользователя
+func (s *AppService) SaveProfile(ctx context.Context, p Profile) (*Profile, error) {
+	if p.Diet == "" {
+		p.Diet = DietOmnivore
+	}
+	if p.TimeZone == "" {
+		p.TimeZone = DefaultTimeZone
+	}
+	if p.RescheduleStrategy == "" {
+		p.RescheduleStrategy = menu.DefaultStrategy
+	}
+	if p.RotationWeeks == 0 {
+		p.RotationWeeks = DefaultRotationWeeks
+	}
+	if err := ValidateProfile(p); err != nil {
+		return nil, err
+	}
+
+	// храним пустые списки, а не null, чтобы клиентам не приходилось различать эти случаи
+	p.Allergens = nonNil(p.Allergens)
+	p.DislikedProducts = nonNil(p.DislikedProducts)
+	p.Cuisines = nonNil(p.Cuisines)
+
+	if err := s.storage.SaveProfile(ctx, p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// DeleteProfile удаляет профиль пользователя
+func (s *AppService) DeleteProfile(ctx context.Context, userID string) error {
+	return s.storage.DeleteProfile(ctx, userID)
+}
+
+// Violations возвращает продукты из списка, которые нарушают профиль пользователя
+func (s *AppService) Violations(ctx context.Context, userID string, productIDs []string) ([]string, error) {
+	p, err := s.storage.LoadProfile(ctx, userID)
+	if errors.Is(err, oops.ErrNoData) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := s.storage.LoadProductCategories(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	var forbidden []string
+	for _, productID := range productIDs {
+		if p.Forbids(productID, categories[productID]) {
+			forbidden = append(forbidden, productID)
+		}
+	}
+	return forbidden, nil
+}
+
+// Location возвращает часовой пояс пользователя. Если профиля нет, используется UTC
+func (s *AppService) Location(ctx context.Context, userID string) (*time.Location, error) {
+	p, err := s.storage.LoadProfile(ctx, userID)
+	if errors.Is(err, oops.ErrNoData) {
+		return time.UTC, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.Location(), nil
+}
+
+// RescheduleStrategy возвращает стратегию переноса меню и длину цикла ротации.
+// Если профиля нет, возвращается стратегия по умолчанию
+func (s *AppService) RescheduleStrategy(ctx context.Context, userID string) (string, int, error) {
+	p, err := s.storage.LoadProfile(ctx, userID)
+	if errors.Is(err, oops.ErrNoData) {
+		return menu.DefaultStrategy, DefaultRotationWeeks, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return p.RescheduleStrategy, p.RotationWeeks, nil
+}
+
+// ValidateProfile проверяет корректность профиля перед сохранением
+func ValidateProfile(p Profile) error {
+	if p.UserID == "" {
+		return oops.NewValidationError("user_id", oops.ErrEmptyValue)
+	}
+	if !p.Diet.IsValid() {
+		return oops.NewValidationError("diet", oops.ErrInvalidValue)
+	}
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return oops.NewValidationError("time_zone", oops.ErrInvalidValue)
+	}
+	if !menu.IsKnownStrategy(p.RescheduleStrategy) {
+		return oops.NewValidationError("reschedule_strategy", oops.ErrInvalidValue)
+	}
+	if p.RotationWeeks < 1 || p.RotationWeeks > MaxRotationWeeks {
+		return oops.NewValidationError("rotation_weeks", oops.ErrInvalidValue)
+	}
+	for _, list := range []struct {
+		field  string
+		values []string
+	}{
+		{"allergens", p.Allergens},
+		{"disliked_products", p.DislikedProducts},
+		{"cuisines", p.Cuisines},
+	} {
+		for _, v := range list.values {
+			if v == "" {
+				return oops.NewValidationError(list.field, oops.ErrEmptyValue)
+			}
+		}
+	}
+	return nil
+}
+
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
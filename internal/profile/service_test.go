@@ -0,0 +1,192 @@
+package profile_test
+
+import (
+	"context"
+	"menu_manager/internal/menu"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+	mocks "menu_manager/internal/profile/mock"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProfileForbids(t *testing.T) {
+	tests := []struct {
+		name       string
+		profile    profile.Profile
+		productID  string
+		categories []string
+		forbidden  bool
+	}{
+		{"omnivore eats meat", profile.Profile{Diet: profile.DietOmnivore}, "куриное_филе", []string{"meat"}, false},
+		{"vegetarian skips meat", profile.Profile{Diet: profile.DietVegetarian}, "куриное_филе", []string{"meat"}, true},
+		{"vegetarian drinks milk", profile.Profile{Diet: profile.DietVegetarian}, "молоко", []string{"dairy"}, false},
+		{"vegan skips milk", profile.Profile{Diet: profile.DietVegan}, "молоко", []string{"dairy"}, true},
+		{"pescatarian eats fish", profile.Profile{Diet: profile.DietPescatarian}, "лосось", []string{"fish"}, false},
+		{"allergen by category", profile.Profile{Allergens: []string{"lactose"}}, "молоко", []string{"dairy", "lactose"}, true},
+		{"allergen by product", profile.Profile{Allergens: []string{"арахис"}}, "арахис", nil, true},
+		{"disliked product", profile.Profile{DislikedProducts: []string{"огурец"}}, "огурец", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.forbidden, tt.profile.Forbids(tt.productID, tt.categories))
+		})
+	}
+}
+
+func TestViolations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := profile.NewService(mockStore)
+
+	ctx := context.Background()
+	products := []string{"куриное_филе", "морковь", "молоко"}
+
+	mockStore.EXPECT().LoadProfile(ctx, "dan").Return(&profile.Profile{UserID: "dan", Diet: profile.DietVegetarian}, nil)
+	mockStore.EXPECT().LoadProductCategories(ctx, products).Return(map[string][]string{
+		"куриное_филе": {"meat"},
+		"молоко":       {"dairy", "lactose"},
+	}, nil)
+
+	forbidden, err := service.Violations(ctx, "dan", products)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"куриное_филе"}, forbidden)
+}
+
+func TestViolations_NoProfile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := profile.NewService(mockStore)
+
+	mockStore.EXPECT().LoadProfile(gomock.Any(), "kolya").Return(nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", "kolya"))
+
+	forbidden, err := service.Violations(context.Background(), "kolya", []string{"крыса"})
+	assert.NoError(t, err)
+	assert.Empty(t, forbidden)
+}
+
+func TestSaveProfile_Defaults(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := profile.NewService(mockStore)
+
+	expected := profile.Profile{
+		UserID:             "kolya",
+		Diet:               profile.DietOmnivore,
+		Allergens:          []string{},
+		DislikedProducts:   []string{"крыса"},
+		Cuisines:           []string{},
+		TimeZone:           profile.DefaultTimeZone,
+		RescheduleStrategy: menu.DefaultStrategy,
+		RotationWeeks:      profile.DefaultRotationWeeks,
+	}
+	mockStore.EXPECT().SaveProfile(gomock.Any(), expected).Return(nil)
+
+	saved, err := service.SaveProfile(context.Background(), profile.Profile{UserID: "kolya", DislikedProducts: []string{"крыса"}})
+	assert.NoError(t, err)
+	assert.Equal(t, expected, *saved)
+}
+
+func TestSaveProfile_InvalidDiet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := profile.NewService(mocks.NewMockStore(ctrl))
+
+	_, err := service.SaveProfile(context.Background(), profile.Profile{UserID: "kolya", Diet: "keto"})
+
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "diet", validationErr.Field)
+}
+
+func TestSaveProfile_InvalidTimeZone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	service := profile.NewService(mocks.NewMockStore(ctrl))
+
+	_, err := service.SaveProfile(context.Background(), profile.Profile{UserID: "kolya", TimeZone: "Mars/Olympus_Mons"})
+
+	var validationErr *oops.ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "time_zone", validationErr.Field)
+}
+
+func TestSaveProfile_InvalidRescheduleSettings(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile profile.Profile
+		field   string
+	}{
+		{"unknown strategy", profile.Profile{UserID: "kolya", RescheduleStrategy: "shuffle"}, "reschedule_strategy"},
+		{"negative rotation", profile.Profile{UserID: "kolya", RescheduleStrategy: menu.StrategyRotate, RotationWeeks: -1}, "rotation_weeks"},
+		{"rotation too long", profile.Profile{UserID: "kolya", RescheduleStrategy: menu.StrategyRotate, RotationWeeks: 52}, "rotation_weeks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			service := profile.NewService(mocks.NewMockStore(ctrl))
+
+			_, err := service.SaveProfile(context.Background(), tt.profile)
+
+			var validationErr *oops.ValidationError
+			assert.ErrorAs(t, err, &validationErr)
+			assert.Equal(t, tt.field, validationErr.Field)
+		})
+	}
+}
+
+func TestRescheduleStrategy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := profile.NewService(mockStore)
+
+	mockStore.EXPECT().LoadProfile(gomock.Any(), "dan").
+		Return(&profile.Profile{UserID: "dan", RescheduleStrategy: menu.StrategyRotate, RotationWeeks: 3}, nil)
+	mockStore.EXPECT().LoadProfile(gomock.Any(), "kolya").Return(nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", "kolya"))
+
+	name, weeks, err := service.RescheduleStrategy(context.Background(), "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, menu.StrategyRotate, name)
+	assert.Equal(t, 3, weeks)
+
+	name, weeks, err = service.RescheduleStrategy(context.Background(), "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, menu.DefaultStrategy, name)
+	assert.Equal(t, profile.DefaultRotationWeeks, weeks)
+}
+
+func TestLocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStore := mocks.NewMockStore(ctrl)
+	service := profile.NewService(mockStore)
+
+	mockStore.EXPECT().LoadProfile(gomock.Any(), "dan").Return(&profile.Profile{UserID: "dan", TimeZone: "Asia/Tokyo"}, nil)
+	mockStore.EXPECT().LoadProfile(gomock.Any(), "kolya").Return(nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", "kolya"))
+
+	loc, err := service.Location(context.Background(), "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", loc.String())
+
+	loc, err = service.Location(context.Background(), "kolya")
+	assert.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+}
@@ -0,0 +1,152 @@
+// Package sqlite реализует profile.Store поверх SQLite, схема лежит в migrations/sqlite.
+// Соединение открывается через internal/menu/sqlite.Open и общее с хранилищем меню
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Storage struct {
+	db *sqlx.DB
+}
+
+func NewStorage(db *sqlx.DB) *Storage {
+	return &Storage{db: db}
+}
+
+// LoadProfile возвращает профиль пользователя из БД
+func (s *Storage) LoadProfile(ctx context.Context, userID string) (*profile.Profile, error) {
+	query := `
+		SELECT user_id, diet, allergens, disliked_products, cuisines, time_zone, reschedule_strategy, rotation_weeks
+		FROM user_profiles
+		WHERE user_id = ?
+	`
+	var p profile.Profile
+	var allergensJson, dislikedJson, cuisinesJson string
+
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&p.UserID,
+		&p.Diet,
+		&allergensJson,
+		&dislikedJson,
+		&cuisinesJson,
+		&p.TimeZone,
+		&p.RescheduleStrategy,
+		&p.RotationWeeks,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, oops.NewDBError(oops.ErrNoData, "LoadProfile", userID)
+	}
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadProfile", userID)
+	}
+
+	for _, field := range []struct {
+		raw  string
+		dest *[]string
+	}{
+		{allergensJson, &p.Allergens},
+		{dislikedJson, &p.DislikedProducts},
+		{cuisinesJson, &p.Cuisines},
+	} {
+		if err := json.Unmarshal([]byte(field.raw), field.dest); err != nil {
+			return nil, oops.NewDBError(err, "LoadProfile.JsonUnmarshal", userID)
+		}
+	}
+	return &p, nil
+}
+
+// SaveProfile создает или заменяет профиль пользователя
+func (s *Storage) SaveProfile(ctx context.Context, p profile.Profile) error {
+	allergensJson, err := json.Marshal(p.Allergens)
+	if err != nil {
+		return oops.NewDBError(err, "SaveProfile.JsonMarshal", p.UserID)
+	}
+	dislikedJson, err := json.Marshal(p.DislikedProducts)
+	if err != nil {
+		return oops.NewDBError(err, "SaveProfile.JsonMarshal", p.UserID)
+	}
+	cuisinesJson, err := json.Marshal(p.Cuisines)
+	if err != nil {
+		return oops.NewDBError(err, "SaveProfile.JsonMarshal", p.UserID)
+	}
+
+	query := `
+		INSERT INTO user_profiles (user_id, diet, allergens, disliked_products, cuisines, time_zone, reschedule_strategy, rotation_weeks)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			diet = excluded.diet,
+			allergens = excluded.allergens,
+			disliked_products = excluded.disliked_products,
+			cuisines = excluded.cuisines,
+			time_zone = excluded.time_zone,
+			reschedule_strategy = excluded.reschedule_strategy,
+			rotation_weeks = excluded.rotation_weeks
+	`
+	_, err = s.db.ExecContext(ctx, query, p.UserID, p.Diet, string(allergensJson), string(dislikedJson), string(cuisinesJson),
+		p.TimeZone, p.RescheduleStrategy, p.RotationWeeks)
+	if err != nil {
+		return oops.NewDBError(err, "SaveProfile", p.UserID)
+	}
+	return nil
+}
+
+// DeleteProfile удаляет профиль пользователя
+func (s *Storage) DeleteProfile(ctx context.Context, userID string) error {
+	res, err := s.db.ExecContext(ctx, "DELETE FROM user_profiles WHERE user_id = ?", userID)
+	if err != nil {
+		return oops.NewDBError(err, "DeleteProfile", userID)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return oops.NewDBError(err, "DeleteProfile.RowsAffected", userID)
+	}
+	if affected == 0 {
+		return oops.NewDBError(oops.ErrNoData, "DeleteProfile", userID)
+	}
+	return nil
+}
+
+// LoadProductCategories возвращает категории продуктов по их product_id
+func (s *Storage) LoadProductCategories(ctx context.Context, productIDs []string) (map[string][]string, error) {
+	categories := make(map[string][]string, len(productIDs))
+	if len(productIDs) == 0 {
+		return categories, nil
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT product_id, category
+		FROM product_categories
+		WHERE product_id IN (?)
+	`, productIDs)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadProductCategories.In", "")
+	}
+
+	rows, err := s.db.QueryContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return nil, oops.NewDBError(err, "LoadProductCategories", "")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, category string
+		if err := rows.Scan(&productID, &category); err != nil {
+			return nil, oops.NewDBError(err, "LoadProductCategories.Scan", "")
+		}
+		categories[productID] = append(categories[productID], category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, oops.NewDBError(err, "LoadProductCategories.Rows", "")
+	}
+	return categories, nil
+}
@@ -0,0 +1,89 @@
+package sqlite_test
+
+import (
+	"context"
+	menusqlite "menu_manager/internal/menu/sqlite"
+	"menu_manager/internal/migrate"
+	"menu_manager/internal/oops"
+	"menu_manager/internal/profile"
+	"menu_manager/internal/profile/sqlite"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newStorage открывает пустую базу в памяти с примененными встроенными миграциями SQLite
+func newStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+	db, err := menusqlite.Open(context.Background(), ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := migrate.New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec(`INSERT INTO product_categories (product_id, category) VALUES
+		('молоко', 'dairy'), ('молоко', 'lactose'), ('куриное_филе', 'meat')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sqlite.NewStorage(db)
+}
+
+func TestProfileLifecycle(t *testing.T) {
+	store := newStorage(t)
+	ctx := context.Background()
+
+	_, err := store.LoadProfile(ctx, "dan")
+	assert.ErrorIs(t, err, oops.ErrNoData)
+
+	p := profile.Profile{
+		UserID:             "dan",
+		Diet:               profile.DietVegetarian,
+		Allergens:          []string{"lactose"},
+		DislikedProducts:   []string{},
+		Cuisines:           []string{"french"},
+		TimeZone:           "Europe/Paris",
+		RescheduleStrategy: "rotate",
+		RotationWeeks:      2,
+	}
+	assert.NoError(t, store.SaveProfile(ctx, p))
+
+	loaded, err := store.LoadProfile(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, &p, loaded)
+
+	// повторное сохранение заменяет профиль
+	p.Diet = profile.DietVegan
+	assert.NoError(t, store.SaveProfile(ctx, p))
+	loaded, err = store.LoadProfile(ctx, "dan")
+	assert.NoError(t, err)
+	assert.Equal(t, profile.DietVegan, loaded.Diet)
+
+	assert.NoError(t, store.DeleteProfile(ctx, "dan"))
+	assert.ErrorIs(t, store.DeleteProfile(ctx, "dan"), oops.ErrNoData)
+}
+
+func TestLoadProductCategories(t *testing.T) {
+	store := newStorage(t)
+
+	categories, err := store.LoadProductCategories(context.Background(), []string{"молоко", "куриное_филе", "огурец"})
+	assert.NoError(t, err)
+	sort.Strings(categories["молоко"])
+	assert.Equal(t, map[string][]string{
+		"молоко":       {"dairy", "lactose"},
+		"куриное_филе": {"meat"},
+	}, categories)
+
+	categories, err = store.LoadProductCategories(context.Background(), nil)
+	assert.NoError(t, err)
+	assert.Empty(t, categories)
+}
@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule вычисляет время следующего запуска задачи
+type Schedule interface {
+	// Next возвращает ближайшее время запуска строго после t
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule разбирает расписание в одном из форматов:
+//   - "@every 15m" — с фиксированным интервалом (time.ParseDuration);
+//   - "@hourly", "@daily" (или "@midnight"), "@weekly";
+//   - cron из пяти полей "минута час день_месяца месяц день_недели".
+//
+// Поля cron поддерживают *, списки (1,15), диапазоны (1-5) и шаги (*/10, 8-18/2).
+// День недели задается числом от 0 до 7, где и 0, и 7 — воскресенье.
+// Расписание cron вычисляется в часовом поясе переданного в Next времени
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	switch spec {
+	case "@hourly":
+		spec = "0 * * * *"
+	case "@daily", "@midnight":
+		spec = "0 0 * * *"
+	case "@weekly":
+		spec = "0 0 * * 0"
+	}
+
+	if rest, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil {
+			return nil, fmt.Errorf("некорректный интервал %q: %w", rest, err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("интервал %s меньше секунды", interval)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("расписание %q должно состоять из пяти полей", spec)
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minutes, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("минуты: %w", err)
+	}
+	if c.hours, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("часы: %w", err)
+	}
+	if c.days, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("день месяца: %w", err)
+	}
+	if c.months, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("месяц: %w", err)
+	}
+	if c.weekdays, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("день недели: %w", err)
+	}
+	// 7 — другое обозначение воскресенья
+	if c.weekdays&(1<<7) != 0 {
+		c.weekdays |= 1
+	}
+	c.anyDay = fields[2] == "*"
+	c.anyWeekday = fields[4] == "*"
+	return c, nil
+}
+
+// everySchedule запускает задачу с фиксированным интервалом
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// cronSchedule хранит допустимые значения каждого поля cron в виде битовых масок
+type cronSchedule struct {
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool
+}
+
+// maxSearchYears ограничивает поиск для расписаний, которые никогда не срабатывают, например "0 0 31 2 *"
+const maxSearchYears = 5
+
+func (c cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if !has(c.months, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.hours, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if !has(c.minutes, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches проверяет день месяца и день недели. Как и в cron, если ограничены оба поля,
+// достаточно совпадения любого из них
+func (c cronSchedule) dayMatches(t time.Time) bool {
+	day := has(c.days, t.Day())
+	weekday := has(c.weekdays, int(t.Weekday()))
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func has(mask uint64, v int) bool {
+	return mask&(1<<uint(v)) != 0
+}
+
+// parseField разбирает одно поле cron в битовую маску значений из [min, max]
+func parseField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("некорректный шаг %q", stepPart)
+			}
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = parseValue(from, min, max); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = parseValue(to, min, max); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" означает от 5 до конца диапазона с шагом 15
+				hi = max
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("пустой диапазон %q", rangePart)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+func parseValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное значение %q", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("значение %d вне диапазона %d-%d", v, min, max)
+	}
+	return v, nil
+}
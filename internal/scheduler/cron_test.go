@@ -0,0 +1,73 @@
+package scheduler_test
+
+import (
+	"menu_manager/internal/scheduler"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSchedule_Next(t *testing.T) {
+	// среда 20 марта 2024, 10:07
+	now := time.Date(2024, 3, 20, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"@every 15m", now.Add(15 * time.Minute)},
+		{"*/15 * * * *", time.Date(2024, 3, 20, 10, 15, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2024, 3, 20, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 3, 20, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, 3, 21, 0, 0, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 3, 21, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 3, 20, 13, 0, 0, 0, time.UTC)},
+		{"5,10 10 * * *", time.Date(2024, 3, 20, 10, 10, 0, 0, time.UTC)},
+		// воскресенье можно задать как 0 или 7
+		{"0 20 * * 0", time.Date(2024, 3, 24, 20, 0, 0, 0, time.UTC)},
+		{"0 20 * * 7", time.Date(2024, 3, 24, 20, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, 3, 24, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)},
+		// ограничены и день месяца, и день недели: достаточно любого совпадения
+		{"0 0 1 * 5", time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := scheduler.ParseSchedule(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(now))
+		})
+	}
+}
+
+func TestParseSchedule_Never(t *testing.T) {
+	schedule, err := scheduler.ParseSchedule("0 0 31 2 *")
+	assert.NoError(t, err)
+	assert.True(t, schedule.Next(time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestParseSchedule_Invalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"10-5 * * * *",
+		"a * * * *",
+		"@every",
+		"@every 10ms",
+		"@yearly",
+	} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := scheduler.ParseSchedule(spec)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package scheduler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job выполняемая по расписанию задача
+type Job func(ctx context.Context) error
+
+// Locker захватывает именованную блокировку, общую для всех экземпляров сервиса.
+// Блокировка с истекшим ttl считается свободной, поэтому упавший экземпляр не держит ее вечно
+type Locker interface {
+	// AcquireLock захватывает блокировку name для owner на время ttl.
+	// Возвращает false, если блокировку держит другой владелец
+	AcquireLock(ctx context.Context, name, owner string, ttl time.Duration) (bool, error)
+	// ReleaseLock освобождает блокировку, если ее держит owner
+	ReleaseLock(ctx context.Context, name, owner string) error
+}
+
+// DefaultLockTTL время жизни блокировки по умолчанию, должно быть больше времени выполнения задачи
+const DefaultLockTTL = 10 * time.Minute
+
+// tickLockMargin на сколько раньше следующего запуска истекает блокировка запуска по расписанию,
+// чтобы расхождение часов экземпляров и БД не помешало захватить ее в следующий раз
+const tickLockMargin = time.Second
+
+// Scheduler запускает задачу по расписанию в фоновой горутине
+type Scheduler struct {
+	name     string
+	schedule Schedule
+	job      Job
+	locker   Locker
+	lockTTL  time.Duration
+	owner    string
+	now      func() time.Time
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Option настраивает необязательные параметры планировщика
+type Option func(*Scheduler)
+
+// WithLocker включает защиту от одновременного запуска задачи на нескольких экземплярах сервиса
+func WithLocker(locker Locker, ttl time.Duration) Option {
+	return func(s *Scheduler) {
+		s.locker = locker
+		if ttl > 0 {
+			s.lockTTL = ttl
+		}
+	}
+}
+
+// WithClock подменяет источник текущего времени, используется в тестах
+func WithClock(now func() time.Time) Option {
+	return func(s *Scheduler) {
+		s.now = now
+	}
+}
+
+// New создает планировщик задачи. name используется в логах и как имя блокировки
+func New(name string, schedule Schedule, job Job, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		name:     name,
+		schedule: schedule,
+		job:      job,
+		lockTTL:  DefaultLockTTL,
+		owner:    newOwnerID(),
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Start запускает планировщик в фоне. Повторный вызов без Stop ничего не делает
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done != nil {
+		return
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.loop(ctx, s.done)
+}
+
+// Stop останавливает планировщик и дожидается завершения текущего запуска задачи
+// или истечения ctx. Контекст задачи отменяется сразу
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel, s.done = nil, nil
+	s.mu.Unlock()
+
+	if done == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("планировщик %s не завершился: %w", s.name, ctx.Err())
+	}
+}
+
+// RunOnce выполняет задачу один раз. Если задачу уже выполняет другой экземпляр сервиса,
+// запуск пропускается и возвращается false
+func (s *Scheduler) RunOnce(ctx context.Context) (bool, error) {
+	return s.run(ctx, s.lockTTL, true)
+}
+
+// runTick выполняет запуск по расписанию, назначенный на tick. Все экземпляры просыпаются к одному и тому же tick,
+// поэтому блокировка не освобождается после задачи, а держится почти до следующего запуска:
+// иначе экземпляр, таймер которого сработал чуть позже, захватил бы ее и выполнил задачу повторно
+func (s *Scheduler) runTick(ctx context.Context, tick time.Time) (bool, error) {
+	ttl := s.lockTTL
+	if next := s.schedule.Next(tick); !next.IsZero() {
+		ttl = max(ttl, next.Sub(s.now())-tickLockMargin)
+	}
+	return s.run(ctx, ttl, false)
+}
+
+// run захватывает блокировку на ttl и выполняет задачу. release освобождает блокировку сразу после задачи
+func (s *Scheduler) run(ctx context.Context, ttl time.Duration, release bool) (bool, error) {
+	if s.locker != nil {
+		acquired, err := s.locker.AcquireLock(ctx, s.name, s.owner, ttl)
+		if err != nil {
+			return false, err
+		}
+		if !acquired {
+			return false, nil
+		}
+		if release {
+			defer func() {
+				// освобождаем блокировку даже при отмене ctx, иначе она продержится до истечения ttl
+				releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+				defer cancel()
+				if err := s.locker.ReleaseLock(releaseCtx, s.name, s.owner); err != nil {
+					slog.Error("не удалось освободить блокировку планировщика", "scheduler", s.name, "error", err)
+				}
+			}()
+		}
+	}
+
+	return true, s.job(ctx)
+}
+
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		now := s.now()
+		next := s.schedule.Next(now)
+		if next.IsZero() {
+			slog.Warn("у расписания планировщика нет следующего запуска", "scheduler", s.name)
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		started := s.now()
+		ran, err := s.runTick(ctx, next)
+		switch {
+		case err != nil:
+			slog.Error("ошибка выполнения задачи планировщика", "scheduler", s.name, "error", err)
+		case !ran:
+			slog.Info("задача планировщика уже выполняется другим экземпляром", "scheduler", s.name)
+		default:
+			slog.Info("задача планировщика выполнена", "scheduler", s.name, "duration", s.now().Sub(started))
+		}
+	}
+}
+
+// newOwnerID возвращает идентификатор экземпляра сервиса для блокировок
+func newOwnerID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
@@ -0,0 +1,155 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"menu_manager/internal/scheduler"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tick запускает задачу с интервалом меньше секунды, недоступным через ParseSchedule
+type tick time.Duration
+
+func (d tick) Next(t time.Time) time.Time { return t.Add(time.Duration(d)) }
+
+// memoryLocker хранит блокировки в памяти, истечение ttl не учитывается
+type memoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]string
+}
+
+func (l *memoryLocker) AcquireLock(_ context.Context, name, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if held, ok := l.locks[name]; ok && held != owner {
+		return false, nil
+	}
+	l.locks[name] = owner
+	return true, nil
+}
+
+func (l *memoryLocker) ReleaseLock(_ context.Context, name, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks[name] == owner {
+		delete(l.locks, name)
+	}
+	return nil
+}
+
+func TestScheduler_RunsJobUntilStopped(t *testing.T) {
+	var runs atomic.Int32
+	s := scheduler.New("test", tick(5*time.Millisecond), func(ctx context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+
+	s.Start(context.Background())
+	assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+
+	assert.NoError(t, s.Stop(context.Background()))
+	stopped := runs.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stopped, runs.Load())
+}
+
+func TestScheduler_StopWaitsForRunningJob(t *testing.T) {
+	started := make(chan struct{})
+	var finished atomic.Bool
+	s := scheduler.New("test", tick(time.Millisecond), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished.Store(true)
+		return ctx.Err()
+	})
+
+	s.Start(context.Background())
+	<-started
+
+	assert.NoError(t, s.Stop(context.Background()))
+	assert.True(t, finished.Load())
+}
+
+func TestScheduler_StopTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	s := scheduler.New("test", tick(time.Millisecond), func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	s.Start(context.Background())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, s.Stop(ctx), context.DeadlineExceeded)
+}
+
+func TestScheduler_RunOnceSkipsWhenLocked(t *testing.T) {
+	locker := &memoryLocker{locks: map[string]string{"reschedule": "other-instance"}}
+	var runs int
+	s := scheduler.New("reschedule", tick(time.Hour), func(ctx context.Context) error {
+		runs++
+		return nil
+	}, scheduler.WithLocker(locker, time.Minute))
+
+	ran, err := s.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	assert.Equal(t, 0, runs)
+
+	// другой экземпляр освободил блокировку
+	locker.ReleaseLock(context.Background(), "reschedule", "other-instance")
+
+	ran, err = s.RunOnce(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, ran)
+	assert.Equal(t, 1, runs)
+	assert.Empty(t, locker.locks, "блокировка должна освобождаться после выполнения задачи")
+}
+
+func TestScheduler_RunOnceReleasesLockOnError(t *testing.T) {
+	locker := &memoryLocker{locks: map[string]string{}}
+	jobErr := errors.New("boom")
+	s := scheduler.New("reschedule", tick(time.Hour), func(ctx context.Context) error {
+		return jobErr
+	}, scheduler.WithLocker(locker, time.Minute))
+
+	ran, err := s.RunOnce(context.Background())
+	assert.True(t, ran)
+	assert.ErrorIs(t, err, jobErr)
+	assert.Empty(t, locker.locks)
+}
+
+func TestScheduler_KeepsLockUntilNextTick(t *testing.T) {
+	locker := &memoryLocker{locks: map[string]string{}}
+	var first, late atomic.Int32
+	a := scheduler.New("reschedule", tick(time.Millisecond), func(ctx context.Context) error {
+		first.Add(1)
+		return nil
+	}, scheduler.WithLocker(locker, time.Minute))
+	b := scheduler.New("reschedule", tick(time.Millisecond), func(ctx context.Context) error {
+		late.Add(1)
+		return nil
+	}, scheduler.WithLocker(locker, time.Minute))
+
+	a.Start(context.Background())
+	assert.Eventually(t, func() bool { return first.Load() >= 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, a.Stop(context.Background()))
+
+	// экземпляр, проснувшийся позже, не должен повторить уже выполненный запуск
+	assert.NotEmpty(t, locker.locks, "блокировка запуска по расписанию держится до следующего запуска")
+	b.Start(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	assert.NoError(t, b.Stop(context.Background()))
+	assert.Equal(t, int32(0), late.Load())
+}
@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
+	"flag"
 	"log"
 	"menu_manager/internal/app"
+	"os"
+	_ "time/tzdata" // часовые пояса пользователей не должны зависеть от наличия tzdata в системе
 )
 
 func main() {
 	ctx := context.Background()
 
-	config, err := app.NewConfig("configs/config.yaml")
+	// Поля конфига можно переопределить переменными окружения, например MENU_MANAGER_DB_DSN
+	configPath := flag.String("config", "configs/config.yaml", "путь к yaml файлу конфигурации")
+	flag.Parse()
+
+	config, err := app.NewConfig(*configPath)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -19,6 +26,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// go run . migrate up|down [N]|status управляет схемой БД без запуска сервера
+	if args := flag.Args(); len(args) > 0 && args[0] == "migrate" {
+		if err = app.Migrate(ctx, config.DB.DSN, args[1:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	if err = app.Setup(ctx, config.DB.DSN, config.BarnURL); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,10 @@
+// Package migrations встраивает SQL-миграции всех поддерживаемых БД в бинарник.
+// Миграции каждой БД лежат в своем каталоге: mysql, postgres, sqlite
+package migrations
+
+import "embed"
+
+// FS содержит файлы миграций вида 000001_name.up.sql и 000001_name.down.sql
+//
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var FS embed.FS
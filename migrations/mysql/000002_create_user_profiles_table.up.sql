@@ -0,0 +1,26 @@
+CREATE TABLE user_profiles (
+    user_id VARCHAR(36) PRIMARY KEY,
+    diet VARCHAR(32) NOT NULL DEFAULT 'omnivore',
+    allergens JSON NOT NULL,
+    disliked_products JSON NOT NULL,
+    cuisines JSON NOT NULL
+);
+
+-- Категории продуктов, по которым проверяются диеты и аллергии
+CREATE TABLE product_categories (
+    product_id VARCHAR(255) NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    PRIMARY KEY (product_id, category)
+);
+
+
+-- Insert example data
+INSERT INTO product_categories (product_id, category) VALUES
+('молоко', 'dairy'),
+('молоко', 'lactose'),
+('овсяные_хлопья', 'gluten'),
+('куриное_филе', 'meat'),
+('крыса', 'meat');
+
+INSERT INTO user_profiles (user_id, diet, allergens, disliked_products, cuisines) VALUES
+('dan', 'vegetarian', JSON_ARRAY('lactose'), JSON_ARRAY(), JSON_ARRAY('french'));
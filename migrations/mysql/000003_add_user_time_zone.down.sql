@@ -0,0 +1,2 @@
+-- Down migration
+ALTER TABLE user_profiles DROP COLUMN time_zone;
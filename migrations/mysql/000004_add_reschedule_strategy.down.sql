@@ -0,0 +1,4 @@
+-- Down migration
+ALTER TABLE user_profiles
+    DROP COLUMN reschedule_strategy,
+    DROP COLUMN rotation_weeks;
@@ -0,0 +1,3 @@
+-- Down migration
+DROP INDEX menu_user_eat_date ON menu;
+DROP TABLE IF EXISTS scheduler_locks;
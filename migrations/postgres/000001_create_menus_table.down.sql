@@ -0,0 +1,3 @@
+-- Down migration
+DROP TABLE IF EXISTS dishes;
+DROP TABLE IF EXISTS menu;
@@ -0,0 +1,14 @@
+CREATE TABLE menu (
+    meal_id VARCHAR(36) PRIMARY KEY,
+    meal_type VARCHAR(255) NOT NULL,
+    eat_date TIMESTAMPTZ NOT NULL,
+    user_id VARCHAR(36) NOT NULL
+);
+
+CREATE TABLE dishes (
+    meal_id VARCHAR(36) NOT NULL,
+    dish_id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    recipie JSONB NOT NULL,
+    total_nutrition JSONB NOT NULL
+);
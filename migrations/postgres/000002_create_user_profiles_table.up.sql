@@ -0,0 +1,14 @@
+CREATE TABLE user_profiles (
+    user_id VARCHAR(36) PRIMARY KEY,
+    diet VARCHAR(32) NOT NULL DEFAULT 'omnivore',
+    allergens JSONB NOT NULL,
+    disliked_products JSONB NOT NULL,
+    cuisines JSONB NOT NULL
+);
+
+-- Категории продуктов, по которым проверяются диеты и аллергии
+CREATE TABLE product_categories (
+    product_id VARCHAR(255) NOT NULL,
+    category VARCHAR(64) NOT NULL,
+    PRIMARY KEY (product_id, category)
+);
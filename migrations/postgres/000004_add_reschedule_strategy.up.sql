@@ -0,0 +1,4 @@
+-- стратегия переноса устаревшего меню выбирается пользователем в профиле
+ALTER TABLE user_profiles
+    ADD COLUMN reschedule_strategy VARCHAR(32) NOT NULL DEFAULT 'keep-slot',
+    ADD COLUMN rotation_weeks INT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+-- блокировки фоновых задач, чтобы задачу выполнял только один экземпляр сервиса
+CREATE TABLE scheduler_locks (
+    name VARCHAR(64) PRIMARY KEY,
+    owner VARCHAR(128) NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+-- планировщик ищет пользователей с устаревшим меню по последнему приему пищи
+CREATE INDEX menu_user_eat_date ON menu (user_id, eat_date);
@@ -0,0 +1,15 @@
+-- eat_date хранится в UTC в виде текста YYYY-MM-DD HH:MM:SS, поэтому сравнивается как строка
+CREATE TABLE menu (
+    meal_id VARCHAR(36) PRIMARY KEY,
+    meal_type VARCHAR(255) NOT NULL,
+    eat_date TIMESTAMP NOT NULL,
+    user_id VARCHAR(36) NOT NULL
+);
+
+CREATE TABLE dishes (
+    meal_id VARCHAR(36) NOT NULL,
+    dish_id VARCHAR(36) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    recipie TEXT NOT NULL,
+    total_nutrition TEXT NOT NULL
+);
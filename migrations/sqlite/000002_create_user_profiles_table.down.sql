@@ -0,0 +1,3 @@
+-- Down migration
+DROP TABLE IF EXISTS product_categories;
+DROP TABLE IF EXISTS user_profiles;
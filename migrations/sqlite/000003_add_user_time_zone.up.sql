@@ -0,0 +1,3 @@
+-- eat_date хранится в UTC, часовой пояс пользователя нужен для границ дня и поиска ближайшего приема пищи
+ALTER TABLE user_profiles
+    ADD COLUMN time_zone VARCHAR(64) NOT NULL DEFAULT 'UTC';
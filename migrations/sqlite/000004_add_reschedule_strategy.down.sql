@@ -0,0 +1,3 @@
+-- Down migration
+ALTER TABLE user_profiles DROP COLUMN rotation_weeks;
+ALTER TABLE user_profiles DROP COLUMN reschedule_strategy;
@@ -0,0 +1,5 @@
+-- стратегия переноса устаревшего меню выбирается пользователем в профиле
+ALTER TABLE user_profiles
+    ADD COLUMN reschedule_strategy VARCHAR(32) NOT NULL DEFAULT 'keep-slot';
+ALTER TABLE user_profiles
+    ADD COLUMN rotation_weeks INT NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- Down migration
+DROP INDEX IF EXISTS menu_user_eat_date;
+DROP TABLE IF EXISTS scheduler_locks;
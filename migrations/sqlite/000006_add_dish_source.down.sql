@@ -0,0 +1,2 @@
+-- Down migration
+ALTER TABLE dishes DROP COLUMN source_dish_id;
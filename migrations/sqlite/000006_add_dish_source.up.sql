@@ -0,0 +1,3 @@
+-- копии блюд, созданные генератором меню, ссылаются на блюдо каталога и удаляются при замене расписания
+ALTER TABLE dishes
+    ADD COLUMN source_dish_id VARCHAR(36) NULL;